@@ -0,0 +1,38 @@
+package hooks
+
+// Event is a lifecycle event of the CLI for which plugins can register
+// hooks. Plugins register for events through the comma-separated "hookEvents"
+// option in their plugin configuration, for example:
+//
+//	"plugins": {
+//	  "my-plugin": {
+//	    "hooks": "image,build",
+//	    "hookEvents": "post-run,error,context-switch"
+//	  }
+//	}
+//
+// If no events are configured, the plugin is only invoked for [EventNextSteps].
+type Event string
+
+const (
+	// EventNextSteps is the event for which plugins are invoked after a
+	// command that matches their "hooks" configuration was executed in an
+	// interactive terminal. Plugins respond with a [HookMessage] to print
+	// hints after the output of the command.
+	EventNextSteps Event = "next-steps"
+
+	// EventPostRun is the event for which plugins are invoked after a
+	// command that matches their "hooks" configuration was executed,
+	// whether it succeeded or not, and whether the CLI is used in a
+	// terminal or not. The response of the plugin is ignored.
+	EventPostRun Event = "post-run"
+
+	// EventError is like EventPostRun, but plugins are only invoked if the
+	// command failed.
+	EventError Event = "error"
+
+	// EventContextSwitch is the event for which plugins are invoked after
+	// a command changed the current context, for example through "docker
+	// context use". The response of the plugin is ignored.
+	EventContextSwitch Event = "context-switch"
+)
@@ -0,0 +1,199 @@
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/moby/sys/atomicwriter"
+	"github.com/sirupsen/logrus"
+)
+
+// metadataCacheFileName is the name of the file in the config-directory in
+// which the metadata of CLI plugins is cached.
+const metadataCacheFileName = "cli-plugins-metadata-cache.json"
+
+// metadataCacheEntry is the cached metadata of a plugin binary. The entry is
+// only used if the modification time and size of the binary are unchanged.
+type metadataCacheEntry struct {
+	ModTime  time.Time       `json:"modTime"`
+	Size     int64           `json:"size"`
+	Metadata json.RawMessage `json:"metadata"`
+
+	// Checksum is the pinned checksum that the binary was verified against
+	// when its metadata was cached, if any.
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// metadataCache caches the metadata of plugin binaries, keyed by path. It is
+// safe for concurrent use.
+type metadataCache struct {
+	fileName string
+
+	mu      sync.Mutex
+	entries map[string]metadataCacheEntry
+	// used contains the entries that were looked up or stored, which are
+	// the entries that are written when the cache is saved, so that entries
+	// for plugins that were removed are dropped.
+	used  map[string]metadataCacheEntry
+	dirty bool
+}
+
+// metadataCacheFile returns the path of the file in which plugin metadata is
+// cached, which is stored next to the given config file.
+func metadataCacheFile(cfg *configfile.ConfigFile) string {
+	if cfg == nil || cfg.Filename == "" {
+		return filepath.Join(config.Dir(), metadataCacheFileName)
+	}
+	return filepath.Join(filepath.Dir(cfg.Filename), metadataCacheFileName)
+}
+
+// loadMetadataCache returns the metadata cache if it is enabled through
+// [ConfigFile.CLIPluginsMetadataCache], or nil otherwise. A cache file that
+// cannot be read is ignored.
+//
+// [ConfigFile.CLIPluginsMetadataCache]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsMetadataCache
+func loadMetadataCache(cfg *configfile.ConfigFile) *metadataCache {
+	if cfg == nil || !cfg.CLIPluginsMetadataCache {
+		return nil
+	}
+	fileName := metadataCacheFile(cfg)
+	entries, err := readMetadataCache(fileName)
+	if err != nil {
+		// Start over if the file is corrupt.
+		logrus.WithError(err).Debugf("Failed to read plugin metadata cache from %s. Ignoring.", fileName)
+		entries = make(map[string]metadataCacheEntry)
+	}
+	return &metadataCache{
+		fileName: fileName,
+		entries:  entries,
+		used:     make(map[string]metadataCacheEntry),
+	}
+}
+
+func readMetadataCache(fileName string) (map[string]metadataCacheEntry, error) {
+	entries := make(map[string]metadataCacheEntry)
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return entries, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// get returns the cache entry of the plugin at path, if the binary did not
+// change since it was cached.
+func (c *metadataCache) get(path string, fi os.FileInfo) (metadataCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[path]
+	if !ok || !e.ModTime.Equal(fi.ModTime()) || e.Size != fi.Size() {
+		return metadataCacheEntry{}, false
+	}
+	c.used[path] = e
+	return e, true
+}
+
+func (c *metadataCache) put(path string, fi os.FileInfo, meta []byte, checksum string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.used[path] = metadataCacheEntry{ModTime: fi.ModTime(), Size: fi.Size(), Metadata: meta, Checksum: checksum}
+	c.dirty = true
+}
+
+// save writes the entries that were used to the cache file, if any entries
+// were added or dropped. Saving is best-effort; errors are logged and
+// otherwise ignored. It is a no-op if c is nil.
+func (c *metadataCache) save() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty && len(c.used) == len(c.entries) {
+		return
+	}
+	data, err := json.Marshal(c.used)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to cache plugin metadata. Ignoring.")
+		return
+	}
+	if err := atomicwriter.WriteFile(c.fileName, data, 0o600); err != nil {
+		logrus.WithError(err).Debugf("Failed to write plugin metadata cache to %s. Ignoring.", c.fileName)
+	}
+}
+
+// wrap returns a candidate that uses the cache to fetch the metadata of cand,
+// or cand itself if c is nil (caching is disabled).
+func (c *metadataCache) wrap(cand *candidate) Candidate {
+	if c == nil {
+		return cand
+	}
+	return &cachedCandidate{candidate: cand, cache: c}
+}
+
+// cachedCandidate is a candidate that uses the metadata cache, and only runs
+// the plugin to fetch its metadata if the binary is not in the cache, or was
+// changed since it was cached.
+type cachedCandidate struct {
+	*candidate
+	cache *metadataCache
+}
+
+func (c *cachedCandidate) Metadata() ([]byte, error) {
+	fi, err := os.Stat(c.path)
+	if err != nil {
+		return c.candidate.Metadata()
+	}
+	if e, ok := c.cache.get(c.path, fi); ok {
+		// The binary was verified against the pinned checksum when it was
+		// cached, so it is only hashed again if the pinned checksum changed.
+		// The signature is always verified, as the signature policy or the
+		// trusted keys may have changed since it was cached.
+		if c.checksum != "" && c.checksum != e.Checksum {
+			if err := verifyFileChecksum(c.name, c.path, c.checksum); err != nil {
+				return nil, err
+			}
+			c.cache.put(c.path, fi, e.Metadata, c.checksum)
+		}
+		if err := c.verifySignature(); err != nil {
+			return nil, err
+		}
+		return e.Metadata, nil
+	}
+	if err := c.verify(); err != nil {
+		return nil, err
+	}
+	meta, err := c.fetchMetadata()
+	if err == nil && json.Valid(meta) {
+		c.cache.put(c.path, fi, meta, c.checksum)
+	}
+	return meta, err
+}
+
+// PruneMetadataCache removes the file in which the metadata of CLI plugins
+// is cached, and returns the number of entries it contained. Use it to clear
+// stale entries if a plugin binary was replaced without changing its
+// modification time and size.
+func PruneMetadataCache(dockerCli config.Provider) (int, error) {
+	fileName := metadataCacheFile(dockerCli.ConfigFile())
+	entries, err := readMetadataCache(fileName)
+	if err != nil {
+		// Remove the file if it is corrupt.
+		entries = nil
+	}
+	if err := os.Remove(fileName); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	return len(entries), nil
+}
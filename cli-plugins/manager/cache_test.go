@@ -0,0 +1,219 @@
+package manager
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/test"
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+// countingPlugin returns a plugin which records each time its metadata is
+// fetched in the given file.
+func countingPlugin(callsFile, version string) string {
+	return `#!/bin/sh
+echo called >> ` + callsFile + `
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing","Version":"` + version + `"}'`
+}
+
+func metadataCalls(t *testing.T, callsFile string) int {
+	t.Helper()
+	data, err := os.ReadFile(callsFile)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	assert.NilError(t, err)
+	return strings.Count(string(data), "called")
+}
+
+func TestListPluginsMetadataCache(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(), fs.WithDir("plugins"), fs.WithDir("config"))
+	defer dir.Remove()
+	callsFile := dir.Join("calls")
+	pluginPath := dir.Join("plugins", "docker-aaa")
+	assert.NilError(t, os.WriteFile(pluginPath, []byte(countingPlugin(callsFile, "v1.0.0")), 0o777))
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		Filename:                dir.Join("config", "config.json"),
+		CLIPluginsExtraDirs:     []string{dir.Join("plugins")},
+		CLIPluginsMetadataCache: true,
+	})
+
+	for i := 0; i < 2; i++ {
+		plugins, err := ListPlugins(cli, &cobra.Command{})
+		assert.NilError(t, err)
+		assert.Assert(t, is.Len(plugins, 1))
+		assert.Check(t, plugins[0].Err)
+		assert.Check(t, is.Equal(plugins[0].Version, "v1.0.0"))
+		assert.Check(t, is.Equal(metadataCalls(t, callsFile), 1), "metadata should only be fetched once")
+	}
+	entries, err := readMetadataCache(dir.Join("config", metadataCacheFileName))
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(entries, 1))
+
+	// Replacing the plugin invalidates the cached metadata.
+	assert.NilError(t, os.WriteFile(pluginPath, []byte(countingPlugin(callsFile, "v1.10.0")), 0o777))
+	plugins, err := ListPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(plugins, 1))
+	assert.Check(t, is.Equal(plugins[0].Version, "v1.10.0"))
+	assert.Check(t, is.Equal(metadataCalls(t, callsFile), 2))
+
+	// Removed plugins are dropped from the cache.
+	assert.NilError(t, os.Rename(pluginPath, dir.Join("plugins", "docker-bbb")))
+	_, err = ListPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	entries, err = readMetadataCache(dir.Join("config", metadataCacheFileName))
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(entries, 1))
+	_, ok := entries[pluginPath]
+	assert.Check(t, !ok, "removed plugin should be dropped from the cache")
+}
+
+func TestListPluginsMetadataCacheSignaturePolicy(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(), fs.WithDir("plugins"), fs.WithDir("config"))
+	defer dir.Remove()
+	callsFile := dir.Join("calls")
+	assert.NilError(t, os.WriteFile(dir.Join("plugins", "docker-aaa"), []byte(countingPlugin(callsFile, "v1.0.0")), 0o777))
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		Filename:                dir.Join("config", "config.json"),
+		CLIPluginsExtraDirs:     []string{dir.Join("plugins")},
+		CLIPluginsMetadataCache: true,
+	})
+	plugins, err := ListPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(plugins, 1))
+	assert.Check(t, plugins[0].Err)
+
+	// Unsigned plugins of which the metadata was cached before signatures
+	// were enforced are not valid.
+	cli.ConfigFile().CLIPluginsSignaturePolicy = "enforce"
+	cli.ConfigFile().CLIPluginsTrustedKeys = []string{newMinisignKey(t, "trusted1").pub}
+	plugins, err = ListPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(plugins, 1))
+	var sigErr *SignatureError
+	assert.Check(t, errors.As(plugins[0].Err, &sigErr))
+	assert.Check(t, is.Equal(metadataCalls(t, callsFile), 1))
+}
+
+func TestListPluginsMetadataCacheChecksum(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(), fs.WithDir("plugins"), fs.WithDir("config"))
+	defer dir.Remove()
+	callsFile := dir.Join("calls")
+	pluginPath := dir.Join("plugins", "docker-aaa")
+	assert.NilError(t, os.WriteFile(pluginPath, []byte(countingPlugin(callsFile, "v1.0.0")), 0o777))
+	checksum, err := fileSHA256(pluginPath)
+	assert.NilError(t, err)
+
+	var verified int
+	orig := fileSHA256
+	defer func() { fileSHA256 = orig }()
+	fileSHA256 = func(path string) (string, error) {
+		verified++
+		return orig(path)
+	}
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		Filename:                dir.Join("config", "config.json"),
+		CLIPluginsExtraDirs:     []string{dir.Join("plugins")},
+		CLIPluginsMetadataCache: true,
+		CLIPluginsChecksums:     map[string]string{"aaa": "sha256:" + checksum},
+	})
+
+	// The plugin is verified once before its metadata is cached, and not
+	// verified again while its metadata is cached.
+	for i := 0; i < 2; i++ {
+		plugins, err := ListPlugins(cli, &cobra.Command{})
+		assert.NilError(t, err)
+		assert.Assert(t, is.Len(plugins, 1))
+		assert.Check(t, plugins[0].Err)
+		assert.Check(t, is.Equal(verified, 1))
+		assert.Check(t, is.Equal(metadataCalls(t, callsFile), 1))
+	}
+
+	// Changing the pinned checksum verifies the plugin again.
+	cli.ConfigFile().CLIPluginsChecksums["aaa"] = "sha256:0000"
+	plugins, err := ListPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(plugins, 1))
+	var checksumErr *ChecksumMismatchError
+	assert.Check(t, errors.As(plugins[0].Err, &checksumErr))
+	assert.Check(t, is.Equal(verified, 2))
+	assert.Check(t, is.Equal(metadataCalls(t, callsFile), 1))
+}
+
+func TestListPluginsMetadataCacheDisabled(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(), fs.WithDir("plugins"), fs.WithDir("config"))
+	defer dir.Remove()
+	callsFile := dir.Join("calls")
+	assert.NilError(t, os.WriteFile(dir.Join("plugins", "docker-aaa"), []byte(countingPlugin(callsFile, "v1.0.0")), 0o777))
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		Filename:            dir.Join("config", "config.json"),
+		CLIPluginsExtraDirs: []string{dir.Join("plugins")},
+	})
+	for i := 0; i < 2; i++ {
+		_, err := ListPlugins(cli, &cobra.Command{})
+		assert.NilError(t, err)
+	}
+	assert.Check(t, is.Equal(metadataCalls(t, callsFile), 2))
+	_, err := os.Stat(dir.Join("config", metadataCacheFileName))
+	assert.Check(t, os.IsNotExist(err))
+}
+
+func TestListPluginsMetadataCacheCorrupt(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("plugins", fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777))),
+		fs.WithDir("config", fs.WithFile(metadataCacheFileName, "not json")),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		Filename:                dir.Join("config", "config.json"),
+		CLIPluginsExtraDirs:     []string{dir.Join("plugins")},
+		CLIPluginsMetadataCache: true,
+	})
+	plugins, err := ListPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(plugins, 1))
+	assert.Check(t, plugins[0].Err)
+
+	entries, err := readMetadataCache(dir.Join("config", metadataCacheFileName))
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(entries, 1))
+}
+
+func TestPruneMetadataCache(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(), fs.WithFile(metadataCacheFileName, `{
+  "/usr/libexec/docker/cli-plugins/docker-aaa": {"modTime":"2024-01-01T00:00:00Z","size":10,"metadata":{}},
+  "/usr/libexec/docker/cli-plugins/docker-bbb": {"modTime":"2024-01-01T00:00:00Z","size":10,"metadata":{}}
+}`))
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{Filename: dir.Join("config.json")})
+
+	n, err := PruneMetadataCache(cli)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(n, 2))
+	_, err = os.Stat(dir.Join(metadataCacheFileName))
+	assert.Check(t, os.IsNotExist(err))
+
+	n, err = PruneMetadataCache(cli)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(n, 0))
+}
@@ -1,9 +1,15 @@
 package manager
 
 import (
-	"os/exec"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/docker/cli/cli-plugins/metadata"
+	"github.com/docker/cli/cli/config/configfile"
 )
 
 // Candidate represents a possible plugin candidate, for mocking purposes
@@ -14,12 +20,115 @@ type Candidate interface {
 
 type candidate struct {
 	path string
+
+	// name is the name of the plugin, which is used to determine the
+	// prefix of the file name of the plugin. If empty, the prefix is
+	// assumed to be metadata.NamePrefix.
+	name string
+
+	// ctx is used to cancel fetching the metadata of the plugin. If nil,
+	// context.Background is used.
+	ctx context.Context
+
+	// metadataTimeout is the maximum time to wait for the plugin to
+	// return its metadata. A zero value means no timeout.
+	metadataTimeout time.Duration
+
+	// verifier, if set and enforcing, is used to verify the signature of
+	// the plugin before running it to fetch its metadata.
+	verifier *signatureVerifier
+
+	// checksum, if set, is the SHA256 checksum that is pinned for the plugin,
+	// which is verified before running the plugin to fetch its metadata.
+	checksum string
+
+	// cfg, if set, is used to run the plugin through the exec wrapper that
+	// is configured through ConfigFile.CLIPluginsExecWrapper, if any.
+	cfg *configfile.ConfigFile
 }
 
 func (c *candidate) Path() string {
 	return c.path
 }
 
+// namePrefix returns the prefix of the file name of the plugin, which is
+// metadata.NamePrefix unless the plugin was discovered with one of the
+// prefixes configured through ConfigFile.CLIPluginsPrefixes.
+func (c *candidate) namePrefix() string {
+	if c.name == "" {
+		return metadata.NamePrefix
+	}
+	fileName, err := trimPluginSuffix(filepath.Base(c.path))
+	if err != nil || !strings.HasSuffix(fileName, c.name) {
+		return metadata.NamePrefix
+	}
+	return strings.TrimSuffix(fileName, c.name)
+}
+
+// verify verifies the pinned checksum of the plugin, if any, and returns a
+// [*ChecksumMismatchError] if it does not match. If signatures are enforced,
+// it also verifies the signature of the plugin, and returns a
+// [*SignatureError] if it is not valid.
+func (c *candidate) verify() error {
+	if c.checksum != "" {
+		if err := verifyFileChecksum(c.name, c.path, c.checksum); err != nil {
+			return err
+		}
+	}
+	return c.verifySignature()
+}
+
+// verifySignature verifies the signature of the plugin if signatures are
+// enforced, and returns a [*SignatureError] if it is not valid.
+func (c *candidate) verifySignature() error {
+	if c.verifier != nil && c.verifier.enforce {
+		if err := c.verifier.verify(c.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (c *candidate) Metadata() ([]byte, error) {
-	return exec.Command(c.path, metadata.MetadataSubcommandName).Output() // #nosec G204 -- ignore "Subprocess launched with a potential tainted input or cmd arguments"
+	// Verify the plugin before running it, so that plugins that do not
+	// match their pinned checksum or signature are never executed.
+	if err := c.verify(); err != nil {
+		return nil, err
+	}
+	return c.fetchMetadata()
+}
+
+// fetchMetadata runs the plugin to fetch its metadata, or reads it from the
+// manifest of a containerized plugin. The plugin must be verified before.
+func (c *candidate) fetchMetadata() ([]byte, error) {
+	if isContainerPlugin(c.path) {
+		// Containerized plugins declare their metadata in their manifest,
+		// so that the image does not have to be pulled and run.
+		m, err := readContainerPluginManifest(c.path)
+		if err != nil {
+			return nil, err
+		}
+		return m.Metadata, nil
+	}
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if c.metadataTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.metadataTimeout)
+		defer cancel()
+	}
+	// Plugins are run through the exec wrapper, if configured, so that
+	// fetching their metadata is subject to the same restrictions as
+	// running them.
+	cmd := pluginExecCommandContext(ctx, c.cfg, c.path, []string{metadata.MetadataSubcommandName})
+	// Don't wait for (grand)children of the plugin which may be holding
+	// on to stdout after the plugin itself was killed.
+	cmd.WaitDelay = time.Second
+	out, err := cmd.Output()
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %s", c.metadataTimeout)
+	}
+	return out, err
 }
@@ -65,7 +65,9 @@ func TestValidateCandidate(t *testing.T) {
 		{name: "builtin command", c: &fakeCandidate{path: builtinName}, invalid: `plugin "builtin" duplicates builtin command`},
 		{name: "builtin alias", c: &fakeCandidate{path: builtinAlias}, invalid: `plugin "alias" duplicates an alias of builtin command "builtin"`},
 		{name: "fetch failure", c: &fakeCandidate{path: goodPluginPath, exec: false}, invalid: fmt.Sprintf("failed to fetch metadata: faked a failure to exec %q", goodPluginPath)},
-		{name: "metadata not json", c: &fakeCandidate{path: goodPluginPath, exec: true, meta: `xyzzy`}, invalid: "invalid character"},
+		{name: "metadata not json", c: &fakeCandidate{path: goodPluginPath, exec: true, meta: "xyzzy\nplugh"}, invalid: `invalid metadata output: expected JSON, but the plugin printed "xyzzy"`},
+		{name: "metadata empty", c: &fakeCandidate{path: goodPluginPath, exec: true, meta: ""}, invalid: "invalid metadata output: expected JSON, but the plugin printed nothing"},
+		{name: "metadata invalid json", c: &fakeCandidate{path: goodPluginPath, exec: true, meta: `{xyzzy}`}, invalid: "invalid metadata: invalid character"},
 		{name: "empty schemaversion", c: &fakeCandidate{path: goodPluginPath, exec: true, meta: `{}`}, invalid: `plugin SchemaVersion "" is not valid`},
 		{name: "invalid schemaversion", c: &fakeCandidate{path: goodPluginPath, exec: true, meta: `{"SchemaVersion": "xyzzy"}`}, invalid: `plugin SchemaVersion "xyzzy" is not valid`},
 		{name: "no vendor", c: &fakeCandidate{path: goodPluginPath, exec: true, meta: `{"SchemaVersion": "0.1.0"}`}, invalid: "plugin metadata does not define a vendor"},
@@ -93,6 +95,17 @@ func TestValidateCandidate(t *testing.T) {
 	}
 }
 
+func TestValidateCandidateUnknownFields(t *testing.T) {
+	const meta = `{"SchemaVersion": "0.1.0", "Vendor": "e2e-testing", "SomeFutureField": {"key": "value"}}`
+
+	p, err := newPlugin(&fakeCandidate{path: "/usr/local/libexec/cli-plugins/docker-goodplugin", exec: true, meta: meta}, nil)
+	assert.NilError(t, err)
+	assert.NilError(t, p.Err)
+	assert.Equal(t, p.SchemaVersion, "0.1.0")
+	assert.Equal(t, p.Vendor, "e2e-testing")
+	assert.Equal(t, string(p.RawMetadata), meta)
+}
+
 func TestCandidatePath(t *testing.T) {
 	exp := "/some/path"
 	cand := &candidate{path: exp}
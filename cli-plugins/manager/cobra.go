@@ -1,8 +1,11 @@
 package manager
 
 import (
+	"bytes"
 	"fmt"
-	"os"
+	"io"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/docker/cli/cli-plugins/metadata"
@@ -22,7 +25,14 @@ func AddPluginCommandStubs(dockerCLI config.Provider, rootCmd *cobra.Command) (e
 		if err != nil {
 			return
 		}
+		seen := make(map[string]bool, len(plugins))
 		for _, p := range plugins {
+			// Only add a stub for the plugin that takes precedence if
+			// shadowed plugins with the same name are listed.
+			if seen[p.Name] {
+				continue
+			}
+			seen[p.Name] = true
 			vendor := p.Vendor
 			if vendor == "" {
 				vendor = "unknown"
@@ -37,9 +47,10 @@ func AddPluginCommandStubs(dockerCLI config.Provider, rootCmd *cobra.Command) (e
 			}
 			rootCmd.AddCommand(&cobra.Command{
 				Use:                p.Name,
-				Short:              p.ShortDescription,
+				Short:              p.localizedShortDescription(),
 				Run:                func(_ *cobra.Command, _ []string) {},
 				Annotations:        annotations,
+				Hidden:             p.Hidden,
 				DisableFlagParsing: true,
 				RunE: func(cmd *cobra.Command, args []string) error {
 					flags := rootCmd.PersistentFlags()
@@ -49,29 +60,92 @@ func AddPluginCommandStubs(dockerCLI config.Provider, rootCmd *cobra.Command) (e
 						return err
 					}
 					if flags.Changed("help") {
-						cmd.HelpFunc()(rootCmd, args)
-						return nil
+						// Forward the arguments to the plugin, so that the
+						// plugin's own help is shown, instead of the help of
+						// the stub.
+						helpcmd, _, err := pluginRunCommand(dockerCLI, p.Name, append([]string{p.Name}, args...), rootCmd, func(err error) {
+							_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "WARNING: %v\n", err)
+						})
+						if err != nil {
+							cmd.HelpFunc()(rootCmd, args)
+							return nil
+						}
+						helpcmd.Stdout = cmd.OutOrStdout()
+						helpcmd.Stderr = cmd.ErrOrStderr()
+						return helpcmd.Run()
 					}
 					return fmt.Errorf("docker: unknown command: docker %s\n\nRun 'docker --help' for more information", cmd.Name())
 				},
-				ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-					// Delegate completion to plugin
-					cargs := []string{p.Path, cobra.ShellCompRequestCmd, p.Name}
-					cargs = append(cargs, args...)
-					cargs = append(cargs, toComplete)
-					os.Args = cargs
-					runCommand, runErr := PluginRunCommand(dockerCLI, p.Name, cmd)
-					if runErr != nil {
-						return nil, cobra.ShellCompDirectiveError
-					}
-					runErr = runCommand.Run()
-					if runErr == nil {
-						os.Exit(0) // plugin already rendered complete data
-					}
-					return nil, cobra.ShellCompDirectiveError
+				ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+					return completePluginCommand(dockerCLI, rootCmd, p.Name, args, toComplete)
 				},
 			})
 		}
 	})
 	return err
 }
+
+// CompletePluginNames returns a completion function that completes the names
+// of the valid plugins that are installed, omitting plugins that failed one
+// of the candidate tests. Only names that start with the given prefix are
+// returned.
+func CompletePluginNames(dockerCLI config.Provider) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		plugins, err := ListValidPlugins(dockerCLI, cmd.Root())
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		names := make([]string, 0, len(plugins))
+		for _, p := range plugins {
+			if strings.HasPrefix(p.Name, toComplete) {
+				names = append(names, p.Name)
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completePluginCommand delegates the completion of the flags and arguments of
+// a plugin command to the plugin. It runs the hidden completion command of the
+// plugin ("__complete"), which is provided by cobra, and parses its output,
+// so that completions are rendered by the CLI's own completion command.
+func completePluginCommand(dockerCLI config.Provider, rootCmd *cobra.Command, name string, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cargs := make([]string, 0, len(args)+3)
+	cargs = append(cargs, cobra.ShellCompRequestCmd, name)
+	cargs = append(cargs, args...)
+	cargs = append(cargs, toComplete)
+	cmd, _, err := pluginRunCommand(dockerCLI, name, cargs, rootCmd, nil)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var out bytes.Buffer
+	cmd.Stdin = nil
+	cmd.Stdout = &out
+	cmd.Stderr = io.Discard
+	if err := cmd.Run(); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return parseCompletions(out.String())
+}
+
+// parseCompletions parses the output of cobra's completion command, which
+// has a completion per line, followed by a line with the directive (for
+// example, ":4").
+func parseCompletions(out string) ([]string, cobra.ShellCompDirective) {
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	last := lines[len(lines)-1]
+	if !strings.HasPrefix(last, ":") {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	directive, err := strconv.Atoi(last[1:])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	completions := make([]string, 0, len(lines)-1)
+	for _, l := range lines[:len(lines)-1] {
+		if l != "" {
+			completions = append(completions, l)
+		}
+	}
+	return completions, cobra.ShellCompDirective(directive)
+}
@@ -1,10 +1,16 @@
 package manager
 
 import (
+	"bytes"
+	"sync"
 	"testing"
 
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/test"
 	"github.com/spf13/cobra"
 	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
 )
 
 func TestPluginResourceAttributesEnvvar(t *testing.T) {
@@ -24,3 +30,186 @@ func TestPluginResourceAttributesEnvvar(t *testing.T) {
 	env = appendPluginResourceAttributesEnvvar(nil, cmd, Plugin{Name: "compose"})
 	assert.DeepEqual(t, []string{"OTEL_RESOURCE_ATTRIBUTES=a.b.c=foo,docker.cli.cobra.command_path=docker%20compose"}, env)
 }
+
+func TestCompletePluginNames(t *testing.T) {
+	const script = `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("plugins1",
+			fs.WithFile("docker-buildx", script, fs.WithMode(0o777)),
+			fs.WithFile("docker-broken", `#!/bin/sh
+echo 'not json'`, fs.WithMode(0o777)),
+		),
+		fs.WithDir("plugins2",
+			fs.WithFile("docker-buildx", script, fs.WithMode(0o777)),
+			fs.WithFile("docker-compose", script, fs.WithMode(0o777)),
+		),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Join("plugins1"), dir.Join("plugins2")}})
+	complete := CompletePluginNames(cli)
+
+	names, directive := complete(&cobra.Command{}, nil, "")
+	assert.DeepEqual(t, names, []string{"buildx", "compose"})
+	assert.Equal(t, directive, cobra.ShellCompDirectiveNoFileComp)
+
+	names, _ = complete(&cobra.Command{}, nil, "b")
+	assert.DeepEqual(t, names, []string{"buildx"})
+
+	names, _ = complete(&cobra.Command{}, nil, "x")
+	assert.DeepEqual(t, names, []string{})
+}
+
+func TestAddPluginCommandStubsHidden(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-visible", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-internal", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing","Hidden":true}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	pluginCommandStubsOnce = sync.Once{}
+	defer func() { pluginCommandStubsOnce = sync.Once{} }()
+
+	root := &cobra.Command{Use: "docker"}
+	assert.NilError(t, AddPluginCommandStubs(cli, root))
+
+	var available []string
+	for _, cmd := range root.Commands() {
+		if cmd.IsAvailableCommand() {
+			available = append(available, cmd.Name())
+		}
+	}
+	assert.DeepEqual(t, available, []string{"visible"})
+
+	p, err := GetPlugin("internal", cli, root)
+	assert.NilError(t, err)
+	assert.NilError(t, p.Err)
+	assert.Check(t, p.Hidden)
+
+	_, err = PluginRunCommand(cli, "internal", root)
+	assert.NilError(t, err)
+}
+
+func TestAddPluginCommandStubsLocalized(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-localized", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing","ShortDescription":"Manage widgets","ShortDescriptions":{"fr":"Gérer les widgets"}}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-fallback", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing","ShortDescription":"Manage gadgets","ShortDescriptions":{"de":"Geräte verwalten"}}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "fr")
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	pluginCommandStubsOnce = sync.Once{}
+	defer func() { pluginCommandStubsOnce = sync.Once{} }()
+
+	root := &cobra.Command{Use: "docker"}
+	assert.NilError(t, AddPluginCommandStubs(cli, root))
+
+	short := map[string]string{}
+	for _, cmd := range root.Commands() {
+		short[cmd.Name()] = cmd.Short
+	}
+	assert.Check(t, is.Equal(short["localized"], "Gérer les widgets"))
+	assert.Check(t, is.Equal(short["fallback"], "Manage gadgets"))
+}
+
+func TestPluginCommandStubHelp(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-helpful", `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+echo "helpful plugin help: $*"`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	for _, flag := range []string{"--help", "-h"} {
+		t.Run(flag, func(t *testing.T) {
+			pluginCommandStubsOnce = sync.Once{}
+			defer func() { pluginCommandStubsOnce = sync.Once{} }()
+
+			root := &cobra.Command{Use: "docker"}
+			root.PersistentFlags().BoolP("help", "h", false, "Print usage")
+			assert.NilError(t, AddPluginCommandStubs(cli, root))
+
+			var out bytes.Buffer
+			root.SetOut(&out)
+			root.SetArgs([]string{"helpful", flag})
+			assert.NilError(t, root.Execute())
+			assert.Check(t, is.Equal(out.String(), "helpful plugin help: helpful "+flag+"\n"))
+		})
+	}
+}
+
+func TestCompletePluginCommand(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(), fs.WithFile("docker-buildx", `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+[ "$*" = "__complete buildx --builder default b" ] || exit 1
+printf 'build\tStart a build\nbake\n:4\n'
+`, fs.WithMode(0o777)))
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	completions, directive := completePluginCommand(cli, &cobra.Command{Use: "docker"}, "buildx", []string{"--builder", "default"}, "b")
+	assert.Check(t, is.DeepEqual(completions, []string{"build\tStart a build", "bake"}))
+	assert.Check(t, is.Equal(directive, cobra.ShellCompDirectiveNoFileComp))
+
+	completions, directive = completePluginCommand(cli, &cobra.Command{Use: "docker"}, "nosuchplugin", nil, "")
+	assert.Check(t, is.Len(completions, 0))
+	assert.Check(t, is.Equal(directive, cobra.ShellCompDirectiveError))
+}
+
+func TestParseCompletions(t *testing.T) {
+	testCases := []struct {
+		out                 string
+		expectedCompletions []string
+		expectedDirective   cobra.ShellCompDirective
+	}{
+		{
+			out:                 ":4\n",
+			expectedCompletions: []string{},
+			expectedDirective:   cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			out:                 "foo\nbar\tdescription\n:0\n",
+			expectedCompletions: []string{"foo", "bar\tdescription"},
+			expectedDirective:   cobra.ShellCompDirectiveDefault,
+		},
+		{
+			out:               "foo\nbar\n",
+			expectedDirective: cobra.ShellCompDirectiveError,
+		},
+		{
+			out:               "",
+			expectedDirective: cobra.ShellCompDirectiveError,
+		},
+	}
+	for _, tc := range testCases {
+		completions, directive := parseCompletions(tc.out)
+		assert.Check(t, is.DeepEqual(completions, tc.expectedCompletions))
+		assert.Check(t, is.Equal(directive, tc.expectedDirective))
+	}
+}
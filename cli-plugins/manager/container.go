@@ -0,0 +1,165 @@
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/context/docker"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// containerManifestSuffix is the suffix of the manifest of a
+	// containerized plugin, for example "docker-foo.json".
+	containerManifestSuffix = ".json"
+
+	// containerDockerSocket is the path at which the Docker API socket is
+	// mounted into containerized plugins. It is also the path of the socket
+	// on the daemon host if the CLI does not connect to the daemon through a
+	// unix socket, unless configured otherwise.
+	containerDockerSocket = "/var/run/docker.sock"
+
+	// containerPluginLabel is the label that is set on the containers of
+	// containerized plugins, with the name of the plugin as value.
+	containerPluginLabel = "com.docker.cli.plugin"
+)
+
+// containerPluginManifest is the manifest of a containerized plugin, which is
+// distributed as an image instead of a binary. The manifest is stored in a
+// plugin directory as "docker-<name>.json".
+type containerPluginManifest struct {
+	// Image is the reference of the image of the plugin. The entrypoint of
+	// the image must be the plugin binary.
+	Image string `json:"image"`
+	// Metadata is the metadata of the plugin, which is used instead of
+	// running the plugin to fetch its metadata.
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+// isContainerPlugin returns whether the candidate at path is the manifest of
+// a containerized plugin.
+func isContainerPlugin(path string) bool {
+	return strings.HasSuffix(filepath.Base(path), containerManifestSuffix)
+}
+
+// trimPluginSuffix trims the suffix of the file name of a plugin candidate,
+// which is either the manifest suffix of a containerized plugin, or the
+// platform's executable suffix.
+func trimPluginSuffix(s string) (string, error) {
+	if strings.HasSuffix(s, containerManifestSuffix) {
+		return strings.TrimSuffix(s, containerManifestSuffix), nil
+	}
+	return trimExeSuffix(s)
+}
+
+func readContainerPluginManifest(path string) (*containerPluginManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m containerPluginManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid container plugin manifest: %w", err)
+	}
+	if m.Image == "" {
+		return nil, errors.New("invalid container plugin manifest: no image specified")
+	}
+	if len(m.Metadata) == 0 {
+		return nil, errors.New("invalid container plugin manifest: no metadata specified")
+	}
+	return &m, nil
+}
+
+// containerRunOptions are the options to run a containerized plugin.
+type containerRunOptions struct {
+	// globalArgs are the global options that the CLI was invoked with, such
+	// as "--context", which are passed to "docker run", so that the plugin
+	// runs on the same daemon as the CLI.
+	globalArgs []string
+	// socket is the path of the Docker API socket on the daemon host, which
+	// is mounted into the container.
+	socket string
+	// tty allocates a TTY for the container.
+	tty bool
+}
+
+// newContainerRunOptions returns the options to run containerized plugins for
+// the CLI, which was invoked with the given arguments (excluding the name of
+// the binary). The global options in args are determined using the flags of
+// rootcmd. dockerCli and rootcmd may be nil.
+func newContainerRunOptions(dockerCli config.Provider, rootcmd *cobra.Command, args []string) containerRunOptions {
+	opts := containerRunOptions{socket: containerSocket(dockerCli)}
+	if i := subcommandIndex(rootcmd, args); i >= 0 {
+		opts.globalArgs = args[:i]
+	}
+	return opts
+}
+
+// containerSocket returns the path of the Docker API socket on the daemon
+// host, which is the socket configured through
+// [ConfigFile.CLIPluginsContainerSocket], or the socket that the CLI connects
+// to if it connects to the daemon through a unix socket, for example for a
+// rootless daemon. Otherwise, the daemon is assumed to listen on the default
+// socket on its host.
+//
+// [ConfigFile.CLIPluginsContainerSocket]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsContainerSocket
+func containerSocket(dockerCli config.Provider) string {
+	if dockerCli == nil {
+		return containerDockerSocket
+	}
+	if cfg := dockerCli.ConfigFile(); cfg != nil && cfg.CLIPluginsContainerSocket != "" {
+		return cfg.CLIPluginsContainerSocket
+	}
+	if ep, ok := dockerCli.(interface{ DockerEndpoint() docker.Endpoint }); ok {
+		if socket, ok := strings.CutPrefix(ep.DockerEndpoint().Host, "unix://"); ok && socket != "" {
+			return socket
+		}
+	}
+	return containerDockerSocket
+}
+
+// containerPluginCommand returns the command and arguments to run the
+// containerized plugin with the given name at path with the given arguments,
+// using the CLI to run the plugin's image in a container. The container is
+// removed when the plugin exits. Stdin is attached to the container, and a
+// TTY is allocated if opts.tty is set. The Docker API socket of the daemon
+// is mounted into the container, so that the plugin can use the daemon.
+func containerPluginCommand(name, path string, args []string, opts containerRunOptions) (string, []string, error) {
+	m, err := readContainerPluginManifest(path)
+	if err != nil {
+		return "", nil, err
+	}
+	socket := opts.socket
+	if socket == "" {
+		socket = containerDockerSocket
+	}
+
+	runArgs := make([]string, 0, len(opts.globalArgs)+len(args)+11)
+	runArgs = append(runArgs, opts.globalArgs...)
+	runArgs = append(runArgs, "run", "--rm", "--interactive")
+	if opts.tty {
+		runArgs = append(runArgs, "--tty")
+	}
+	runArgs = append(runArgs,
+		"--label", containerPluginLabel+"="+name,
+		"--volume", socket+":"+containerDockerSocket,
+		"--env", "DOCKER_HOST=unix://"+containerDockerSocket,
+		m.Image,
+	)
+	runArgs = append(runArgs, args...)
+	return dockerExecutable(), runArgs, nil
+}
+
+// dockerExecutable returns the path of the CLI binary that is running, which
+// is used to run containerized plugins.
+func dockerExecutable() string {
+	if exe, err := os.Executable(); err == nil {
+		return exe
+	}
+	return os.Args[0]
+}
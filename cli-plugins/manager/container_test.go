@@ -0,0 +1,167 @@
+package manager
+
+import (
+	"os"
+	"testing"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/cli/context/docker"
+	"github.com/docker/cli/internal/test"
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+const containerPluginManifestJSON = `{
+	"image": "example.com/plugins/foo:1.0",
+	"metadata": {"SchemaVersion":"0.1.0","Vendor":"e2e-testing","Version":"1.0.0"}
+}`
+
+func TestListPluginCandidatesContainer(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-foo.json", containerPluginManifestJSON),
+	)
+	defer dir.Remove()
+
+	candidates := listPluginCandidates([]string{dir.Path()})
+	assert.DeepEqual(t, candidates, map[string][]string{
+		"foo": {dir.Join("docker-foo.json")},
+	})
+}
+
+func TestGetPluginContainer(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		// The manifest is not executable; its metadata is read without
+		// running the plugin.
+		fs.WithFile("docker-foo.json", containerPluginManifestJSON),
+		fs.WithFile("docker-bar.json", `{"metadata": {"SchemaVersion":"0.1.0"}}`),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	p, err := GetPlugin("foo", cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.NilError(t, p.Err)
+	assert.Equal(t, p.Name, "foo")
+	assert.Equal(t, p.Version, "1.0.0")
+
+	p, err = GetPlugin("bar", cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.ErrorContains(t, p.Err, "no image specified")
+}
+
+func TestPluginRunCommandContainer(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-foo.json", containerPluginManifestJSON),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	cmd, err := PluginRunCommand(cli, "foo", &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Equal(t, cmd.Path, dockerExecutable())
+	// The arguments of the CLI are passed to the plugin after the image.
+	assert.DeepEqual(t, cmd.Args[1:11], []string{
+		"run", "--rm", "--interactive",
+		"--label", "com.docker.cli.plugin=foo",
+		"--volume", "/var/run/docker.sock:/var/run/docker.sock",
+		"--env", "DOCKER_HOST=unix:///var/run/docker.sock",
+		"example.com/plugins/foo:1.0",
+	})
+}
+
+func TestPluginRunCommandContainerGlobalOptions(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-foo.json", containerPluginManifestJSON),
+	)
+	defer dir.Remove()
+
+	origArgs := os.Args
+	defer func() { os.Args = origArgs }()
+	os.Args = []string{"docker", "--context", "foo", "-D", "foo", "build", "--context", "."}
+
+	rootCmd := &cobra.Command{Use: "docker"}
+	rootCmd.Flags().StringP("context", "c", "", "")
+	rootCmd.Flags().BoolP("debug", "D", false, "")
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+	cli.SetDockerEndpoint(docker.Endpoint{EndpointMeta: docker.EndpointMeta{Host: "unix:///run/user/1000/docker.sock"}})
+
+	cmd, err := PluginRunCommand(cli, "foo", rootCmd)
+	assert.NilError(t, err)
+	// The global options are passed to "docker run", and the socket of the
+	// daemon that the CLI connects to is mounted into the container.
+	assert.Check(t, is.DeepEqual(cmd.Args[1:], []string{
+		"--context", "foo", "-D",
+		"run", "--rm", "--interactive",
+		"--label", "com.docker.cli.plugin=foo",
+		"--volume", "/run/user/1000/docker.sock:/var/run/docker.sock",
+		"--env", "DOCKER_HOST=unix:///var/run/docker.sock",
+		"example.com/plugins/foo:1.0", "foo", "build", "--context", ".",
+	}))
+}
+
+func TestContainerSocket(t *testing.T) {
+	cli := test.NewFakeCli(nil)
+	assert.Check(t, is.Equal(containerSocket(nil), "/var/run/docker.sock"))
+
+	// Remote daemons are assumed to listen on the default socket.
+	cli.SetDockerEndpoint(docker.Endpoint{EndpointMeta: docker.EndpointMeta{Host: "ssh://user@example.com"}})
+	assert.Check(t, is.Equal(containerSocket(cli), "/var/run/docker.sock"))
+
+	cli.SetDockerEndpoint(docker.Endpoint{EndpointMeta: docker.EndpointMeta{Host: "unix:///run/user/1000/docker.sock"}})
+	assert.Check(t, is.Equal(containerSocket(cli), "/run/user/1000/docker.sock"))
+
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsContainerSocket: "/run/docker-remote.sock"})
+	assert.Check(t, is.Equal(containerSocket(cli), "/run/docker-remote.sock"))
+}
+
+func TestContainerPluginCommand(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-foo.json", containerPluginManifestJSON),
+		fs.WithFile("docker-invalid.json", `{"image": `),
+	)
+	defer dir.Remove()
+
+	_, args, err := containerPluginCommand("foo", dir.Join("docker-foo.json"), []string{"foo", "--flag", "arg"}, containerRunOptions{
+		globalArgs: []string{"--context", "remote"},
+		socket:     "/run/user/1000/docker.sock",
+		tty:        true,
+	})
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(args, []string{
+		"--context", "remote",
+		"run", "--rm", "--interactive", "--tty",
+		"--label", "com.docker.cli.plugin=foo",
+		"--volume", "/run/user/1000/docker.sock:/var/run/docker.sock",
+		"--env", "DOCKER_HOST=unix:///var/run/docker.sock",
+		"example.com/plugins/foo:1.0", "foo", "--flag", "arg",
+	}))
+
+	_, _, err = containerPluginCommand("invalid", dir.Join("docker-invalid.json"), nil, containerRunOptions{})
+	assert.Check(t, is.ErrorContains(err, "invalid container plugin manifest"))
+}
+
+func TestDiagnoseContainerPlugin(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-foo.json", containerPluginManifestJSON),
+		fs.WithFile("docker-bar.json", `{"image": "example.com/plugins/bar:1.0"}`),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	candidates, err := DiagnosePluginCandidates(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(candidates["foo"], 1))
+	assert.Check(t, candidates["foo"][0].Err == nil, "manifests are not expected to be executable")
+	assert.Assert(t, is.Len(candidates["bar"], 1))
+	assert.Check(t, is.Equal(candidates["bar"][0].Problem, ProblemMetadataFailed))
+}
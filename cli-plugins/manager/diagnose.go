@@ -0,0 +1,159 @@
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"runtime"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/spf13/cobra"
+)
+
+// CandidateProblem classifies why a CLI plugin candidate is not a valid
+// plugin.
+type CandidateProblem string
+
+const (
+	// ProblemBrokenSymlink is reported for candidates that are a symlink
+	// to a file that does not exist.
+	ProblemBrokenSymlink CandidateProblem = "broken symlink"
+	// ProblemNotExecutable is reported for candidates that do not have
+	// execute permissions, or could not be executed due to permissions.
+	ProblemNotExecutable CandidateProblem = "not executable"
+	// ProblemWrongPlatform is reported for candidates that were built for
+	// a different OS or architecture, or declare that they do not support
+	// the current platform or CLI version.
+	ProblemWrongPlatform CandidateProblem = "incompatible"
+	// ProblemMetadataFailed is reported for candidates that failed or timed
+	// out when fetching their metadata.
+	ProblemMetadataFailed CandidateProblem = "metadata failed"
+	// ProblemInvalidMetadata is reported for candidates that returned
+	// metadata that is not valid JSON.
+	ProblemInvalidMetadata CandidateProblem = "invalid metadata"
+	// ProblemSchemaVersion is reported for candidates that returned
+	// metadata with a schema version that is not supported.
+	ProblemSchemaVersion CandidateProblem = "schema version mismatch"
+	// ProblemSignature is reported for candidates of which the signature
+	// could not be verified.
+	ProblemSignature CandidateProblem = "signature"
+	// ProblemInvalid is reported for candidates that are not valid for
+	// other reasons, for example because their name conflicts with a
+	// builtin command.
+	ProblemInvalid CandidateProblem = "invalid"
+)
+
+// CandidateDiagnostic is the result of validating a CLI plugin candidate.
+type CandidateDiagnostic struct {
+	// Path is the path of the candidate.
+	Path string
+	// Active is true if this is the candidate that is run for the plugin
+	// name. Only the candidate with the highest precedence can be active,
+	// and only if it is valid.
+	Active bool
+	// Shadowed is true if another candidate with the same name takes
+	// precedence over this candidate.
+	Shadowed bool
+	// Err is non-nil if the candidate is not a valid plugin, for example
+	// because it returned invalid metadata, or could not be executed.
+	Err error
+	// Problem classifies Err. It is empty if Err is nil.
+	Problem CandidateProblem
+}
+
+// DiagnosePluginCandidates validates all CLI plugin candidates on the system,
+// including candidates that are shadowed by a candidate with the same name in
+// a directory with a higher precedence. The results are keyed by plugin name,
+// and are in order of precedence. Unlike ListPlugins, it runs every
+// candidate, so it is intended to diagnose why a plugin is not used.
+func DiagnosePluginCandidates(dockerCli config.Provider, rootcmd *cobra.Command) (map[string][]CandidateDiagnostic, error) {
+	cfg := dockerCli.ConfigFile()
+	metadataTimeout := getMetadataTimeout(cfg)
+	verifier := newSignatureVerifier(cfg)
+	candidates := listConfiguredPluginCandidates(cfg)
+	ctx := commandContext(rootcmd)
+	cmds := rootcmd.Commands()
+
+	results := make(map[string][]CandidateDiagnostic, len(candidates))
+	for name, paths := range candidates {
+		diags := make([]CandidateDiagnostic, 0, len(paths))
+		for i, path := range paths {
+			c := &recordingCandidate{candidate: &candidate{path: path, name: name, ctx: ctx, metadataTimeout: metadataTimeout, verifier: verifier, checksum: pinnedChecksum(cfg, name), cfg: cfg}}
+			p, err := newPlugin(c, cmds)
+			if err != nil {
+				return nil, err
+			}
+			diag := CandidateDiagnostic{
+				Path:     path,
+				Active:   i == 0 && p.Err == nil,
+				Shadowed: i > 0,
+				Err:      p.Err,
+			}
+			if p.Err != nil {
+				diag.Problem = classifyProblem(c, p.Err)
+			}
+			diags = append(diags, diag)
+		}
+		results[name] = diags
+	}
+	return results, nil
+}
+
+// recordingCandidate is a candidate that records the result of fetching the
+// metadata, so that the reason why the candidate is invalid can be classified.
+type recordingCandidate struct {
+	*candidate
+	called bool
+	meta   []byte
+	err    error
+}
+
+func (c *recordingCandidate) Metadata() ([]byte, error) {
+	c.called = true
+	c.meta, c.err = c.candidate.Metadata()
+	return c.meta, c.err
+}
+
+// classifyProblem returns why the candidate is not a valid plugin, based on
+// pluginErr, the error that was set as Plugin.Err.
+func classifyProblem(c *recordingCandidate, pluginErr error) CandidateProblem {
+	if fi, err := os.Lstat(c.path); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		if _, err := os.Stat(c.path); errors.Is(err, os.ErrNotExist) {
+			return ProblemBrokenSymlink
+		}
+	}
+	if runtime.GOOS != "windows" && !isContainerPlugin(c.path) {
+		if fi, err := os.Stat(c.path); err == nil && fi.Mode().Perm()&0o111 == 0 {
+			return ProblemNotExecutable
+		}
+	}
+	if !c.called {
+		// The candidate was rejected before running it.
+		return ProblemInvalid
+	}
+	if c.err != nil {
+		var sigErr *SignatureError
+		switch {
+		case errors.As(c.err, &sigErr):
+			return ProblemSignature
+		case isExecFormatError(c.err):
+			return ProblemWrongPlatform
+		case errors.Is(c.err, os.ErrPermission):
+			return ProblemNotExecutable
+		default:
+			return ProblemMetadataFailed
+		}
+	}
+	var meta struct{ SchemaVersion string }
+	if err := json.Unmarshal(c.meta, &meta); err != nil {
+		return ProblemInvalidMetadata
+	}
+	if !isSupportedSchemaVersion(meta.SchemaVersion) {
+		return ProblemSchemaVersion
+	}
+	var incompatible *incompatibleError
+	if errors.As(pluginErr, &incompatible) {
+		return ProblemWrongPlatform
+	}
+	return ProblemInvalid
+}
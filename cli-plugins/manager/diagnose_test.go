@@ -0,0 +1,57 @@
+package manager
+
+import (
+	"os"
+	"testing"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/test"
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestDiagnosePluginCandidatesProblems(t *testing.T) {
+	const valid = `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-valid", valid, fs.WithMode(0o777)),
+		fs.WithFile("docker-noexec", valid, fs.WithMode(0o644)),
+		fs.WithFile("docker-badjson", "#!/bin/sh\necho 'not json'", fs.WithMode(0o777)),
+		fs.WithFile("docker-schema", `#!/bin/sh
+echo '{"SchemaVersion":"9.9.9","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-novendor", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0"}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-fails", "#!/bin/sh\nexit 1", fs.WithMode(0o777)),
+		fs.WithFile("docker-platform", `#!/bin/sh
+echo '{"SchemaVersion":"0.2.0","Vendor":"e2e-testing","Platforms":["plan9/mips"]}'`, fs.WithMode(0o777)),
+		fs.WithSymlink("docker-broken", "does-not-exist"),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+	candidates, err := DiagnosePluginCandidates(cli, &cobra.Command{})
+	assert.NilError(t, err)
+
+	expected := map[string]CandidateProblem{
+		"valid":    "",
+		"noexec":   ProblemNotExecutable,
+		"badjson":  ProblemInvalidMetadata,
+		"schema":   ProblemSchemaVersion,
+		"novendor": ProblemInvalid,
+		"fails":    ProblemMetadataFailed,
+		"platform": ProblemWrongPlatform,
+		"broken":   ProblemBrokenSymlink,
+	}
+	for name, problem := range expected {
+		diags := candidates[name]
+		assert.Assert(t, len(diags) > 0, name)
+		assert.Check(t, is.Equal(diags[0].Path, dir.Join("docker-"+name)))
+		assert.Check(t, is.Equal(diags[0].Problem, problem), name)
+		assert.Check(t, is.Equal(diags[0].Err == nil, problem == ""), name)
+	}
+	_, err = os.Lstat(dir.Join("docker-broken"))
+	assert.NilError(t, err)
+}
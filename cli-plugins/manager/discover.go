@@ -0,0 +1,65 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/fvbommel/sortorder"
+)
+
+// DiscoveredPlugin is a CLI plugin candidate found in the plugin directories,
+// as returned by [DiscoverPlugins].
+type DiscoveredPlugin struct {
+	// Name is the name of the plugin, without the "docker-" prefix.
+	Name string
+	// Path is the path of the candidate that takes precedence, and which
+	// is run when invoking the plugin.
+	Path string
+	// SourceDir is the directory Path was found in. This is either one of
+	// the plugin directories, or a symlinked directory inside one of them.
+	SourceDir string
+	// IsSymlink is true if SourceDir is a symlink to a directory.
+	IsSymlink bool
+	// Shadowed contains the paths of the candidates with the same name
+	// that are shadowed by Path, in order of precedence.
+	Shadowed []string
+}
+
+// DiscoverPlugins returns the CLI plugin candidates found in the plugin
+// directories, sorted by name, including the directory each plugin was
+// found in. Unlike [ListPlugins], plugins are not run to fetch their
+// metadata, and are therefore not validated.
+func DiscoverPlugins(dockerCli config.Provider) []DiscoveredPlugin {
+	candidates := listConfiguredPluginCandidates(dockerCli.ConfigFile())
+
+	symlinks := make(map[string]bool)
+	isSymlink := func(dir string) bool {
+		if v, ok := symlinks[dir]; ok {
+			return v
+		}
+		fi, err := os.Lstat(dir)
+		symlinks[dir] = err == nil && fi.Mode()&os.ModeSymlink != 0
+		return symlinks[dir]
+	}
+
+	plugins := make([]DiscoveredPlugin, 0, len(candidates))
+	for name, paths := range candidates {
+		if len(paths) == 0 {
+			continue
+		}
+		sourceDir := filepath.Dir(paths[0])
+		plugins = append(plugins, DiscoveredPlugin{
+			Name:      name,
+			Path:      paths[0],
+			SourceDir: sourceDir,
+			IsSymlink: isSymlink(sourceDir),
+			Shadowed:  paths[1:],
+		})
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return sortorder.NaturalLess(plugins[i].Name, plugins[j].Name)
+	})
+	return plugins
+}
@@ -0,0 +1,85 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestDiscoverPlugins(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("plugins1",
+			fs.WithFile("docker-discover1", ""),
+			fs.WithFile("not-a-plugin", ""),
+			fs.WithDir("ignored1"),
+		),
+		fs.WithDir("plugins2",
+			fs.WithFile("docker-discover1", ""),
+			fs.WithFile("docker-discover2", ""),
+			fs.WithSymlink("linked", "../linked-target"),
+		),
+		fs.WithDir("linked-target",
+			fs.WithFile("docker-discover3", ""),
+		),
+		fs.WithDir("plugins3-target",
+			fs.WithFile("docker-discover1", ""),
+			fs.WithFile("docker-discover4", ""),
+		),
+		fs.WithSymlink("plugins3", "plugins3-target"),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{
+		dir.Join("plugins1"),
+		dir.Join("plugins2"),
+		dir.Join("plugins3"),
+	}})
+
+	// Only check the plugins created for this test, as plugins installed
+	// on the system may also be discovered.
+	discovered := map[string]DiscoveredPlugin{}
+	var names []string
+	for _, p := range DiscoverPlugins(cli) {
+		switch p.Name {
+		case "discover1", "discover2", "discover3", "discover4":
+			discovered[p.Name] = p
+			names = append(names, p.Name)
+		}
+	}
+	assert.Check(t, is.DeepEqual(names, []string{"discover1", "discover2", "discover3", "discover4"}))
+
+	assert.Check(t, is.DeepEqual(discovered["discover1"], DiscoveredPlugin{
+		Name:      "discover1",
+		Path:      dir.Join("plugins1", "docker-discover1"),
+		SourceDir: dir.Join("plugins1"),
+		Shadowed: []string{
+			dir.Join("plugins2", "docker-discover1"),
+			dir.Join("plugins3", "docker-discover1"),
+		},
+	}))
+	assert.Check(t, is.DeepEqual(discovered["discover2"], DiscoveredPlugin{
+		Name:      "discover2",
+		Path:      dir.Join("plugins2", "docker-discover2"),
+		SourceDir: dir.Join("plugins2"),
+		Shadowed:  []string{},
+	}))
+	assert.Check(t, is.DeepEqual(discovered["discover3"], DiscoveredPlugin{
+		Name:      "discover3",
+		Path:      dir.Join("plugins2", "linked", "docker-discover3"),
+		SourceDir: dir.Join("plugins2", "linked"),
+		IsSymlink: true,
+		Shadowed:  []string{},
+	}))
+	assert.Check(t, is.DeepEqual(discovered["discover4"], DiscoveredPlugin{
+		Name:      "discover4",
+		Path:      dir.Join("plugins3", "docker-discover4"),
+		SourceDir: dir.Join("plugins3"),
+		IsSymlink: true,
+		Shadowed:  []string{},
+	}))
+}
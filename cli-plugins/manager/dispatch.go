@@ -0,0 +1,91 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/docker/cli/cli-plugins/metadata"
+	"github.com/spf13/cobra"
+)
+
+// PluginExitError is returned by the commands added by [AddPluginCommands]
+// if the plugin exited with a non-zero exit code.
+type PluginExitError struct {
+	Name     string
+	ExitCode int
+}
+
+func (e *PluginExitError) Error() string {
+	return fmt.Sprintf("plugin %q exited with status %d", e.Name, e.ExitCode)
+}
+
+// AddPluginCommands adds a hidden command to rootCmd for each valid plugin,
+// which runs the plugin with the arguments that follow the command, using
+// [RunPlugin]. It is intended for CLIs that embed the Docker CLI packages, and
+// want to dispatch commands to CLI plugins the same way the docker CLI does.
+//
+// If rootCmd has no Run or RunE function, it is also set up to look up unknown
+// commands using [GetPlugin], so that plugins are found even if they were
+// installed after the commands were added. Flags following an unknown command
+// are passed to the plugin as-is.
+//
+// The commands return a [*PluginExitError] if the plugin exited with a
+// non-zero exit code.
+func AddPluginCommands(dockerCLI RunPluginCli, rootCmd *cobra.Command) error {
+	plugins, err := ListValidPlugins(dockerCLI, rootCmd)
+	if err != nil {
+		return err
+	}
+	for _, p := range plugins {
+		rootCmd.AddCommand(&cobra.Command{
+			Use:    p.Name,
+			Short:  p.localizedShortDescription(),
+			Hidden: true,
+			Annotations: map[string]string{
+				metadata.CommandAnnotationPlugin:        "true",
+				metadata.CommandAnnotationPluginVendor:  p.Vendor,
+				metadata.CommandAnnotationPluginVersion: p.Version,
+			},
+			DisableFlagParsing: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runPluginCommand(cmd, dockerCLI, p.Name, args)
+			},
+		})
+	}
+
+	if rootCmd.Run == nil && rootCmd.RunE == nil {
+		rootCmd.Args = cobra.ArbitraryArgs
+		// Stop parsing flags at the first argument, so that flags for
+		// the plugin are not parsed as flags of the root command.
+		rootCmd.Flags().SetInterspersed(false)
+		rootCmd.RunE = func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return cmd.Help()
+			}
+			p, err := GetPlugin(args[0], dockerCLI, rootCmd)
+			if err != nil {
+				if IsNotFound(err) {
+					return fmt.Errorf("unknown command %q for %q", args[0], rootCmd.CommandPath())
+				}
+				return err
+			}
+			if p.Err != nil {
+				return p.Err
+			}
+			return runPluginCommand(cmd, dockerCLI, p.Name, args[1:])
+		}
+	}
+	return nil
+}
+
+// runPluginCommand runs the named plugin with the given arguments, and
+// returns a [*PluginExitError] if it exited with a non-zero exit code.
+func runPluginCommand(cmd *cobra.Command, dockerCLI RunPluginCli, name string, args []string) error {
+	exitCode, err := RunPlugin(commandContext(cmd), dockerCLI, name, args)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return &PluginExitError{Name: name, ExitCode: exitCode}
+	}
+	return nil
+}
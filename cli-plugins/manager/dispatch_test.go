@@ -0,0 +1,84 @@
+package manager
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/test"
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestAddPluginCommands(t *testing.T) {
+	const plugin = `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+echo "$@"
+[ "$2" = "fail" ] && exit 3
+exit 0`
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-echo", plugin, fs.WithMode(0o777)),
+		fs.WithFile("docker-builtin", plugin, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	newRootCmd := func() *cobra.Command {
+		rootCmd := &cobra.Command{Use: "mycli", SilenceUsage: true, SilenceErrors: true}
+		rootCmd.AddCommand(&cobra.Command{Use: "builtin", Run: func(*cobra.Command, []string) {}})
+		rootCmd.SetOut(io.Discard)
+		rootCmd.SetErr(io.Discard)
+		return rootCmd
+	}
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	rootCmd := newRootCmd()
+	assert.NilError(t, AddPluginCommands(cli, rootCmd))
+
+	cmd, _, err := rootCmd.Find([]string{"echo"})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(cmd.Name(), "echo"))
+	assert.Check(t, cmd.Hidden)
+	assert.Check(t, IsPluginCommand(cmd))
+
+	// A plugin that conflicts with a builtin command is not added.
+	cmd, _, err = rootCmd.Find([]string{"builtin"})
+	assert.NilError(t, err)
+	assert.Check(t, !IsPluginCommand(cmd))
+
+	rootCmd.SetArgs([]string{"echo", "--flag", "value"})
+	assert.NilError(t, rootCmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "echo --flag value\n"))
+
+	cli.ResetOutputBuffers()
+	rootCmd = newRootCmd()
+	assert.NilError(t, AddPluginCommands(cli, rootCmd))
+	rootCmd.SetArgs([]string{"echo", "fail"})
+	err = rootCmd.Execute()
+	var exitErr *PluginExitError
+	assert.Assert(t, errors.As(err, &exitErr))
+	assert.Check(t, is.Equal(exitErr.ExitCode, 3))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "echo fail\n"))
+
+	// Plugins installed after adding the commands are found through the
+	// root command.
+	cli.ResetOutputBuffers()
+	rootCmd = newRootCmd()
+	assert.NilError(t, AddPluginCommands(cli, rootCmd))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir.Path(), "docker-later"), []byte(plugin), 0o777))
+	rootCmd.SetArgs([]string{"later", "--flag"})
+	assert.NilError(t, rootCmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "later --flag\n"))
+
+	rootCmd.SetArgs([]string{"missing"})
+	assert.Error(t, rootCmd.Execute(), `unknown command "missing" for "mycli"`)
+}
@@ -4,7 +4,10 @@
 package manager
 
 import (
+	"errors"
 	"fmt"
+	"runtime"
+	"strings"
 )
 
 // pluginError is set as Plugin.Err by NewPlugin if the plugin
@@ -52,3 +55,94 @@ func wrapAsPluginError(err error, msg string) error {
 func NewPluginError(msg string, args ...any) error {
 	return &pluginError{cause: fmt.Errorf(msg, args...)}
 }
+
+// ChecksumMismatchError is returned by PluginRunCommand if the SHA256 checksum
+// of a plugin does not match the checksum that is pinned for it through
+// [ConfigFile.CLIPluginsChecksums].
+//
+// [ConfigFile.CLIPluginsChecksums]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsChecksums
+type ChecksumMismatchError struct {
+	Name     string
+	Path     string
+	Expected string
+	Actual   string
+}
+
+// Error satisfies the core error interface for ChecksumMismatchError.
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("refusing to run plugin %q: checksum of %s (sha256:%s) does not match the pinned checksum (sha256:%s)", e.Name, e.Path, e.Actual, e.Expected)
+}
+
+// archMismatchError is set as Plugin.Err if the plugin could not be executed
+// because it was built for a different OS or architecture.
+type archMismatchError struct {
+	cause error
+}
+
+// Error satisfies the core error interface for archMismatchError.
+func (e *archMismatchError) Error() string {
+	return fmt.Sprintf("plugin built for wrong architecture (cannot execute on %s/%s): %v", runtime.GOOS, runtime.GOARCH, e.cause)
+}
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (e *archMismatchError) Unwrap() error {
+	return e.cause
+}
+
+// IsArchMismatch is true if the given error is due to a plugin being built
+// for a different OS or architecture than the one the CLI is running on.
+func IsArchMismatch(err error) bool {
+	var e *archMismatchError
+	return errors.As(err, &e)
+}
+
+// incompatibleError is set as Plugin.Err if the plugin declares in its
+// metadata that it is not compatible with the CLI, or with the platform the
+// CLI is running on.
+type incompatibleError struct {
+	cause error
+}
+
+// Error satisfies the core error interface for incompatibleError.
+func (e *incompatibleError) Error() string {
+	return e.cause.Error()
+}
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (e *incompatibleError) Unwrap() error {
+	return e.cause
+}
+
+// ShadowingError is returned by PluginRunCommand if
+// [ConfigFile.CLIPluginsStrictShadowing] is set, and the plugin shadows other
+// plugins with the same name in plugin directories with a lower precedence.
+//
+// [ConfigFile.CLIPluginsStrictShadowing]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsStrictShadowing
+type ShadowingError struct {
+	Name          string
+	Path          string
+	ShadowedPaths []string
+}
+
+// Error satisfies the core error interface for ShadowingError.
+func (e *ShadowingError) Error() string {
+	return fmt.Sprintf("refusing to run plugin %q: %s shadows other plugins with the same name: %s", e.Name, e.Path, strings.Join(e.ShadowedPaths, ", "))
+}
+
+// IncompatiblePluginError is returned by PluginRunCommand if the plugin
+// declares in its metadata that it is not compatible with the CLI, or with
+// the platform the CLI is running on.
+type IncompatiblePluginError struct {
+	Name string
+	Err  error
+}
+
+// Error satisfies the core error interface for IncompatiblePluginError.
+func (e *IncompatiblePluginError) Error() string {
+	return fmt.Sprintf("refusing to run plugin %q: %v", e.Name, e.Err)
+}
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (e *IncompatiblePluginError) Unwrap() error {
+	return e.Err
+}
@@ -24,6 +24,14 @@ type HookPluginData struct {
 	RootCmd      string
 	Flags        map[string]string
 	CommandError string
+	// Event is the lifecycle event for which the plugin is invoked.
+	Event hooks.Event
+	// ExitCode is the exit code of the command that was executed. It is
+	// not set for [hooks.EventContextSwitch].
+	ExitCode int
+	// Context is the name of the context that is now the current context.
+	// It is only set for [hooks.EventContextSwitch].
+	Context string
 }
 
 // RunCLICommandHooks is the entrypoint into the hooks execution flow after
@@ -34,7 +42,7 @@ func RunCLICommandHooks(ctx context.Context, dockerCLI config.Provider, rootCmd,
 	commandName := strings.TrimPrefix(subCommand.CommandPath(), rootCmd.Name()+" ")
 	flags := getCommandFlags(subCommand)
 
-	runHooks(ctx, dockerCLI.ConfigFile(), rootCmd, subCommand, commandName, flags, cmdErrorMessage)
+	runHooks(ctx, dockerCLI, rootCmd, subCommand, commandName, flags, cmdErrorMessage)
 }
 
 // RunPluginHooks is the entrypoint for the hooks execution flow
@@ -43,15 +51,118 @@ func RunPluginHooks(ctx context.Context, dockerCLI config.Provider, rootCmd, sub
 	commandName := strings.Join(args, " ")
 	flags := getNaiveFlags(args)
 
-	runHooks(ctx, dockerCLI.ConfigFile(), rootCmd, subCommand, commandName, flags, "")
+	runHooks(ctx, dockerCLI, rootCmd, subCommand, commandName, flags, "")
 }
 
-func runHooks(ctx context.Context, cfg *configfile.ConfigFile, rootCmd, subCommand *cobra.Command, invokedCommand string, flags map[string]string, cmdErrorMessage string) {
-	nextSteps := invokeAndCollectHooks(ctx, cfg, rootCmd, subCommand, invokedCommand, flags, cmdErrorMessage)
+func runHooks(ctx context.Context, dockerCLI config.Provider, rootCmd, subCommand *cobra.Command, invokedCommand string, flags map[string]string, cmdErrorMessage string) {
+	nextSteps := invokeAndCollectHooks(ctx, dockerCLI, rootCmd, subCommand, invokedCommand, flags, cmdErrorMessage)
 	hooks.PrintNextSteps(subCommand.ErrOrStderr(), nextSteps)
 }
 
-func invokeAndCollectHooks(ctx context.Context, cfg *configfile.ConfigFile, rootCmd, subCmd *cobra.Command, subCmdStr string, flags map[string]string, cmdErrorMessage string) []string {
+// RunCLICommandEventHooks invokes the plugins that registered for the
+// [hooks.EventPostRun] or [hooks.EventError] events after a main CLI command
+// was executed. Unlike RunCLICommandHooks, plugins are also invoked if the
+// CLI is not used in a terminal, and their responses are ignored.
+func RunCLICommandEventHooks(ctx context.Context, dockerCLI config.Provider, rootCmd, subCommand *cobra.Command, exitCode int, cmdErr error) {
+	commandName := strings.TrimPrefix(subCommand.CommandPath(), rootCmd.Name()+" ")
+	flags := getCommandFlags(subCommand)
+
+	runCommandEventHooks(ctx, dockerCLI, rootCmd, commandName, flags, exitCode, cmdErr)
+}
+
+// RunPluginEventHooks is like RunCLICommandEventHooks, but is used after a
+// plugin command was executed by the CLI.
+func RunPluginEventHooks(ctx context.Context, dockerCLI config.Provider, rootCmd *cobra.Command, args []string, exitCode int, cmdErr error) {
+	commandName := strings.Join(args, " ")
+	flags := getNaiveFlags(args)
+
+	runCommandEventHooks(ctx, dockerCLI, rootCmd, commandName, flags, exitCode, cmdErr)
+}
+
+// RunContextSwitchHooks invokes the plugins that registered for the
+// [hooks.EventContextSwitch] event after the current context was changed to
+// contextName.
+func RunContextSwitchHooks(ctx context.Context, dockerCLI config.Provider, rootCmd *cobra.Command, contextName string) {
+	invokeEventHooks(ctx, dockerCLI, rootCmd, hooks.EventContextSwitch, "", HookPluginData{
+		Event:   hooks.EventContextSwitch,
+		Context: contextName,
+	})
+}
+
+func runCommandEventHooks(ctx context.Context, dockerCLI config.Provider, rootCmd *cobra.Command, invokedCommand string, flags map[string]string, exitCode int, cmdErr error) {
+	data := HookPluginData{
+		Flags:    flags,
+		ExitCode: exitCode,
+	}
+	if cmdErr != nil {
+		data.CommandError = cmdErr.Error()
+	}
+	events := []hooks.Event{hooks.EventPostRun}
+	if cmdErr != nil {
+		events = append(events, hooks.EventError)
+	}
+	for _, event := range events {
+		data.Event = event
+		invokeEventHooks(ctx, dockerCLI, rootCmd, event, invokedCommand, data)
+	}
+}
+
+// invokeEventHooks invokes the hook subcommand of all plugins that registered
+// for the given event, ignoring their responses. For events other than
+// [hooks.EventContextSwitch], plugins are only invoked if invokedCommand
+// matches their "hooks" configuration, in which case RootCmd is set to the
+// matching hook.
+func invokeEventHooks(ctx context.Context, dockerCLI config.Provider, rootCmd *cobra.Command, event hooks.Event, invokedCommand string, data HookPluginData) {
+	if ctx.Err() != nil {
+		return
+	}
+	cfg := dockerCLI.ConfigFile()
+	eventHooks := configuredEventHooks(cfg, event, invokedCommand)
+	if len(eventHooks) == 0 {
+		// Don't look up the plugins if none of them registered for this
+		// event, which is the case for most invocations.
+		return
+	}
+
+	candidates := listConfiguredPluginCandidates(cfg)
+	for pluginName, match := range eventHooks {
+		data.RootCmd = match
+		p, err := getPlugin(pluginName, candidates, cfg, rootCmd)
+		if err != nil {
+			continue
+		}
+		if err := checkPluginRunnable(ctx, cfg, *p, candidates, newSignatureVerifier(cfg), nil); err != nil {
+			logrus.WithError(err).Debugf("Not invoking %s hook of plugin %s.", event, pluginName)
+			continue
+		}
+		if _, err := p.runHook(ctx, data, dockerCLI, rootCmd); err != nil {
+			logrus.WithError(err).Debugf("Failed to invoke %s hook of plugin %s. Ignoring.", event, pluginName)
+		}
+	}
+}
+
+// configuredEventHooks returns the plugins that registered for the given
+// event, with the hook in their configuration that matches invokedCommand.
+// The hook is empty for [hooks.EventContextSwitch], which is not related to
+// a command.
+func configuredEventHooks(cfg *configfile.ConfigFile, event hooks.Event, invokedCommand string) map[string]string {
+	eventHooks := make(map[string]string)
+	for pluginName, pluginCfg := range cfg.Plugins {
+		if !pluginHasEvent(pluginCfg, event) {
+			continue
+		}
+		if event == hooks.EventContextSwitch {
+			eventHooks[pluginName] = ""
+			continue
+		}
+		if match, ok := pluginMatch(pluginCfg, invokedCommand); ok {
+			eventHooks[pluginName] = match
+		}
+	}
+	return eventHooks
+}
+
+func invokeAndCollectHooks(ctx context.Context, dockerCLI config.Provider, rootCmd, subCmd *cobra.Command, subCmdStr string, flags map[string]string, cmdErrorMessage string) []string {
 	// check if the context was cancelled before invoking hooks
 	select {
 	case <-ctx.Done():
@@ -59,29 +170,37 @@ func invokeAndCollectHooks(ctx context.Context, cfg *configfile.ConfigFile, root
 	default:
 	}
 
+	cfg := dockerCLI.ConfigFile()
 	pluginsCfg := cfg.Plugins
 	if pluginsCfg == nil {
 		return nil
 	}
 
-	pluginDirs := getPluginDirs(cfg)
+	candidates := listConfiguredPluginCandidates(cfg)
 	nextSteps := make([]string, 0, len(pluginsCfg))
 	for pluginName, pluginCfg := range pluginsCfg {
+		if !pluginHasEvent(pluginCfg, hooks.EventNextSteps) {
+			continue
+		}
 		match, ok := pluginMatch(pluginCfg, subCmdStr)
 		if !ok {
 			continue
 		}
 
-		p, err := getPlugin(pluginName, pluginDirs, rootCmd)
+		p, err := getPlugin(pluginName, candidates, cfg, rootCmd)
 		if err != nil {
 			continue
 		}
+		if err := checkPluginRunnable(ctx, cfg, *p, candidates, newSignatureVerifier(cfg), nil); err != nil {
+			continue
+		}
 
-		hookReturn, err := p.RunHook(ctx, HookPluginData{
+		hookReturn, err := p.runHook(ctx, HookPluginData{
 			RootCmd:      match,
 			Flags:        flags,
 			CommandError: cmdErrorMessage,
-		})
+			Event:        hooks.EventNextSteps,
+		}, dockerCLI, rootCmd)
 		if err != nil {
 			// skip misbehaving plugins, but don't halt execution
 			continue
@@ -151,6 +270,22 @@ func pluginMatch(pluginCfg map[string]string, subCmd string) (string, bool) {
 	return "", false
 }
 
+// pluginHasEvent returns whether the plugin registered for the given event
+// through the "hookEvents" option in its configuration. Plugins that did not
+// configure any events are only invoked for [hooks.EventNextSteps].
+func pluginHasEvent(pluginCfg map[string]string, event hooks.Event) bool {
+	configuredEvents, ok := pluginCfg["hookEvents"]
+	if !ok || configuredEvents == "" {
+		return event == hooks.EventNextSteps
+	}
+	for _, e := range strings.Split(configuredEvents, ",") {
+		if hooks.Event(strings.TrimSpace(e)) == event {
+			return true
+		}
+	}
+	return false
+}
+
 func hookMatch(hookCmd, subCmd string) bool {
 	hookCmdTokens := strings.Split(hookCmd, " ")
 	subCmdTokens := strings.Split(subCmd, " ")
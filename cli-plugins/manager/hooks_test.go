@@ -1,10 +1,24 @@
 package manager
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/docker/cli/cli-plugins/hooks"
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/test"
+	"github.com/spf13/cobra"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
 )
 
 func TestGetNaiveFlags(t *testing.T) {
@@ -141,3 +155,279 @@ func TestAppendNextSteps(t *testing.T) {
 		})
 	}
 }
+
+func TestPluginHasEvent(t *testing.T) {
+	testCases := []struct {
+		pluginConfig map[string]string
+		event        hooks.Event
+		expected     bool
+	}{
+		{
+			pluginConfig: map[string]string{"hooks": "image"},
+			event:        hooks.EventNextSteps,
+			expected:     true,
+		},
+		{
+			pluginConfig: map[string]string{"hooks": "image"},
+			event:        hooks.EventPostRun,
+			expected:     false,
+		},
+		{
+			pluginConfig: map[string]string{"hooks": "image", "hookEvents": "post-run, error"},
+			event:        hooks.EventError,
+			expected:     true,
+		},
+		{
+			pluginConfig: map[string]string{"hooks": "image", "hookEvents": "post-run"},
+			event:        hooks.EventNextSteps,
+			expected:     false,
+		},
+		{
+			pluginConfig: map[string]string{"hookEvents": "next-steps,context-switch"},
+			event:        hooks.EventNextSteps,
+			expected:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Check(t, is.Equal(pluginHasEvent(tc.pluginConfig, tc.event), tc.expected), "%v: %s", tc.pluginConfig, tc.event)
+	}
+}
+
+func TestConfiguredEventHooks(t *testing.T) {
+	cfg := &configfile.ConfigFile{
+		Plugins: map[string]map[string]string{
+			"next-steps":     {"hooks": "image"},
+			"post-run":       {"hooks": "image,container ls", "hookEvents": "post-run"},
+			"context-switch": {"hookEvents": "context-switch"},
+			"no-hooks":       {"some-option": "value"},
+		},
+	}
+	testCases := []struct {
+		event          hooks.Event
+		invokedCommand string
+		expected       map[string]string
+	}{
+		{
+			event:          hooks.EventPostRun,
+			invokedCommand: "image ls",
+			expected:       map[string]string{"post-run": "image"},
+		},
+		{
+			event:          hooks.EventPostRun,
+			invokedCommand: "container ls",
+			expected:       map[string]string{"post-run": "container ls"},
+		},
+		{
+			event:          hooks.EventPostRun,
+			invokedCommand: "volume ls",
+			expected:       map[string]string{},
+		},
+		{
+			event:          hooks.EventError,
+			invokedCommand: "image ls",
+			expected:       map[string]string{},
+		},
+		{
+			event:    hooks.EventContextSwitch,
+			expected: map[string]string{"context-switch": ""},
+		},
+	}
+	for _, tc := range testCases {
+		actual := configuredEventHooks(cfg, tc.event, tc.invokedCommand)
+		assert.Check(t, is.DeepEqual(actual, tc.expected), "%s: %s", tc.event, tc.invokedCommand)
+	}
+}
+
+func TestInvokeEventHooks(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	eventsFile := dir.Join("events")
+	assert.NilError(t, os.WriteFile(dir.Join("docker-recorder"), []byte(`#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+echo "$3" >> `+eventsFile+`
+`), 0o777))
+
+	cfg := &configfile.ConfigFile{
+		CLIPluginsExtraDirs: []string{dir.Path()},
+		Plugins: map[string]map[string]string{
+			"recorder": {"hooks": "image", "hookEvents": "post-run,error,context-switch"},
+		},
+	}
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(cfg)
+	rootCmd := &cobra.Command{Use: "docker"}
+	imageCmd := &cobra.Command{Use: "image"}
+	lsCmd := &cobra.Command{Use: "ls"}
+	imageCmd.AddCommand(lsCmd)
+	containerCmd := &cobra.Command{Use: "container"}
+	rootCmd.AddCommand(imageCmd, containerCmd)
+
+	ctx := context.Background()
+	RunCLICommandEventHooks(ctx, cli, rootCmd, lsCmd, 0, nil)
+	RunCLICommandEventHooks(ctx, cli, rootCmd, lsCmd, 125, errors.New("boom"))
+	RunCLICommandEventHooks(ctx, cli, rootCmd, containerCmd, 1, errors.New("not matched"))
+	RunContextSwitchHooks(ctx, cli, rootCmd, "my-context")
+
+	data, err := os.ReadFile(eventsFile)
+	assert.NilError(t, err)
+	var events []HookPluginData
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var d HookPluginData
+		assert.NilError(t, json.Unmarshal([]byte(line), &d))
+		events = append(events, d)
+	}
+	assert.Check(t, is.DeepEqual(events, []HookPluginData{
+		{RootCmd: "image", Flags: map[string]string{}, Event: hooks.EventPostRun},
+		{RootCmd: "image", Flags: map[string]string{}, Event: hooks.EventPostRun, ExitCode: 125, CommandError: "boom"},
+		{RootCmd: "image", Flags: map[string]string{}, Event: hooks.EventError, ExitCode: 125, CommandError: "boom"},
+		{Event: hooks.EventContextSwitch, Context: "my-context"},
+	}))
+}
+
+func TestInvokeEventHooksChecksumMismatch(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	executedFile := dir.Join("executed")
+	assert.NilError(t, os.WriteFile(dir.Join("docker-recorder"), []byte(`#!/bin/sh
+touch `+executedFile+`
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+`), 0o777))
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs: []string{dir.Path()},
+		CLIPluginsChecksums: map[string]string{"recorder": "sha256:0000"},
+		Plugins: map[string]map[string]string{
+			"recorder": {"hooks": "image", "hookEvents": "post-run"},
+		},
+	})
+	rootCmd := &cobra.Command{Use: "docker"}
+	imageCmd := &cobra.Command{Use: "image"}
+	rootCmd.AddCommand(imageCmd)
+
+	RunCLICommandEventHooks(context.Background(), cli, rootCmd, imageCmd, 0, nil)
+
+	_, err := os.Stat(executedFile)
+	assert.Check(t, is.ErrorType(err, os.IsNotExist))
+}
+
+func TestInvokeEventHooksExecWrapper(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-recorder", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+	// The wrapper logs the commands it runs, without the hook data.
+	assert.NilError(t, os.WriteFile(dir.Join("wrapper"), []byte(`#!/bin/sh
+echo "$1" "$2" "$3" >> "$(dirname "$0")/wrapper.log"
+exec "$@"`), 0o777))
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs:   []string{dir.Path()},
+		CLIPluginsExecWrapper: []string{dir.Join("wrapper")},
+		Plugins: map[string]map[string]string{
+			"recorder": {"hooks": "image", "hookEvents": "post-run"},
+		},
+	})
+	rootCmd := &cobra.Command{Use: "docker"}
+	imageCmd := &cobra.Command{Use: "image"}
+	rootCmd.AddCommand(imageCmd)
+
+	RunCLICommandEventHooks(context.Background(), cli, rootCmd, imageCmd, 0, nil)
+
+	log, err := os.ReadFile(dir.Join("wrapper.log"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Contains(string(log), dir.Join("docker-recorder")+" recorder docker-cli-plugin-hooks\n"))
+}
+
+func TestInvokeEventHooksEnv(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("recorder.env", "FROM_ENV_FILE=yes\n"),
+	)
+	defer dir.Remove()
+	envFile := dir.Join("env")
+	assert.NilError(t, os.WriteFile(dir.Join("docker-recorder"), []byte(`#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+env > `+envFile+`
+`), 0o777))
+
+	t.Setenv("HOOK_ALLOWED", "1")
+	t.Setenv("HOOK_SECRET", "1")
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs:    []string{dir.Path()},
+		CLIPluginsEnvAllowlist: []string{"HOOK_ALLOWED"},
+		Plugins: map[string]map[string]string{
+			"recorder": {"hooks": "image", "hookEvents": "post-run"},
+		},
+	})
+	rootCmd := &cobra.Command{Use: "docker"}
+	imageCmd := &cobra.Command{Use: "image"}
+	rootCmd.AddCommand(imageCmd)
+
+	RunCLICommandEventHooks(context.Background(), cli, rootCmd, imageCmd, 0, nil)
+
+	data, err := os.ReadFile(envFile)
+	assert.NilError(t, err)
+	env := "\n" + string(data)
+	assert.Check(t, is.Contains(env, "\nHOOK_ALLOWED=1\n"))
+	assert.Check(t, !strings.Contains(env, "\nHOOK_SECRET="))
+	assert.Check(t, is.Contains(env, "\nFROM_ENV_FILE=yes\n"))
+	assert.Check(t, is.Contains(env, "\nDOCKER_CONFIG="+config.Dir()+"\n"))
+}
+
+func TestInvokeEventHooksNotRunnable(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	hookScript := func(name, meta string) string {
+		return `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '` + meta + `'
+	exit 0
+fi
+touch ` + dir.Join(name+".executed") + `
+`
+	}
+	const meta = `{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}`
+	assert.NilError(t, os.WriteFile(dir.Join("docker-approved"), []byte(hookScript("approved", meta)), 0o777))
+	assert.NilError(t, os.WriteFile(dir.Join("docker-unlisted"), []byte(hookScript("unlisted", meta)), 0o777))
+	assert.NilError(t, os.WriteFile(dir.Join("docker-incompatible"), []byte(hookScript("incompatible", `{"SchemaVersion":"0.2.0","Vendor":"e2e-testing","Platforms":["plan9/mips"]}`)), 0o777))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"name":"approved"},{"name":"incompatible"}]`))
+	}))
+	defer srv.Close()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		Filename:              filepath.Join(t.TempDir(), "config.json"),
+		CLIPluginsExtraDirs:   []string{dir.Path()},
+		CLIPluginsManifestURL: srv.URL,
+		Plugins: map[string]map[string]string{
+			"approved":     {"hooks": "image", "hookEvents": "post-run"},
+			"unlisted":     {"hooks": "image", "hookEvents": "post-run"},
+			"incompatible": {"hooks": "image", "hookEvents": "post-run"},
+		},
+	})
+	rootCmd := &cobra.Command{Use: "docker"}
+	imageCmd := &cobra.Command{Use: "image"}
+	rootCmd.AddCommand(imageCmd)
+
+	RunCLICommandEventHooks(context.Background(), cli, rootCmd, imageCmd, 0, nil)
+
+	_, err := os.Stat(dir.Join("approved.executed"))
+	assert.Check(t, err, "hook of approved plugin was not invoked")
+	_, err = os.Stat(dir.Join("unlisted.executed"))
+	assert.Check(t, is.ErrorType(err, os.IsNotExist))
+	_, err = os.Stat(dir.Join("incompatible.executed"))
+	assert.Check(t, is.ErrorType(err, os.IsNotExist))
+}
@@ -0,0 +1,64 @@
+package manager
+
+import (
+	"os"
+	"strings"
+)
+
+// localeEnvVars are the environment variables that determine the language of
+// messages, in order of precedence.
+var localeEnvVars = []string{"LC_ALL", "LC_MESSAGES", "LANG"}
+
+// messagesLocale returns the language tag for messages from the environment,
+// for example "pt-br" for LANG=pt_BR.UTF-8. It returns an empty string if no
+// locale is set, or for the "C" and "POSIX" locales.
+func messagesLocale() string {
+	for _, name := range localeEnvVars {
+		v := os.Getenv(name)
+		if v == "" {
+			continue
+		}
+		// Strip the codeset and modifier, as in "fr_FR.UTF-8@euro".
+		if i := strings.IndexAny(v, ".@"); i >= 0 {
+			v = v[:i]
+		}
+		if v == "C" || v == "POSIX" {
+			return ""
+		}
+		return normalizeLanguageTag(v)
+	}
+	return ""
+}
+
+func normalizeLanguageTag(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+}
+
+// localizedShortDescription returns the entry in the plugin's
+// ShortDescriptions matching the user's locale. It tries the full language
+// tag first (for example "pt-BR"), then only the language ("pt"). It falls
+// back to the plugin's ShortDescription if there is no matching entry.
+func (p *Plugin) localizedShortDescription() string {
+	if len(p.ShortDescriptions) == 0 {
+		return p.ShortDescription
+	}
+	locale := messagesLocale()
+	if locale == "" {
+		return p.ShortDescription
+	}
+	descriptions := make(map[string]string, len(p.ShortDescriptions))
+	for tag, desc := range p.ShortDescriptions {
+		if desc != "" {
+			descriptions[normalizeLanguageTag(tag)] = desc
+		}
+	}
+	if desc, ok := descriptions[locale]; ok {
+		return desc
+	}
+	if lang, _, ok := strings.Cut(locale, "-"); ok {
+		if desc, ok := descriptions[lang]; ok {
+			return desc
+		}
+	}
+	return p.ShortDescription
+}
@@ -0,0 +1,44 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/docker/cli/cli-plugins/metadata"
+	"gotest.tools/v3/assert"
+)
+
+func TestLocalizedShortDescription(t *testing.T) {
+	p := Plugin{Metadata: metadata.Metadata{
+		ShortDescription: "Manage widgets",
+		ShortDescriptions: map[string]string{
+			"fr":    "Gérer les widgets",
+			"pt-BR": "Gerenciar widgets",
+			"de":    "",
+		},
+	}}
+
+	testCases := []struct {
+		doc      string
+		env      map[string]string
+		expected string
+	}{
+		{doc: "no locale", expected: "Manage widgets"},
+		{doc: "language", env: map[string]string{"LANG": "fr"}, expected: "Gérer les widgets"},
+		{doc: "language with region", env: map[string]string{"LANG": "fr_CA.UTF-8"}, expected: "Gérer les widgets"},
+		{doc: "region", env: map[string]string{"LANG": "pt_BR.UTF-8"}, expected: "Gerenciar widgets"},
+		{doc: "region not matching", env: map[string]string{"LANG": "pt_PT.UTF-8"}, expected: "Manage widgets"},
+		{doc: "no match", env: map[string]string{"LANG": "nl_NL.UTF-8"}, expected: "Manage widgets"},
+		{doc: "empty description", env: map[string]string{"LANG": "de_DE.UTF-8"}, expected: "Manage widgets"},
+		{doc: "C locale", env: map[string]string{"LANG": "C"}, expected: "Manage widgets"},
+		{doc: "LC_MESSAGES takes precedence", env: map[string]string{"LANG": "en_US.UTF-8", "LC_MESSAGES": "fr_FR.UTF-8"}, expected: "Gérer les widgets"},
+		{doc: "LC_ALL takes precedence", env: map[string]string{"LC_ALL": "pt_BR", "LC_MESSAGES": "fr_FR.UTF-8"}, expected: "Gerenciar widgets"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			for _, name := range localeEnvVars {
+				t.Setenv(name, tc.env[name])
+			}
+			assert.Equal(t, p.localizedShortDescription(), tc.expected)
+		})
+	}
+}
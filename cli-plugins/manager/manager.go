@@ -2,18 +2,26 @@
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/docker/cli/cli-plugins/metadata"
 	"github.com/docker/cli/cli/config"
 	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/pkg/kvfile"
 	"github.com/fvbommel/sortorder"
+	"github.com/moby/term"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -29,6 +37,10 @@
 	//
 	// Deprecated: The "OTEL_RESOURCE_ATTRIBUTES" env-var is part of the OpenTelemetry specification; users should define their own const for this. This const will be removed in the next release.
 	ResourceAttributesEnvvar = "OTEL_RESOURCE_ATTRIBUTES"
+
+	// defaultMetadataTimeout is the default time to wait for a plugin to
+	// return its metadata.
+	defaultMetadataTimeout = 3 * time.Second
 )
 
 // errPluginNotFound is the error returned when a plugin could not be found.
@@ -40,6 +52,23 @@ func (e errPluginNotFound) Error() string {
 	return "Error: No such CLI plugin: " + string(e)
 }
 
+// errPluginsDisabled is the error returned when running a plugin while
+// plugins are disabled through SetPluginsDisabled.
+type errPluginsDisabled string
+
+func (errPluginsDisabled) NotFound() {}
+
+func (e errPluginsDisabled) Error() string {
+	return fmt.Sprintf("cannot run CLI plugin %q: CLI plugins are disabled", string(e))
+}
+
+// IsPluginsDisabled is true if the given error is due to plugins being
+// disabled. Errors for which this is true also satisfy IsNotFound.
+func IsPluginsDisabled(err error) bool {
+	var e errPluginsDisabled
+	return errors.As(err, &e)
+}
+
 type notFound interface{ NotFound() }
 
 // IsNotFound is true if the given error is due to a plugin not being found.
@@ -51,6 +80,20 @@ func IsNotFound(err error) bool {
 	return ok
 }
 
+// pluginsDisabled is set through SetPluginsDisabled.
+var pluginsDisabled atomic.Bool
+
+// readDir is used to list plugin directories, and can be overridden in tests.
+var readDir = os.ReadDir
+
+// SetPluginsDisabled disables (or re-enables) CLI plugins. When disabled,
+// plugin directories are not scanned, so no plugins are listed or added as
+// commands, and running a plugin returns an error that satisfies
+// IsPluginsDisabled.
+func SetPluginsDisabled(disabled bool) {
+	pluginsDisabled.Store(disabled)
+}
+
 // getPluginDirs returns the platform-specific locations to search for plugins
 // in order of preference.
 //
@@ -60,6 +103,10 @@ func IsNotFound(err error) bool {
 // 2. Additional plugin directories as configured through [ConfigFile.CLIPluginsExtraDirs].
 // 3. Platform-specific defaultSystemPluginDirs.
 //
+// On Linux, the "docker/cli-plugins" directory inside XDG_CONFIG_HOME is
+// searched before the "cli-plugins" directory inside the CLIs config
+// directory if XDG_CONFIG_HOME is set (see xdgPluginDir).
+//
 // [ConfigFile.CLIPluginsExtraDirs]: https://pkg.go.dev/github.com/docker/cli@v26.1.4+incompatible/cli/config/configfile#ConfigFile.CLIPluginsExtraDirs
 func getPluginDirs(cfg *configfile.ConfigFile) []string {
 	var pluginDirs []string
@@ -67,62 +114,330 @@ func getPluginDirs(cfg *configfile.ConfigFile) []string {
 	if cfg != nil {
 		pluginDirs = append(pluginDirs, cfg.CLIPluginsExtraDirs...)
 	}
+	if xdgDir := xdgPluginDir(); xdgDir != "" {
+		pluginDirs = append(pluginDirs, xdgDir)
+	}
 	pluginDir := filepath.Join(config.Dir(), "cli-plugins")
 	pluginDirs = append(pluginDirs, pluginDir)
 	pluginDirs = append(pluginDirs, defaultSystemPluginDirs...)
-	return pluginDirs
+	return dedupPluginDirs(pluginDirs)
+}
+
+// xdgPluginDir returns the "docker/cli-plugins" directory inside the
+// XDG_CONFIG_HOME directory on Linux. It returns an empty string if
+// XDG_CONFIG_HOME is not set (or not an absolute path, as required by the
+// XDG Base Directory Specification), or if the CLIs config directory was
+// changed from its default (~/.docker) through DOCKER_CONFIG or the
+// "--config" option.
+func xdgPluginDir() string {
+	if runtime.GOOS != "linux" {
+		return ""
+	}
+	xdgConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	if xdgConfigHome == "" || !filepath.IsAbs(xdgConfigHome) {
+		return ""
+	}
+	if home, err := os.UserHomeDir(); err != nil || config.Dir() != filepath.Join(home, ".docker") {
+		return ""
+	}
+	return filepath.Join(xdgConfigHome, "docker", "cli-plugins")
+}
+
+// dedupPluginDirs removes directories that resolve to the same absolute path
+// as a directory earlier in the list, so that plugins in those directories
+// are not reported as shadowing themselves.
+func dedupPluginDirs(dirs []string) []string {
+	seen := make(map[string]struct{}, len(dirs))
+	res := make([]string, 0, len(dirs))
+	for _, d := range dirs {
+		key := d
+		if abs, err := filepath.Abs(d); err == nil {
+			key = abs
+		}
+		if resolved, err := filepath.EvalSymlinks(key); err == nil {
+			key = resolved
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		res = append(res, d)
+	}
+	return res
+}
+
+// getMetadataTimeout returns the time to wait for a plugin to return its
+// metadata, as configured through [ConfigFile.CLIPluginsMetadataTimeout].
+// It returns defaultMetadataTimeout if no (valid) timeout is configured.
+//
+// [ConfigFile.CLIPluginsMetadataTimeout]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsMetadataTimeout
+func getMetadataTimeout(cfg *configfile.ConfigFile) time.Duration {
+	if cfg == nil || cfg.CLIPluginsMetadataTimeout == "" {
+		return defaultMetadataTimeout
+	}
+	timeout, err := time.ParseDuration(cfg.CLIPluginsMetadataTimeout)
+	if err != nil || timeout <= 0 {
+		return defaultMetadataTimeout
+	}
+	return timeout
 }
 
-func addPluginCandidatesFromDir(res map[string][]string, d string) {
-	dentries, err := os.ReadDir(d)
-	// Silently ignore any directories which we cannot list (e.g. due to
-	// permissions or anything else) or which is not a directory
+// candidateOptions configures how plugin candidates are discovered in the
+// plugin directories.
+type candidateOptions struct {
+	// prefixes are the prefixes of the file names of plugins. Only
+	// metadata.NamePrefix is used if empty.
+	prefixes []string
+	// pathDirs are the directories in PATH, which are searched after the
+	// plugin directories for the plugins in pathAllowlist.
+	pathDirs      []string
+	pathAllowlist map[string]struct{}
+}
+
+// pluginCandidateOptions returns the options to discover plugin candidates as
+// configured through [ConfigFile.CLIPluginsPrefixes] and
+// [ConfigFile.CLIPluginsPathAllowlist].
+//
+// [ConfigFile.CLIPluginsPrefixes]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsPrefixes
+// [ConfigFile.CLIPluginsPathAllowlist]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsPathAllowlist
+func pluginCandidateOptions(cfg *configfile.ConfigFile) candidateOptions {
+	opts := candidateOptions{prefixes: pluginPrefixes(cfg)}
+	if cfg == nil || len(cfg.CLIPluginsPathAllowlist) == 0 {
+		return opts
+	}
+	opts.pathAllowlist = make(map[string]struct{}, len(cfg.CLIPluginsPathAllowlist))
+	for _, name := range cfg.CLIPluginsPathAllowlist {
+		opts.pathAllowlist[name] = struct{}{}
+	}
+	var pathDirs []string
+	for _, d := range filepath.SplitList(os.Getenv("PATH")) {
+		// Relative directories (including an empty entry, which means the
+		// current directory) depend on the working directory of the CLI,
+		// so are never searched for plugins.
+		if filepath.IsAbs(d) {
+			pathDirs = append(pathDirs, d)
+		}
+	}
+	// Skip directories in PATH that are also plugin directories, which
+	// are searched already.
+	pluginDirs := getPluginDirs(cfg)
+	opts.pathDirs = dedupPluginDirs(append(pluginDirs, pathDirs...))[len(pluginDirs):]
+	return opts
+}
+
+// pluginPrefixes returns the prefixes of the file names of plugins, which are
+// metadata.NamePrefix, followed by the prefixes that are configured through
+// [ConfigFile.CLIPluginsPrefixes]. Configured prefixes that do not end with
+// a "-" are ignored.
+//
+// [ConfigFile.CLIPluginsPrefixes]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsPrefixes
+func pluginPrefixes(cfg *configfile.ConfigFile) []string {
+	prefixes := []string{metadata.NamePrefix}
+	if cfg == nil {
+		return prefixes
+	}
+	for _, prefix := range cfg.CLIPluginsPrefixes {
+		if len(prefix) < 2 || !strings.HasSuffix(prefix, "-") || prefix == metadata.NamePrefix {
+			continue
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes
+}
+
+// matchPluginName returns a function that returns the name of the plugin for
+// the file name of a plugin candidate, and false if the file is not a plugin
+// candidate, because it does not have one of the prefixes, or its name is not
+// in allowlist, unless allowlist is nil.
+func matchPluginName(prefixes []string, allowlist map[string]struct{}) func(string) (string, bool) {
+	if len(prefixes) == 0 {
+		prefixes = []string{metadata.NamePrefix}
+	}
+	return func(fileName string) (string, bool) {
+		if strings.HasSuffix(fileName, signatureFileSuffix) {
+			// Skip signature files that are stored next to the plugin.
+			return "", false
+		}
+		for _, prefix := range prefixes {
+			if !strings.HasPrefix(fileName, prefix) {
+				continue
+			}
+			name, err := trimPluginSuffix(strings.TrimPrefix(fileName, prefix))
+			if err != nil {
+				continue
+			}
+			if allowlist != nil {
+				if _, ok := allowlist[name]; !ok {
+					continue
+				}
+			}
+			return name, true
+		}
+		return "", false
+	}
+}
+
+func addPluginCandidatesFromDir(res map[string][]string, d string, match func(string) (string, bool), warn func(error)) {
+	if pluginsDisabled.Load() {
+		return
+	}
+	visited := make(map[string]struct{})
+	if resolved, err := filepath.EvalSymlinks(d); err == nil {
+		visited[resolved] = struct{}{}
+	}
+	addPluginCandidatesFromDirs(res, d, match, visited, true, warn)
+}
+
+// addPluginCandidatesFromDirs adds the plugin candidates found in d to res,
+// using match to get the name of the plugin from the name of each file.
+// If followSymlinks is set, symlinks to directories are followed (one level
+// deep), skipping directories that were already visited to prevent loops.
+// Candidates found in d itself take precedence over those found in symlinked
+// directories. If warn is non-nil, it is called for directories that exist,
+// but cannot be listed, including regular files that are configured as a
+// plugin directory.
+func addPluginCandidatesFromDirs(res map[string][]string, d string, match func(string) (string, bool), visited map[string]struct{}, followSymlinks bool, warn func(error)) {
+	dentries, err := readDir(d)
+	// Skip any directories which we cannot list (e.g. due to permissions
+	// or anything else) or which is not a directory
 	if err != nil {
+		if warn != nil && !os.IsNotExist(err) {
+			// A file in place of a directory is almost always a mistake
+			// in the configuration, so report it as such.
+			if fi, statErr := os.Stat(d); statErr == nil && fi.Mode().IsRegular() {
+				err = fmt.Errorf("%s is a file, not a directory", d)
+			}
+			warn(err)
+		}
 		return
 	}
+	var linkedDirs []string
 	for _, dentry := range dentries {
 		switch dentry.Type() & os.ModeType { //nolint:exhaustive,nolintlint // no need to include all possible file-modes in this list
-		case 0, os.ModeSymlink:
-			// Regular file or symlink, keep going
+		case 0:
+			// Regular file, keep going
+		case os.ModeSymlink:
+			if followSymlinks {
+				p := filepath.Join(d, dentry.Name())
+				if target, ok := resolveDirSymlink(p); ok {
+					if _, seen := visited[target]; !seen {
+						visited[target] = struct{}{}
+						linkedDirs = append(linkedDirs, p)
+					}
+					continue
+				}
+			}
+			// Symlink to a file (or not followed), keep going
 		default:
 			// Something else, ignore.
 			continue
 		}
-		name := dentry.Name()
-		if !strings.HasPrefix(name, metadata.NamePrefix) {
-			continue
-		}
-		name = strings.TrimPrefix(name, metadata.NamePrefix)
-		var err error
-		if name, err = trimExeSuffix(name); err != nil {
+		name, ok := match(dentry.Name())
+		if !ok {
 			continue
 		}
 		res[name] = append(res[name], filepath.Join(d, dentry.Name()))
 	}
+	for _, ld := range linkedDirs {
+		addPluginCandidatesFromDirs(res, ld, match, visited, false, warn)
+	}
+}
+
+// resolveDirSymlink returns the resolved path of the symlink at p if it
+// points to a directory.
+func resolveDirSymlink(p string) (string, bool) {
+	if fi, err := os.Stat(p); err != nil || !fi.IsDir() {
+		return "", false
+	}
+	target, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return "", false
+	}
+	return target, true
 }
 
 // listPluginCandidates returns a map from plugin name to the list of (unvalidated) Candidates. The list is in descending order of priority.
 func listPluginCandidates(dirs []string) map[string][]string {
+	result, _ := listPluginCandidatesContext(context.Background(), dirs, candidateOptions{}, nil)
+	return result
+}
+
+// listConfiguredPluginCandidates is like listPluginCandidates, but lists the
+// candidates in the plugin directories of the given config, including the
+// candidates with the prefixes and the candidates in PATH that are configured.
+func listConfiguredPluginCandidates(cfg *configfile.ConfigFile) map[string][]string {
+	result, _ := listPluginCandidatesContext(context.Background(), getPluginDirs(cfg), pluginCandidateOptions(cfg), nil)
+	return result
+}
+
+// listPluginCandidatesWithWarnings is like listPluginCandidates, but also
+// returns a warning for each plugin directory that exists, but cannot be
+// listed.
+func listPluginCandidatesWithWarnings(dirs []string, opts candidateOptions) (map[string][]string, []error) {
+	var warnings []error
+	warn := func(err error) {
+		warnings = append(warnings, err)
+	}
+	result, _ := listPluginCandidatesContext(context.Background(), dirs, opts, warn)
+	return result, warnings
+}
+
+// listPluginCandidatesContext is like listPluginCandidates, but stops
+// scanning directories and returns an error when ctx is done. If warn is
+// non-nil, it is called for directories that exist, but cannot be listed.
+// Directories in PATH are searched silently.
+func listPluginCandidatesContext(ctx context.Context, dirs []string, opts candidateOptions, warn func(error)) (map[string][]string, error) {
 	result := make(map[string][]string)
+	match := matchPluginName(opts.prefixes, nil)
 	for _, d := range dirs {
-		addPluginCandidatesFromDir(result, d)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		addPluginCandidatesFromDir(result, d, match, warn)
 	}
-	return result
+	if len(opts.pathDirs) > 0 {
+		match = matchPluginName(opts.prefixes, opts.pathAllowlist)
+		for _, d := range opts.pathDirs {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			addPluginCandidatesFromDir(result, d, match, nil)
+		}
+	}
+	return result, nil
+}
+
+// ListPluginCandidates returns a map from plugin name to the paths of all
+// (unvalidated) plugin candidates with that name, including candidates that
+// are shadowed by a candidate in a directory with a higher precedence. The
+// paths are in descending order of precedence, so the first path is the one
+// that is used when running the plugin.
+func ListPluginCandidates(dockerCli config.Provider) map[string][]string {
+	return listConfiguredPluginCandidates(dockerCli.ConfigFile())
 }
 
 // GetPlugin returns a plugin on the system by its name
 func GetPlugin(name string, dockerCLI config.Provider, rootcmd *cobra.Command) (*Plugin, error) {
-	pluginDirs := getPluginDirs(dockerCLI.ConfigFile())
-	return getPlugin(name, pluginDirs, rootcmd)
+	cfg := dockerCLI.ConfigFile()
+	return getPlugin(name, listConfiguredPluginCandidates(cfg), cfg, rootcmd)
+}
+
+// GetPluginFromDir returns the plugin with the given name from the given
+// directory, ignoring any plugins with the same name in other plugin
+// directories. The error returned satisfies the IsNotFound() predicate if
+// the directory does not contain a plugin with that name.
+func GetPluginFromDir(name, dir string, dockerCLI config.Provider, rootcmd *cobra.Command) (*Plugin, error) {
+	cfg := dockerCLI.ConfigFile()
+	candidates, _ := listPluginCandidatesContext(context.Background(), []string{dir}, candidateOptions{prefixes: pluginPrefixes(cfg)}, nil)
+	return getPlugin(name, candidates, cfg, rootcmd)
 }
 
-func getPlugin(name string, pluginDirs []string, rootcmd *cobra.Command) (*Plugin, error) {
-	candidates := listPluginCandidates(pluginDirs)
+func getPlugin(name string, candidates map[string][]string, cfg *configfile.ConfigFile, rootcmd *cobra.Command) (*Plugin, error) {
 	if paths, ok := candidates[name]; ok {
 		if len(paths) == 0 {
 			return nil, errPluginNotFound(name)
 		}
-		c := &candidate{paths[0]}
+		c := &candidate{path: paths[0], name: name, metadataTimeout: getMetadataTimeout(cfg), verifier: newSignatureVerifier(cfg), checksum: pinnedChecksum(cfg, name), cfg: cfg}
 		p, err := newPlugin(c, rootcmd.Commands())
 		if err != nil {
 			return nil, err
@@ -138,72 +453,209 @@ func getPlugin(name string, pluginDirs []string, rootcmd *cobra.Command) (*Plugi
 
 // ListPlugins produces a list of the plugins available on the system
 func ListPlugins(dockerCli config.Provider, rootcmd *cobra.Command) ([]Plugin, error) {
-	pluginDirs := getPluginDirs(dockerCli.ConfigFile())
-	candidates := listPluginCandidates(pluginDirs)
+	return ListPluginsContext(commandContext(rootcmd), dockerCli, rootcmd)
+}
+
+// ListPluginsContext is like ListPlugins, but stops scanning plugin
+// directories and fetching the metadata of plugins when ctx is done, in
+// which case the context's error is returned.
+func ListPluginsContext(ctx context.Context, dockerCli config.Provider, rootcmd *cobra.Command) ([]Plugin, error) {
+	cfg := dockerCli.ConfigFile()
+	candidates, err := listPluginCandidatesContext(ctx, getPluginDirs(cfg), pluginCandidateOptions(cfg), nil)
+	if err != nil {
+		return nil, err
+	}
+	return listPlugins(ctx, dockerCli, rootcmd, candidates)
+}
+
+// ListPluginsWithWarnings is like ListPlugins, but also returns a warning for
+// each plugin directory that exists but could not be listed (for example,
+// due to insufficient permissions), and which may therefore contain plugins
+// that are missing from the list.
+func ListPluginsWithWarnings(dockerCli config.Provider, rootcmd *cobra.Command) ([]Plugin, []error, error) {
+	cfg := dockerCli.ConfigFile()
+	candidates, warnings := listPluginCandidatesWithWarnings(getPluginDirs(cfg), pluginCandidateOptions(cfg))
+	plugins, err := listPlugins(commandContext(rootcmd), dockerCli, rootcmd, candidates)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plugins, warnings, nil
+}
+
+// commandContext returns the context of cmd, or context.Background if it
+// has no context.
+func commandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	// Fallback, mostly for tests that pass a bare cobra.command
+	return context.Background()
+}
+
+func listPlugins(ctx context.Context, dockerCli config.Provider, rootcmd *cobra.Command, candidates map[string][]string) ([]Plugin, error) {
+	cfg := dockerCli.ConfigFile()
+	metadataTimeout := getMetadataTimeout(cfg)
 	if len(candidates) == 0 {
 		return nil, nil
 	}
 
+	cache := loadMetadataCache(cfg)
+	verifier := newSignatureVerifier(cfg)
+
 	var plugins []Plugin
 	var mu sync.Mutex
-	ctx := rootcmd.Context()
-	if ctx == nil {
-		// Fallback, mostly for tests that pass a bare cobra.command
-		ctx = context.Background()
-	}
-	eg, _ := errgroup.WithContext(ctx)
+	eg, egCtx := errgroup.WithContext(ctx)
 	cmds := rootcmd.Commands()
-	for _, paths := range candidates {
-		func(paths []string) {
+	for name, paths := range candidates {
+		func(name string, paths []string) {
 			eg.Go(func() error {
 				if len(paths) == 0 {
 					return nil
 				}
-				c := &candidate{paths[0]}
-				p, err := newPlugin(c, cmds)
+				if err := egCtx.Err(); err != nil {
+					return err
+				}
+				c := &candidate{path: paths[0], name: name, ctx: egCtx, metadataTimeout: metadataTimeout, verifier: verifier, checksum: pinnedChecksum(cfg, name), cfg: cfg}
+				p, err := newPlugin(cache.wrap(c), cmds)
 				if err != nil {
 					return err
 				}
 				if !IsNotFound(p.Err) {
 					p.ShadowedPaths = paths[1:]
+					var shadowed []Plugin
+					if len(p.ShadowedPaths) > 0 && cfg != nil && cfg.CLIPluginsStrictShadowing {
+						if p.Err == nil {
+							p.Err = NewPluginError("plugin shadows other plugins with the same name: %s", strings.Join(p.ShadowedPaths, ", "))
+						}
+						// List the shadowed plugins as well, instead of
+						// hiding them.
+						for _, path := range p.ShadowedPaths {
+							shadowed = append(shadowed, Plugin{
+								Name: name,
+								Path: path,
+								Err:  NewPluginError("plugin is shadowed by %s", p.Path),
+							})
+						}
+					}
 					mu.Lock()
 					defer mu.Unlock()
 					plugins = append(plugins, p)
+					plugins = append(plugins, shadowed...)
 				}
 				return nil
 			})
-		}(paths)
+		}(name, paths)
 	}
 	if err := eg.Wait(); err != nil {
 		return nil, err
 	}
+	// Plugins that were being probed when ctx was cancelled are marked
+	// invalid, instead of returning an error, so check for it here.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cache.save()
+
+	if cfg != nil && cfg.CLIPluginsManifestURL != "" {
+		annotateApproved(ctx, cfg, plugins)
+	}
 
-	sort.Slice(plugins, func(i, j int) bool {
+	// Sort stably, so that shadowed plugins are listed after the plugin
+	// with the same name that takes precedence.
+	sort.SliceStable(plugins, func(i, j int) bool {
 		return sortorder.NaturalLess(plugins[i].Name, plugins[j].Name)
 	})
 
 	return plugins, nil
 }
 
+// ListValidPlugins produces a list of the plugins available on the system,
+// omitting any plugin which failed one of the candidate tests (for example,
+// because it could not be executed or returned invalid metadata).
+func ListValidPlugins(dockerCli config.Provider, rootcmd *cobra.Command) ([]Plugin, error) {
+	plugins, err := ListPlugins(dockerCli, rootcmd)
+	if err != nil {
+		return nil, err
+	}
+	valid := make([]Plugin, 0, len(plugins))
+	for _, p := range plugins {
+		if p.Err == nil {
+			valid = append(valid, p)
+		}
+	}
+	return valid, nil
+}
+
 // PluginRunCommand returns an "os/exec".Cmd which when .Run() will execute the named plugin.
 // The rootcmd argument is referenced to determine the set of builtin commands in order to detect conficts.
 // The error returned satisfies the IsNotFound() predicate if no plugin was found or if the first candidate plugin was invalid somehow.
+//
+// Warnings, such as a plugin that is not signed while signatures are not
+// enforced, are discarded; use [PluginRunCommandWithWarnings] to print them.
 func PluginRunCommand(dockerCli config.Provider, name string, rootcmd *cobra.Command) (*exec.Cmd, error) {
 	// This uses the full original args, not the args which may
 	// have been provided by cobra to our caller. This is because
 	// they lack e.g. global options which we must propagate here.
-	args := os.Args[1:]
+	cmd, _, err := pluginRunCommand(dockerCli, name, os.Args[1:], rootcmd, nil)
+	return cmd, err
+}
+
+// PluginRunCommandWithWarnings is like PluginRunCommand, but also returns
+// warnings about the plugin that do not prevent it from being run, for
+// example if the plugin is not signed while the signature policy is "warn".
+func PluginRunCommandWithWarnings(dockerCli config.Provider, name string, rootcmd *cobra.Command) (*exec.Cmd, []error, error) {
+	cmd, _, warnings, err := ResolvePluginCommand(dockerCli, name, rootcmd)
+	return cmd, warnings, err
+}
+
+// ResolvePluginCommand is like PluginRunCommandWithWarnings, but also
+// returns the plugin that is run by the command. This is not the named
+// plugin if name is a plugin alias, and the path of the plugin is not the
+// path of the command if the plugin is run through an exec wrapper, or in
+// a container.
+func ResolvePluginCommand(dockerCli config.Provider, name string, rootcmd *cobra.Command) (*exec.Cmd, *Plugin, []error, error) {
+	var warnings []error
+	cmd, plugin, err := pluginRunCommand(dockerCli, name, os.Args[1:], rootcmd, func(err error) {
+		warnings = append(warnings, err)
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return cmd, plugin, warnings, nil
+}
+
+// pluginRunCommand returns an "os/exec".Cmd which runs the named plugin
+// with the given arguments, which must include the name of the plugin.
+// It also returns the plugin that is run by the command. If warn is not
+// nil, it is called for each warning about the plugin.
+func pluginRunCommand(dockerCli config.Provider, name string, args []string, rootcmd *cobra.Command, warn func(error)) (*exec.Cmd, *Plugin, error) {
 	if !pluginNameRe.MatchString(name) {
 		// We treat this as "not found" so that callers will
 		// fallback to their "invalid" command path.
-		return nil, errPluginNotFound(name)
+		return nil, nil, errPluginNotFound(name)
+	}
+	if pluginsDisabled.Load() {
+		return nil, nil, errPluginsDisabled(name)
+	}
+	cfg := dockerCli.ConfigFile()
+	candidates := listConfiguredPluginCandidates(cfg)
+
+	if len(candidates[name]) == 0 {
+		target, err := resolvePluginAlias(dockerCli, name, rootcmd)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(target) > 0 {
+			args = replaceSubcommand(rootcmd, args, name, target...)
+			name = target[0]
+		}
 	}
-	exename := addExeSuffix(metadata.NamePrefix + name)
-	pluginDirs := getPluginDirs(dockerCli.ConfigFile())
 
-	for _, d := range pluginDirs {
-		path := filepath.Join(d, exename)
+	if paths := candidates[name]; len(paths) > 1 && cfg != nil && cfg.CLIPluginsStrictShadowing {
+		return nil, nil, &ShadowingError{Name: name, Path: paths[0], ShadowedPaths: paths[1:]}
+	}
 
+	for _, path := range candidates[name] {
 		// We stat here rather than letting the exec tell us
 		// ENOENT because the latter does not distinguish a
 		// file not existing from its dynamic loader or one of
@@ -212,16 +664,31 @@ func PluginRunCommand(dockerCli config.Provider, name string, rootcmd *cobra.Com
 			continue
 		}
 
-		c := &candidate{path: path}
+		verifier := newSignatureVerifier(cfg)
+		c := &candidate{path: path, name: name, metadataTimeout: getMetadataTimeout(cfg), verifier: verifier, checksum: pinnedChecksum(cfg, name), cfg: cfg}
 		plugin, err := newPlugin(c, rootcmd.Commands())
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		if plugin.Err != nil {
-			// TODO: why are we not returning plugin.Err?
-			return nil, errPluginNotFound(name)
+		if err := checkPluginRunnable(commandContext(rootcmd), cfg, plugin, candidates, verifier, warn); err != nil {
+			return nil, nil, err
 		}
-		cmd := exec.Command(plugin.Path, args...) // #nosec G204 -- ignore "Subprocess launched with a potential tainted input or cmd arguments"
+		execPath, execArgs := plugin.Path, args
+		if isContainerPlugin(plugin.Path) {
+			// The global options are passed to "docker run" instead of the
+			// plugin, which cannot use them inside the container.
+			runOpts := newContainerRunOptions(dockerCli, rootcmd, args)
+			pluginArgs := args[len(runOpts.globalArgs):]
+			// Only allocate a TTY if the CLI is attached to one; completion
+			// requests are never interactive.
+			runOpts.tty = term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd()) &&
+				(len(pluginArgs) == 0 || pluginArgs[0] != cobra.ShellCompRequestCmd)
+			execPath, execArgs, err = containerPluginCommand(plugin.Name, plugin.Path, pluginArgs, runOpts)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		cmd := pluginExecCommand(cfg, execPath, execArgs)
 
 		// Using dockerCli.{In,Out,Err}() here results in a hang until something is input.
 		// See: - https://github.com/golang/go/issues/10338
@@ -231,13 +698,420 @@ func PluginRunCommand(dockerCli config.Provider, name string, rootcmd *cobra.Com
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
+		if cfg != nil && cfg.CLIPluginsPrefixStderr {
+			cmd.Stderr = newPrefixWriter(os.Stderr, "["+plugin.Name+"] ")
+		}
+
+		cmd.Env, err = pluginEnv(cmd.Environ(), cfg, plugin, rootcmd)
+		if err != nil {
+			return nil, nil, err
+		}
+		return cmd, &plugin, nil
+	}
+	return nil, nil, errPluginNotFound(name)
+}
 
-		cmd.Env = append(cmd.Environ(), metadata.ReexecEnvvar+"="+os.Args[0])
-		cmd.Env = appendPluginResourceAttributesEnvvar(cmd.Env, rootcmd, plugin)
+// checkPluginRunnable returns an error if the plugin must not be run, for
+// example because it is incompatible with the CLI, because its signature
+// or pinned checksum could not be verified, or because it is not approved
+// by the plugin manifest. If warn is not nil, it is called for each warning
+// about the plugin. It is used both to run the plugin's commands and its
+// hooks.
+func checkPluginRunnable(ctx context.Context, cfg *configfile.ConfigFile, plugin Plugin, candidates map[string][]string, verifier *signatureVerifier, warn func(error)) error {
+	if plugin.Err != nil {
+		// Report plugins that declare that they are incompatible,
+		// instead of pretending that the command does not exist.
+		var incompatible *incompatibleError
+		if errors.As(plugin.Err, &incompatible) {
+			return &IncompatiblePluginError{Name: plugin.Name, Err: incompatible}
+		}
+		var sigErr *SignatureError
+		if errors.As(plugin.Err, &sigErr) {
+			return sigErr
+		}
+		var checksumErr *ChecksumMismatchError
+		if errors.As(plugin.Err, &checksumErr) {
+			return checksumErr
+		}
+		// TODO: why are we not returning plugin.Err?
+		return errPluginNotFound(plugin.Name)
+	}
+	if err := checkRequiredPlugins(plugin, candidates); err != nil {
+		return err
+	}
+	// Verify the checksum again, as the plugin may have been replaced
+	// after fetching its metadata.
+	if err := verifyChecksum(plugin, cfg); err != nil {
+		return err
+	}
+	if cfg != nil && cfg.CLIPluginsManifestURL != "" {
+		if err := verifyApproved(ctx, cfg, plugin); err != nil {
+			return err
+		}
+	}
+	if verifier != nil {
+		// Verify the signature again, as the plugin may have been
+		// replaced after fetching its metadata.
+		if err := verifier.verify(plugin.Path); err != nil {
+			if verifier.enforce {
+				return err
+			}
+			if warn != nil {
+				warn(err)
+			}
+		}
+	}
+	return nil
+}
 
-		return cmd, nil
+// pluginEnv returns the environment to run the plugin with, based on env,
+// which is the environment of the CLI. It is used both to run the plugin's
+// commands and its hooks.
+func pluginEnv(env []string, cfg *configfile.ConfigFile, plugin Plugin, rootcmd *cobra.Command) ([]string, error) {
+	envFile, err := readPluginEnvFile(plugin)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errPluginNotFound(name)
+	// Variables from the plugin's env-file take precedence over the
+	// environment of the CLI, but not over the variables set below.
+	env = append(filterPluginEnv(env, cfg), envFile...)
+	env = append(env, metadata.ReexecEnvvar+"="+os.Args[0])
+	// Pass the effective config directory, which may have been set
+	// through the "--config" flag, so that the plugin uses the same
+	// configuration as the CLI that invoked it.
+	env = append(env, config.EnvOverrideConfigDir+"="+config.Dir())
+	if rootcmd != nil {
+		env = appendPluginResourceAttributesEnvvar(env, rootcmd, plugin)
+	}
+	return env, nil
+}
+
+// readPluginEnvFile reads the optional env-file for the plugin, which is
+// named after the plugin ("<name>.env"), and located in the same directory
+// as the plugin. No variables are returned if the file does not exist.
+func readPluginEnvFile(p Plugin) ([]string, error) {
+	envFile := filepath.Join(filepath.Dir(p.Path), p.Name+".env")
+	env, err := kvfile.Parse(envFile, nil)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read env-file for plugin %q: %w", p.Name, err)
+	}
+	return env, nil
+}
+
+// essentialPluginEnv are the environment variables that are passed to CLI
+// plugins, even if they are not in [ConfigFile.CLIPluginsEnvAllowlist],
+// because plugins need them to connect to the daemon in the same way as
+// the CLI (SSH_AUTH_SOCK is used for "ssh://" hosts), or to interact with
+// the user's terminal and locale.
+//
+// [ConfigFile.CLIPluginsEnvAllowlist]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsEnvAllowlist
+var essentialPluginEnv = []string{
+	"DOCKER_API_VERSION",
+	"DOCKER_CERT_PATH",
+	"DOCKER_CONTEXT",
+	"DOCKER_HOST",
+	"DOCKER_TLS",
+	"DOCKER_TLS_VERIFY",
+	"LANG",
+	"LOGNAME",
+	"SSH_AUTH_SOCK",
+	"TERM",
+	"USER",
+}
+
+// essentialPluginEnvPrefixes are the prefixes of the names of environment
+// variables that are passed to CLI plugins, even if they are not in the
+// allowlist, such as the LC_* locale variables.
+var essentialPluginEnvPrefixes = []string{"LC_"}
+
+// filterPluginEnv returns the variables in env that are in the allowlist
+// of the given config, or that are essential to run plugins. All variables
+// are returned if the allowlist is empty.
+func filterPluginEnv(env []string, cfg *configfile.ConfigFile) []string {
+	if cfg == nil || len(cfg.CLIPluginsEnvAllowlist) == 0 {
+		return env
+	}
+	allowed := make(map[string]struct{})
+	for _, names := range [][]string{cfg.CLIPluginsEnvAllowlist, essentialPluginEnv, platformPluginEnv} {
+		for _, name := range names {
+			allowed[envKey(name)] = struct{}{}
+		}
+	}
+	filtered := make([]string, 0, len(allowed))
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, ok := allowed[envKey(name)]; ok || hasEssentialEnvPrefix(name) {
+			filtered = append(filtered, kv)
+		}
+	}
+	return filtered
+}
+
+// hasEssentialEnvPrefix returns whether name has one of the prefixes in
+// essentialPluginEnvPrefixes.
+func hasEssentialEnvPrefix(name string) bool {
+	for _, prefix := range essentialPluginEnvPrefixes {
+		if strings.HasPrefix(envKey(name), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginExecCommand returns the command to run the plugin at path with the
+// given arguments. If an exec wrapper is configured through
+// [ConfigFile.CLIPluginsExecWrapper], the plugin is run through the wrapper,
+// with the path of the plugin and its arguments appended to the arguments of
+// the wrapper.
+//
+// [ConfigFile.CLIPluginsExecWrapper]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsExecWrapper
+func pluginExecCommand(cfg *configfile.ConfigFile, path string, args []string) *exec.Cmd {
+	if cfg == nil || len(cfg.CLIPluginsExecWrapper) == 0 {
+		return exec.Command(path, args...) // #nosec G204 -- ignore "Subprocess launched with a potential tainted input or cmd arguments"
+	}
+	wrapper := cfg.CLIPluginsExecWrapper
+	return exec.Command(wrapper[0], execWrapperArgs(wrapper, path, args)...) // #nosec G204 -- ignore "Subprocess launched with a potential tainted input or cmd arguments"
+}
+
+// pluginExecCommandContext is like pluginExecCommand, but the plugin (or the
+// exec wrapper) is killed if ctx is done before the command completes.
+func pluginExecCommandContext(ctx context.Context, cfg *configfile.ConfigFile, path string, args []string) *exec.Cmd {
+	if cfg == nil || len(cfg.CLIPluginsExecWrapper) == 0 {
+		return exec.CommandContext(ctx, path, args...) // #nosec G204 -- ignore "Subprocess launched with a potential tainted input or cmd arguments"
+	}
+	wrapper := cfg.CLIPluginsExecWrapper
+	return exec.CommandContext(ctx, wrapper[0], execWrapperArgs(wrapper, path, args)...) // #nosec G204 -- ignore "Subprocess launched with a potential tainted input or cmd arguments"
+}
+
+// execWrapperArgs returns the arguments of the exec wrapper to run the
+// plugin at path with the given arguments.
+func execWrapperArgs(wrapper []string, path string, args []string) []string {
+	wrapperArgs := make([]string, 0, len(wrapper)+len(args))
+	wrapperArgs = append(wrapperArgs, wrapper[1:]...)
+	wrapperArgs = append(wrapperArgs, path)
+	return append(wrapperArgs, args...)
+}
+
+// resolvePluginAlias returns the plugin command that name is an alias for, as
+// configured through [ConfigFile.CLIPluginsAliases]. The first element is the
+// name of the plugin, optionally followed by its subcommand and arguments,
+// for example "buildx build". It returns nil if name is not an alias, and an
+// error if the alias refers to a plugin that is not installed or not valid.
+//
+// [ConfigFile.CLIPluginsAliases]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsAliases
+func resolvePluginAlias(dockerCli config.Provider, name string, rootcmd *cobra.Command) ([]string, error) {
+	cfg := dockerCli.ConfigFile()
+	if cfg == nil {
+		return nil, nil
+	}
+	alias, ok := cfg.CLIPluginsAliases[name]
+	if !ok {
+		return nil, nil
+	}
+	target := strings.Fields(alias)
+	if len(target) == 0 {
+		return nil, fmt.Errorf("plugin alias %q does not refer to a plugin", name)
+	}
+	p, err := GetPlugin(target[0], dockerCli, rootcmd)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, fmt.Errorf("plugin alias %q refers to plugin %q which is not installed", name, target[0])
+		}
+		return nil, err
+	}
+	if p.Err != nil {
+		return nil, fmt.Errorf("plugin alias %q refers to plugin %q which is not valid: %w", name, target[0], p.Err)
+	}
+	return target, nil
+}
+
+// CheckPluginAliases returns an error for each alias in
+// [ConfigFile.CLIPluginsAliases] that is not used, because a builtin command,
+// an alias of a builtin command, or a CLI plugin with the same name exists,
+// or that does not refer to a plugin that is installed and valid. The errors
+// are sorted by alias.
+//
+// [ConfigFile.CLIPluginsAliases]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsAliases
+func CheckPluginAliases(dockerCli config.Provider, rootcmd *cobra.Command) []error {
+	cfg := dockerCli.ConfigFile()
+	if cfg == nil || len(cfg.CLIPluginsAliases) == 0 {
+		return nil
+	}
+	aliases := make([]string, 0, len(cfg.CLIPluginsAliases))
+	for alias := range cfg.CLIPluginsAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	candidates := listConfiguredPluginCandidates(cfg)
+	var errs []error
+	for _, alias := range aliases {
+		if cmd := findBuiltinCommand(rootcmd, alias); cmd != nil {
+			if cmd.Name() == alias {
+				errs = append(errs, fmt.Errorf("plugin alias %q is not used: it conflicts with builtin command %q", alias, alias))
+			} else {
+				errs = append(errs, fmt.Errorf("plugin alias %q is not used: it conflicts with an alias of builtin command %q", alias, cmd.Name()))
+			}
+			continue
+		}
+		if len(candidates[alias]) > 0 {
+			errs = append(errs, fmt.Errorf("plugin alias %q is not used: a CLI plugin with the same name is installed", alias))
+			continue
+		}
+		if _, err := resolvePluginAlias(dockerCli, alias, rootcmd); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// findBuiltinCommand returns the builtin subcommand of rootcmd with the given
+// name or alias, ignoring plugin command stubs.
+func findBuiltinCommand(rootcmd *cobra.Command, name string) *cobra.Command {
+	for _, cmd := range rootcmd.Commands() {
+		if IsPluginCommand(cmd) {
+			continue
+		}
+		if cmd.Name() == name || cmd.HasAlias(name) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// subcommandIndex returns the index of the subcommand in args, which is the
+// first argument that is not a global option of rootcmd or the value of one,
+// or -1 if args has no subcommand. Options that are not defined on rootcmd
+// are assumed to not take a value.
+func subcommandIndex(rootcmd *cobra.Command, args []string) int {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			if i+1 < len(args) {
+				return i + 1
+			}
+			return -1
+		case strings.HasPrefix(arg, "--"):
+			name, _, hasValue := strings.Cut(arg[2:], "=")
+			if !hasValue && flagTakesValue(lookupGlobalFlag(rootcmd, name, "")) {
+				i++
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// Shorthand options can be combined ("-Dl debug"), and the value
+			// of the last one can be attached to it ("-ldebug").
+			for j := 1; j < len(arg); j++ {
+				if flagTakesValue(lookupGlobalFlag(rootcmd, "", arg[j:j+1])) {
+					if j == len(arg)-1 {
+						i++
+					}
+					break
+				}
+			}
+		default:
+			return i
+		}
+	}
+	return -1
+}
+
+// lookupGlobalFlag returns the flag of rootcmd with the given name, or with
+// the given shorthand if name is empty. It returns nil if rootcmd is nil, or
+// if the flag is not defined.
+func lookupGlobalFlag(rootcmd *cobra.Command, name, shorthand string) *pflag.Flag {
+	if rootcmd == nil {
+		return nil
+	}
+	for _, flags := range []*pflag.FlagSet{rootcmd.Flags(), rootcmd.PersistentFlags()} {
+		if name != "" {
+			if f := flags.Lookup(name); f != nil {
+				return f
+			}
+		} else if f := flags.ShorthandLookup(shorthand); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// flagTakesValue returns whether f requires a value, which is the case for
+// flags that have no default value when used without a value, unlike
+// boolean flags.
+func flagTakesValue(f *pflag.Flag) bool {
+	return f != nil && f.NoOptDefVal == ""
+}
+
+// replaceSubcommand returns a copy of args with the subcommand, as determined
+// by subcommandIndex, replaced by replacement, which may consist of multiple
+// arguments. The subcommand following a completion request is replaced for
+// completion requests. args is returned unchanged if the subcommand is not
+// old, for example because old is the value of a global option.
+func replaceSubcommand(rootcmd *cobra.Command, args []string, old string, replacement ...string) []string {
+	out := make([]string, len(args))
+	copy(out, args)
+	i := subcommandIndex(rootcmd, args)
+	if i >= 0 && args[i] == cobra.ShellCompRequestCmd {
+		i++
+	}
+	if i < 0 || i >= len(args) || args[i] != old {
+		return out
+	}
+	out = make([]string, 0, len(args)+len(replacement)-1)
+	out = append(out, args[:i]...)
+	out = append(out, replacement...)
+	return append(out, args[i+1:]...)
+}
+
+// pinnedChecksum returns the checksum that is pinned for the plugin with the
+// given name through [ConfigFile.CLIPluginsChecksums], or an empty string if
+// no checksum is pinned.
+//
+// [ConfigFile.CLIPluginsChecksums]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsChecksums
+func pinnedChecksum(cfg *configfile.ConfigFile, name string) string {
+	if cfg == nil {
+		return ""
+	}
+	return cfg.CLIPluginsChecksums[name]
+}
+
+// verifyChecksum returns a ChecksumMismatchError if the plugin's checksum
+// does not match the checksum pinned for it in the config. Plugins without
+// a pinned checksum are not verified.
+func verifyChecksum(p Plugin, cfg *configfile.ConfigFile) error {
+	expected := pinnedChecksum(cfg, p.Name)
+	if expected == "" {
+		return nil
+	}
+	return verifyFileChecksum(p.Name, p.Path, expected)
+}
+
+// verifyFileChecksum returns a ChecksumMismatchError if the SHA256 checksum
+// of the plugin at path does not match the expected checksum, which may be
+// prefixed with "sha256:".
+func verifyFileChecksum(name, path, expected string) error {
+	actual, err := fileSHA256(path)
+	if err != nil {
+		return fmt.Errorf("failed to verify checksum of plugin %q: %w", name, err)
+	}
+	expected = strings.TrimPrefix(strings.ToLower(expected), "sha256:")
+	if actual != expected {
+		return &ChecksumMismatchError{Name: name, Path: path, Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+// checkRequiredPlugins returns an error if any of the plugins listed in the
+// plugin's Requires metadata is not one of the plugin candidates.
+func checkRequiredPlugins(p Plugin, candidates map[string][]string) error {
+	for _, name := range p.Requires {
+		if len(candidates[name]) == 0 {
+			return fmt.Errorf("plugin %q requires plugin %q which is not installed", p.Name, name)
+		}
+	}
+	return nil
 }
 
 // IsPluginCommand checks if the given cmd is a plugin-stub.
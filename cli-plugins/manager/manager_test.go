@@ -1,15 +1,27 @@
 package manager
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/docker/cli/cli-plugins/metadata"
 	"github.com/docker/cli/cli/config"
 	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/cli/version"
 	"github.com/docker/cli/internal/test"
 	"github.com/spf13/cobra"
 	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
 	"gotest.tools/v3/fs"
 )
 
@@ -80,6 +92,66 @@ func TestListPluginCandidates(t *testing.T) {
 	}
 
 	assert.DeepEqual(t, candidates, exp)
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: dirs})
+	candidates = ListPluginCandidates(cli)
+	for name, paths := range exp {
+		assert.DeepEqual(t, candidates[name], paths)
+	}
+}
+
+func TestListPluginCandidatesSymlinkedDir(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("other",
+			fs.WithFile("docker-other", ""),
+		),
+		fs.WithDir("plugins",
+			fs.WithFile("docker-plugin1", ""),
+			fs.WithDir("v1", // A directory should be ignored ...
+				fs.WithFile("docker-plugin2", ""),
+			),
+			fs.WithDir("v2",
+				fs.WithFile("docker-plugin1", ""),
+				fs.WithFile("docker-plugin2", ""),
+				fs.WithSymlink("nested", "../../other"), // Only one level of symlinks is followed
+			),
+			fs.WithSymlink("current", "v2"), // ... but a symlink to a directory is followed
+			fs.WithSymlink("loop", "."),     // Already visited
+		),
+	)
+	defer dir.Remove()
+
+	candidates := listPluginCandidates([]string{dir.Join("plugins")})
+	assert.DeepEqual(t, candidates, map[string][]string{
+		"plugin1": {
+			dir.Join("plugins", "docker-plugin1"),
+			dir.Join("plugins", "current", "docker-plugin1"),
+		},
+		"plugin2": {
+			dir.Join("plugins", "current", "docker-plugin2"),
+		},
+	})
+}
+
+func TestPluginRunCommandSymlinkedDir(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("v2",
+			fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+		),
+		fs.WithDir("plugins",
+			fs.WithSymlink("current", "../v2"),
+		),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Join("plugins")}})
+
+	cmd, err := PluginRunCommand(cli, "aaa", &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Equal(t, cmd.Path, dir.Join("plugins", "current", "docker-aaa"))
 }
 
 func TestListPluginCandidatesEmpty(t *testing.T) {
@@ -119,6 +191,9 @@ func TestGetPlugin(t *testing.T) {
 		fs.WithFile("docker-aaa", `
 #!/bin/sh
 echo '{"SchemaVersion":"0.1.0"}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-garbage", `#!/bin/sh
+echo 'panic: runtime error: invalid memory address or nil pointer dereference'
+echo 'goroutine 1 [running]:'`, fs.WithMode(0o777)),
 	)
 	defer dir.Remove()
 
@@ -129,11 +204,101 @@ func TestGetPlugin(t *testing.T) {
 	assert.NilError(t, err)
 	assert.Equal(t, plugin.Name, "bbb")
 
+	plugin, err = GetPlugin("garbage", cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Error(t, plugin.Err, `invalid metadata output: expected JSON, but the plugin printed "panic: runtime error: invalid memory address or nil pointer dereference"`)
+
 	_, err = GetPlugin("ccc", cli, &cobra.Command{})
 	assert.Error(t, err, "Error: No such CLI plugin: ccc")
 	assert.Assert(t, IsNotFound(err))
 }
 
+func TestGetPluginArchMismatch(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		// Not a valid executable for any platform.
+		fs.WithFile("docker-aaa", "\x7fELF\x02\x01\x01", fs.WithMode(0o777)),
+		fs.WithFile("docker-bbb", `#!/bin/sh
+exit 1`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	plugin, err := GetPlugin("aaa", cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Check(t, IsArchMismatch(plugin.Err))
+	assert.Check(t, is.ErrorContains(plugin.Err, "plugin built for wrong architecture"))
+
+	plugin, err = GetPlugin("bbb", cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Check(t, plugin.Err != nil)
+	assert.Check(t, !IsArchMismatch(plugin.Err))
+}
+
+func TestGetPluginMinCLIVersion(t *testing.T) {
+	defer func(orig string) { version.Version = orig }(version.Version)
+	version.Version = "28.1.0"
+
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing","MinCLIVersion":"28.0.0"}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-bbb", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing","MinCLIVersion":"29.0.0"}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	plugin, err := GetPlugin("aaa", cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Check(t, plugin.Err)
+
+	plugin, err = GetPlugin("bbb", cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Check(t, is.Error(plugin.Err, "plugin requires CLI >= 29.0.0 (current version is 28.1.0)"))
+
+	_, err = PluginRunCommand(cli, "bbb", &cobra.Command{})
+	assert.Check(t, !IsNotFound(err))
+	var incompatible *IncompatiblePluginError
+	assert.Check(t, errors.As(err, &incompatible))
+	assert.Check(t, is.Error(err, `refusing to run plugin "bbb": plugin requires CLI >= 29.0.0 (current version is 28.1.0)`))
+}
+
+func TestGetPluginPlatforms(t *testing.T) {
+	otherOS := "windows"
+	if runtime.GOOS == "windows" {
+		otherOS = "linux"
+	}
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.2.0","Vendor":"e2e-testing","Platforms":["`+otherOS+`","`+runtime.GOOS+`/`+runtime.GOARCH+`"]}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-bbb", `#!/bin/sh
+echo '{"SchemaVersion":"0.2.0","Vendor":"e2e-testing","Platforms":["`+runtime.GOOS+`"]}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-ccc", `#!/bin/sh
+echo '{"SchemaVersion":"0.2.0","Vendor":"e2e-testing","Platforms":["`+otherOS+`/amd64"]}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	for _, name := range []string{"aaa", "bbb"} {
+		plugin, err := GetPlugin(name, cli, &cobra.Command{})
+		assert.NilError(t, err)
+		assert.Check(t, plugin.Err, name)
+	}
+
+	plugin, err := GetPlugin("ccc", cli, &cobra.Command{})
+	assert.NilError(t, err)
+	expected := "plugin does not support " + runtime.GOOS + "/" + runtime.GOARCH + " (supported platforms: " + otherOS + "/amd64)"
+	assert.Check(t, is.Error(plugin.Err, expected))
+
+	_, err = PluginRunCommand(cli, "ccc", &cobra.Command{})
+	assert.Check(t, is.Error(err, `refusing to run plugin "ccc": `+expected))
+}
+
 func TestListPluginsIsSorted(t *testing.T) {
 	dir := fs.NewDir(t, t.Name(),
 		fs.WithFile("docker-bbb", `
@@ -162,6 +327,229 @@ func TestListPluginsIsSorted(t *testing.T) {
 	assert.DeepEqual(t, names, []string{"aaa", "bbb"})
 }
 
+func TestListPluginsContextCancelScan(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("plugins1"),
+		fs.WithDir("plugins2"),
+	)
+	defer dir.Remove()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var scanned []string
+	defer func(orig func(string) ([]os.DirEntry, error)) { readDir = orig }(readDir)
+	readDir = func(name string) ([]os.DirEntry, error) {
+		scanned = append(scanned, name)
+		cancel()
+		return os.ReadDir(name)
+	}
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Join("plugins1"), dir.Join("plugins2")}})
+
+	_, err := ListPluginsContext(ctx, cli, &cobra.Command{})
+	assert.Check(t, is.ErrorIs(err, context.Canceled))
+	assert.Check(t, is.DeepEqual(scanned, []string{dir.Join("plugins1")}))
+}
+
+func TestListPluginsContextCancelMetadata(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-slow", `#!/bin/sh
+sleep 10`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs:       []string{dir.Path()},
+		CLIPluginsMetadataTimeout: "30s",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := ListPluginsContext(ctx, cli, &cobra.Command{})
+	assert.Check(t, is.ErrorIs(err, context.DeadlineExceeded))
+	assert.Check(t, time.Since(start) < 5*time.Second)
+}
+
+func TestListValidPlugins(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+		fs.WithSymlink("docker-brokensymlink", "broken"),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	plugins, err := ListPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	var names []string
+	for _, p := range plugins {
+		if p.Name == "aaa" || p.Name == "brokensymlink" {
+			names = append(names, p.Name)
+		}
+	}
+	assert.DeepEqual(t, names, []string{"aaa", "brokensymlink"})
+
+	plugins, err = ListValidPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	names = nil
+	for _, p := range plugins {
+		assert.NilError(t, p.Err)
+		if p.Name == "aaa" || p.Name == "brokensymlink" {
+			names = append(names, p.Name)
+		}
+	}
+	assert.DeepEqual(t, names, []string{"aaa"})
+}
+
+func TestListPluginsCapabilities(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing","Capabilities":["network","filesystem"]}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-bbb", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	plugins, err := ListPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	capabilities := map[string][]string{}
+	for _, p := range plugins {
+		if p.Name == "aaa" || p.Name == "bbb" {
+			assert.NilError(t, p.Err)
+			capabilities[p.Name] = p.Capabilities
+		}
+	}
+	assert.DeepEqual(t, capabilities, map[string][]string{
+		"aaa": {"network", "filesystem"},
+		"bbb": nil,
+	})
+}
+
+func TestListPluginsStrictShadowing(t *testing.T) {
+	const plugin = `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("amd64",
+			fs.WithFile("docker-aaa", plugin, fs.WithMode(0o777)),
+			fs.WithFile("docker-bbb", plugin, fs.WithMode(0o777)),
+		),
+		fs.WithDir("arm64",
+			fs.WithFile("docker-aaa", plugin, fs.WithMode(0o777)),
+		),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Join("amd64"), dir.Join("arm64")}})
+
+	getPlugins := func() map[string]Plugin {
+		t.Helper()
+		plugins, err := ListPlugins(cli, &cobra.Command{})
+		assert.NilError(t, err)
+		res := make(map[string]Plugin)
+		for _, p := range plugins {
+			if _, ok := res[p.Name]; !ok {
+				res[p.Name] = p
+			}
+		}
+		return res
+	}
+
+	plugins := getPlugins()
+	assert.Check(t, plugins["aaa"].Err)
+	assert.Check(t, is.DeepEqual(plugins["aaa"].ShadowedPaths, []string{dir.Join("arm64", "docker-aaa")}))
+
+	cli.ConfigFile().CLIPluginsStrictShadowing = true
+	plugins = getPlugins()
+	assert.Check(t, is.Error(plugins["aaa"].Err, "plugin shadows other plugins with the same name: "+dir.Join("arm64", "docker-aaa")))
+	assert.Check(t, plugins["bbb"].Err)
+
+	// Shadowed plugins are listed after the plugin that takes precedence.
+	list, err := ListPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(list, 3))
+	assert.Check(t, is.Equal(list[0].Path, dir.Join("amd64", "docker-aaa")))
+	assert.Check(t, is.Equal(list[1].Path, dir.Join("arm64", "docker-aaa")))
+	assert.Check(t, is.Error(list[1].Err, "plugin is shadowed by "+dir.Join("amd64", "docker-aaa")))
+
+	// Plugins that shadow other plugins are not run.
+	_, err = PluginRunCommand(cli, "aaa", &cobra.Command{})
+	var shadowingErr *ShadowingError
+	assert.Assert(t, errors.As(err, &shadowingErr))
+	assert.Check(t, is.DeepEqual(shadowingErr.ShadowedPaths, []string{dir.Join("arm64", "docker-aaa")}))
+	_, err = PluginRunCommand(cli, "bbb", &cobra.Command{})
+	assert.Check(t, err)
+}
+
+func TestGetPluginMetadataTimeout(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-slow", `#!/bin/sh
+sleep 10
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs:       []string{dir.Path()},
+		CLIPluginsMetadataTimeout: "100ms",
+	})
+
+	start := time.Now()
+	plugin, err := GetPlugin("slow", cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Error(t, plugin.Err, "failed to fetch metadata: timed out after 100ms")
+	assert.Check(t, time.Since(start) < 5*time.Second)
+}
+
+func TestGetMetadataTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		timeout  string
+		expected time.Duration
+	}{
+		{timeout: "", expected: defaultMetadataTimeout},
+		{timeout: "10s", expected: 10 * time.Second},
+		{timeout: "-1s", expected: defaultMetadataTimeout},
+		{timeout: "invalid", expected: defaultMetadataTimeout},
+	} {
+		t.Run(tc.timeout, func(t *testing.T) {
+			cfg := &configfile.ConfigFile{CLIPluginsMetadataTimeout: tc.timeout}
+			assert.Equal(t, getMetadataTimeout(cfg), tc.expected)
+		})
+	}
+	assert.Equal(t, getMetadataTimeout(nil), defaultMetadataTimeout)
+}
+
+func TestPluginRunCommandRequiredPlugins(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing","Requires":["bbb"]}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-ccc", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing","Requires":["aaa"]}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	_, err := PluginRunCommand(cli, "aaa", &cobra.Command{})
+	assert.Error(t, err, `plugin "aaa" requires plugin "bbb" which is not installed`)
+	assert.Check(t, !IsNotFound(err))
+
+	_, err = PluginRunCommand(cli, "ccc", &cobra.Command{})
+	assert.NilError(t, err)
+}
+
 func TestErrPluginNotFound(t *testing.T) {
 	var err error = errPluginNotFound("test")
 	err.(errPluginNotFound).NotFound()
@@ -170,7 +558,54 @@ func TestErrPluginNotFound(t *testing.T) {
 	assert.Assert(t, !IsNotFound(nil))
 }
 
+func TestPluginsDisabled(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	var scans int
+	defer func(orig func(string) ([]os.DirEntry, error)) { readDir = orig }(readDir)
+	readDir = func(name string) ([]os.DirEntry, error) {
+		scans++
+		return os.ReadDir(name)
+	}
+
+	// Reset the global state, so that other tests are not affected.
+	pluginCommandStubsOnce = sync.Once{}
+	t.Cleanup(func() {
+		SetPluginsDisabled(false)
+		pluginCommandStubsOnce = sync.Once{}
+	})
+	SetPluginsDisabled(true)
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	plugins, err := ListPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(plugins, 0))
+
+	rootCmd := &cobra.Command{}
+	assert.NilError(t, AddPluginCommandStubs(cli, rootCmd))
+	assert.Check(t, is.Len(rootCmd.Commands(), 0))
+
+	_, err = PluginRunCommand(cli, "aaa", &cobra.Command{})
+	assert.Check(t, is.Error(err, `cannot run CLI plugin "aaa": CLI plugins are disabled`))
+	assert.Check(t, IsPluginsDisabled(err))
+	assert.Check(t, IsNotFound(err))
+
+	assert.Check(t, is.Equal(scans, 0))
+
+	SetPluginsDisabled(false)
+	_, err = PluginRunCommand(cli, "aaa", &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Check(t, scans > 0)
+}
+
 func TestGetPluginDirs(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "")
 	cli := test.NewFakeCli(nil)
 
 	pluginDir := filepath.Join(config.Dir(), "cli-plugins")
@@ -188,4 +623,594 @@ func TestGetPluginDirs(t *testing.T) {
 	})
 	pluginDirs = getPluginDirs(cli.ConfigFile())
 	assert.DeepEqual(t, expected, pluginDirs)
+
+	// Directories that resolve to the same path are only included once,
+	// at the position where they were first seen.
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs: []string{"foo", pluginDir, "./foo", pluginDir + string(filepath.Separator)},
+	})
+	expected = append([]string{"foo", pluginDir}, defaultSystemPluginDirs...)
+	pluginDirs = getPluginDirs(cli.ConfigFile())
+	assert.DeepEqual(t, expected, pluginDirs)
+}
+
+func TestGetPluginDirsXDG(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG_CONFIG_HOME is only used on Linux")
+	}
+	home, err := os.UserHomeDir()
+	assert.NilError(t, err)
+	defer config.SetDir(config.Dir())
+	config.SetDir(filepath.Join(home, ".docker"))
+
+	xdgConfigHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdgConfigHome)
+
+	cli := test.NewFakeCli(nil)
+	pluginDir := filepath.Join(config.Dir(), "cli-plugins")
+	xdgPluginDir := filepath.Join(xdgConfigHome, "docker", "cli-plugins")
+	expected := append([]string{xdgPluginDir, pluginDir}, defaultSystemPluginDirs...)
+	assert.DeepEqual(t, getPluginDirs(cli.ConfigFile()), expected)
+
+	// XDG_CONFIG_HOME is ignored if it's not an absolute path.
+	t.Setenv("XDG_CONFIG_HOME", "relative")
+	expected = append([]string{pluginDir}, defaultSystemPluginDirs...)
+	assert.DeepEqual(t, getPluginDirs(cli.ConfigFile()), expected)
+
+	// XDG_CONFIG_HOME is ignored if the config directory was changed.
+	t.Setenv("XDG_CONFIG_HOME", xdgConfigHome)
+	configDir := t.TempDir()
+	config.SetDir(configDir)
+	expected = append([]string{filepath.Join(configDir, "cli-plugins")}, defaultSystemPluginDirs...)
+	assert.DeepEqual(t, getPluginDirs(cli.ConfigFile()), expected)
+}
+
+func TestPluginRunCommandAlias(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-buildx", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	origArgs := os.Args
+	defer func() { os.Args = origArgs }()
+	os.Args = []string{"docker", "bx", "build", "."}
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs: []string{dir.Path()},
+		CLIPluginsAliases: map[string]string{
+			"bx":      "buildx",
+			"missing": "nosuchplugin",
+		},
+	})
+
+	cmd, err := PluginRunCommand(cli, "bx", &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Equal(t, cmd.Path, dir.Join("docker-buildx"))
+	assert.DeepEqual(t, cmd.Args, []string{dir.Join("docker-buildx"), "buildx", "build", "."})
+
+	_, err = PluginRunCommand(cli, "missing", &cobra.Command{})
+	assert.Error(t, err, `plugin alias "missing" refers to plugin "nosuchplugin" which is not installed`)
+	assert.Check(t, !IsNotFound(err))
+
+	_, err = PluginRunCommand(cli, "notanalias", &cobra.Command{})
+	assert.Check(t, IsNotFound(err))
+}
+
+func TestGetPluginFromDir(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("stable",
+			fs.WithFile("docker-bbb", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Version":"1.0.0"}'`, fs.WithMode(0o777)),
+		),
+		fs.WithDir("dev",
+			fs.WithFile("docker-bbb", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Version":"2.0.0-dev"}'`, fs.WithMode(0o777)),
+		),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Join("stable"), dir.Join("dev")}})
+
+	plugin, err := GetPlugin("bbb", cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Equal(t, plugin.Version, "1.0.0")
+
+	plugin, err = GetPluginFromDir("bbb", dir.Join("dev"), cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Equal(t, plugin.Path, dir.Join("dev", "docker-bbb"))
+	assert.Equal(t, plugin.Version, "2.0.0-dev")
+	assert.Check(t, len(plugin.ShadowedPaths) == 0)
+
+	_, err = GetPluginFromDir("aaa", dir.Join("dev"), cli, &cobra.Command{})
+	assert.Check(t, IsNotFound(err))
+}
+
+func TestPluginRunCommandPrefixStderr(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+echo "first line" >&2
+echo "second line" >&2`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	cmd, err := PluginRunCommand(cli, "aaa", &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Check(t, cmd.Stderr == os.Stderr)
+
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}, CLIPluginsPrefixStderr: true})
+	cmd, err = PluginRunCommand(cli, "aaa", &cobra.Command{})
+	assert.NilError(t, err)
+	pw, ok := cmd.Stderr.(*prefixWriter)
+	assert.Assert(t, ok)
+
+	var stderr bytes.Buffer
+	pw.w = &stderr
+	cmd.Stdout = nil
+	assert.NilError(t, cmd.Run())
+	assert.Equal(t, stderr.String(), "[aaa] first line\n[aaa] second line\n")
+}
+
+func TestPluginRunCommandConfigDir(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+echo "$DOCKER_CONFIG"`, fs.WithMode(0o777)),
+		fs.WithDir("config"),
+	)
+	defer dir.Remove()
+
+	origDir := config.Dir()
+	defer config.SetDir(origDir)
+	config.SetDir(dir.Join("config"))
+	t.Setenv(config.EnvOverrideConfigDir, dir.Join("other"))
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	cmd, err := PluginRunCommand(cli, "aaa", &cobra.Command{})
+	assert.NilError(t, err)
+
+	cmd.Stdout = nil
+	out, err := cmd.Output()
+	assert.NilError(t, err)
+	assert.Equal(t, strings.TrimSpace(string(out)), dir.Join("config"))
+}
+
+func TestPluginRunCommandEnvFile(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+echo "$PARENT_VAR,$FILE_VAR,$DOCKER_CLI_PLUGIN_ORIGINAL_CLI_COMMAND"`, fs.WithMode(0o777)),
+		fs.WithFile("docker-bbb", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+		fs.WithFile("aaa.env", `# comment
+PARENT_VAR=from-file
+FILE_VAR=file
+DOCKER_CLI_PLUGIN_ORIGINAL_CLI_COMMAND=from-file
+`),
+		fs.WithFile("bbb.env", "INVALID VAR=value\n"),
+	)
+	defer dir.Remove()
+
+	defer func(origArgs []string) { os.Args = origArgs }(os.Args)
+	os.Args = []string{"docker", "aaa"}
+	t.Setenv("PARENT_VAR", "parent")
+	t.Setenv("FILE_VAR", "")
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	cmd, err := PluginRunCommand(cli, "aaa", &cobra.Command{})
+	assert.NilError(t, err)
+	cmd.Stdout = nil
+	out, err := cmd.Output()
+	assert.NilError(t, err)
+	assert.Equal(t, strings.TrimSpace(string(out)), "from-file,file,docker")
+
+	_, err = PluginRunCommand(cli, "bbb", &cobra.Command{})
+	assert.ErrorContains(t, err, `failed to read env-file for plugin "bbb"`)
+}
+
+func TestPluginRunCommandEnvAllowlist(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+echo "$ALLOWED_VAR,$OTHER_VAR,$FILE_VAR,$DOCKER_HOST,$DOCKER_CONFIG,$SSH_AUTH_SOCK,$TERM,$LC_TIME"`, fs.WithMode(0o777)),
+		fs.WithFile("aaa.env", "FILE_VAR=file\n"),
+		fs.WithDir("config"),
+	)
+	defer dir.Remove()
+
+	origDir := config.Dir()
+	defer config.SetDir(origDir)
+	config.SetDir(dir.Join("config"))
+	t.Setenv("ALLOWED_VAR", "allowed")
+	t.Setenv("OTHER_VAR", "other")
+	t.Setenv("DOCKER_HOST", "tcp://example.com:2376")
+	t.Setenv("SSH_AUTH_SOCK", "/tmp/ssh-agent.sock")
+	t.Setenv("TERM", "xterm")
+	t.Setenv("LC_TIME", "C")
+
+	testCases := []struct {
+		doc       string
+		allowlist []string
+		expected  string
+	}{
+		{
+			doc:      "no allowlist",
+			expected: "allowed,other,file,tcp://example.com:2376," + dir.Join("config") + ",/tmp/ssh-agent.sock,xterm,C",
+		},
+		{
+			doc:       "allowlist",
+			allowlist: []string{"ALLOWED_VAR"},
+			expected:  "allowed,,file,tcp://example.com:2376," + dir.Join("config") + ",/tmp/ssh-agent.sock,xterm,C",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(nil)
+			cli.SetConfigFile(&configfile.ConfigFile{
+				CLIPluginsExtraDirs:    []string{dir.Path()},
+				CLIPluginsEnvAllowlist: tc.allowlist,
+			})
+
+			cmd, err := PluginRunCommand(cli, "aaa", &cobra.Command{})
+			assert.NilError(t, err)
+			cmd.Stdout = nil
+			out, err := cmd.Output()
+			assert.NilError(t, err)
+			assert.Equal(t, strings.TrimSpace(string(out)), tc.expected)
+		})
+	}
+}
+
+func TestPluginRunCommandExecWrapper(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+	)
+	// The wrapper logs the commands it runs, without its own options.
+	assert.NilError(t, os.WriteFile(dir.Join("wrapper"), []byte(`#!/bin/sh
+shift
+echo "$@" >> "$(dirname "$0")/wrapper.log"
+exec "$@"`), 0o777))
+	defer dir.Remove()
+
+	defer func(origArgs []string) { os.Args = origArgs }(os.Args)
+	os.Args = []string{"docker", "aaa", "--flag", "arg"}
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	cmd, err := PluginRunCommand(cli, "aaa", &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(cmd.Args, []string{dir.Join("docker-aaa"), "aaa", "--flag", "arg"}))
+
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs:   []string{dir.Path()},
+		CLIPluginsExecWrapper: []string{dir.Join("wrapper"), "--quiet"},
+	})
+	cmd, err = PluginRunCommand(cli, "aaa", &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(cmd.Args, []string{dir.Join("wrapper"), "--quiet", dir.Join("docker-aaa"), "aaa", "--flag", "arg"}))
+
+	// The plugin is also run through the wrapper to fetch its metadata.
+	log, err := os.ReadFile(dir.Join("wrapper.log"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(log), dir.Join("docker-aaa")+" docker-cli-plugin-metadata\n"))
+}
+
+func TestListPluginsWithWarnings(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("not-a-dir", ""),
+		fs.WithDir("plugins",
+			fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+		),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{
+		dir.Join("no-such-dir"),
+		dir.Join("not-a-dir"),
+		dir.Join("plugins"),
+	}})
+
+	plugins, warnings, err := ListPluginsWithWarnings(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Assert(t, len(plugins) == 1)
+	assert.Equal(t, plugins[0].Name, "aaa")
+
+	// Missing directories are not reported.
+	assert.Assert(t, len(warnings) == 1)
+	assert.Error(t, warnings[0], dir.Join("not-a-dir")+" is a file, not a directory")
+
+	// ListPlugins does not report warnings.
+	plugins, err = ListPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Assert(t, len(plugins) == 1)
+}
+
+func TestPluginRunCommandChecksums(t *testing.T) {
+	const script = `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`
+	sum := sha256.Sum256([]byte(script))
+	checksum := hex.EncodeToString(sum[:])
+
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", script, fs.WithMode(0o777)),
+		fs.WithFile("docker-bbb", script, fs.WithMode(0o777)),
+		fs.WithFile("docker-ccc", script, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs: []string{dir.Path()},
+		CLIPluginsChecksums: map[string]string{
+			"aaa": checksum,
+			"bbb": "sha256:0000",
+		},
+	})
+
+	_, err := PluginRunCommand(cli, "aaa", &cobra.Command{})
+	assert.NilError(t, err)
+
+	_, err = PluginRunCommand(cli, "bbb", &cobra.Command{})
+	var mismatch *ChecksumMismatchError
+	assert.Assert(t, errors.As(err, &mismatch))
+	assert.Check(t, is.Equal(mismatch.Name, "bbb"))
+	assert.Check(t, is.Equal(mismatch.Path, dir.Join("docker-bbb")))
+	assert.Check(t, is.Equal(mismatch.Expected, "0000"))
+	assert.Check(t, is.Equal(mismatch.Actual, checksum))
+	assert.Check(t, !IsNotFound(err))
+
+	// Plugins without a pinned checksum are not verified.
+	_, err = PluginRunCommand(cli, "ccc", &cobra.Command{})
+	assert.NilError(t, err)
+}
+
+func TestListPluginsChecksums(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+
+	// The plugin creates a file when it is run, to verify that plugins that
+	// do not match their pinned checksum are never executed.
+	script := `#!/bin/sh
+touch ` + dir.Join("executed") + `
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`
+	assert.NilError(t, os.WriteFile(dir.Join("docker-aaa"), []byte(script), 0o777))
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs: []string{dir.Path()},
+		CLIPluginsChecksums: map[string]string{"aaa": "sha256:0000"},
+	})
+
+	plugins, err := ListPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(plugins, 1))
+	var mismatch *ChecksumMismatchError
+	assert.Check(t, errors.As(plugins[0].Err, &mismatch))
+
+	p, err := GetPlugin("aaa", cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Check(t, errors.As(p.Err, &mismatch))
+
+	_, err = PluginRunCommand(cli, "aaa", &cobra.Command{})
+	assert.Check(t, errors.As(err, &mismatch))
+
+	_, err = os.Stat(dir.Join("executed"))
+	assert.Check(t, is.ErrorType(err, os.IsNotExist))
+}
+
+func TestPluginRunCommandAliasSubcommand(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-buildx", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	origArgs := os.Args
+	defer func() { os.Args = origArgs }()
+	os.Args = []string{"docker", "--debug", "b", "-t", "foo", "."}
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs: []string{dir.Path()},
+		CLIPluginsAliases: map[string]string{
+			"b":     "buildx  build",
+			"empty": " ",
+		},
+	})
+
+	cmd, err := PluginRunCommand(cli, "b", &cobra.Command{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, cmd.Args, []string{dir.Join("docker-buildx"), "--debug", "buildx", "build", "-t", "foo", "."})
+
+	_, err = PluginRunCommand(cli, "empty", &cobra.Command{})
+	assert.Error(t, err, `plugin alias "empty" does not refer to a plugin`)
+}
+
+func TestCheckPluginAliases(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-buildx", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-broken", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0"}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs: []string{dir.Path()},
+		CLIPluginsAliases: map[string]string{
+			"b":      "buildx build",
+			"images": "buildx ls",
+			"ls":     "buildx ls",
+			"buildx": "buildx",
+			"empty":  "",
+			"gone":   "missing run",
+			"bad":    "broken",
+		},
+	})
+	rootCmd := &cobra.Command{Use: "docker"}
+	rootCmd.AddCommand(
+		&cobra.Command{Use: "images"},
+		&cobra.Command{Use: "list", Aliases: []string{"ls"}},
+		&cobra.Command{Use: "compose", Annotations: map[string]string{metadata.CommandAnnotationPlugin: "true"}},
+	)
+
+	var msgs []string
+	for _, err := range CheckPluginAliases(cli, rootCmd) {
+		msgs = append(msgs, err.Error())
+	}
+	assert.DeepEqual(t, msgs, []string{
+		`plugin alias "bad" refers to plugin "broken" which is not valid: plugin metadata does not define a vendor`,
+		`plugin alias "buildx" is not used: a CLI plugin with the same name is installed`,
+		`plugin alias "empty" does not refer to a plugin`,
+		`plugin alias "gone" refers to plugin "missing" which is not installed`,
+		`plugin alias "images" is not used: it conflicts with builtin command "images"`,
+		`plugin alias "ls" is not used: it conflicts with an alias of builtin command "list"`,
+	})
+}
+
+func TestSubcommandIndex(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "docker"}
+	rootCmd.Flags().StringP("context", "c", "", "")
+	rootCmd.Flags().BoolP("debug", "D", false, "")
+	rootCmd.Flags().StringP("log-level", "l", "info", "")
+
+	testCases := []struct {
+		args     []string
+		expected int
+	}{
+		{args: []string{}, expected: -1},
+		{args: []string{"foo", "--context", "b"}, expected: 0},
+		{args: []string{"--context", "b", "b", "."}, expected: 2},
+		{args: []string{"--context=b", "b"}, expected: 1},
+		{args: []string{"-c", "b", "b"}, expected: 2},
+		{args: []string{"-cb", "b"}, expected: 1},
+		{args: []string{"-Dl", "debug", "b"}, expected: 2},
+		{args: []string{"-D", "b"}, expected: 1},
+		{args: []string{"--unknown", "b"}, expected: 1},
+		{args: []string{"--", "b"}, expected: 1},
+		{args: []string{"--context"}, expected: -1},
+	}
+	for _, tc := range testCases {
+		assert.Check(t, is.Equal(subcommandIndex(rootCmd, tc.args), tc.expected), "%v", tc.args)
+	}
+	assert.Check(t, is.Equal(subcommandIndex(nil, []string{"--debug", "b"}), 1))
+}
+
+func TestReplaceSubcommand(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "docker"}
+	rootCmd.Flags().StringP("context", "c", "", "")
+	rootCmd.Flags().BoolP("debug", "D", false, "")
+
+	args := []string{"--debug", "b", "-t", "b"}
+	assert.DeepEqual(t, replaceSubcommand(rootCmd, args, "b", "buildx", "build"), []string{"--debug", "buildx", "build", "-t", "b"})
+	assert.DeepEqual(t, replaceSubcommand(rootCmd, args, "x", "buildx"), args)
+	assert.DeepEqual(t, args, []string{"--debug", "b", "-t", "b"})
+
+	// The value of a global option is not replaced.
+	args = []string{"--context", "b", "b", "."}
+	assert.DeepEqual(t, replaceSubcommand(rootCmd, args, "b", "buildx", "build"), []string{"--context", "b", "buildx", "build", "."})
+
+	args = []string{cobra.ShellCompRequestCmd, "b", ""}
+	assert.DeepEqual(t, replaceSubcommand(rootCmd, args, "b", "buildx", "build"), []string{cobra.ShellCompRequestCmd, "buildx", "build", ""})
+}
+
+func TestPluginPrefixes(t *testing.T) {
+	assert.DeepEqual(t, pluginPrefixes(nil), []string{"docker-"})
+	assert.DeepEqual(t, pluginPrefixes(&configfile.ConfigFile{
+		CLIPluginsPrefixes: []string{"acme-", "docker-", "nodash", "-", ""},
+	}), []string{"docker-", "acme-"})
+}
+
+func TestListPluginCandidatesPrefixes(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("plugins",
+			fs.WithFile("docker-aaa", ""),
+			fs.WithFile("acme-bbb", ""),
+			fs.WithFile("other-ccc", ""),
+		),
+		fs.WithDir("path",
+			fs.WithFile("docker-ddd", ""),
+			fs.WithFile("acme-eee", ""),
+			fs.WithFile("docker-fff", ""),
+			fs.WithFile("acme-aaa", ""),
+		),
+	)
+	defer dir.Remove()
+
+	t.Setenv("PATH", strings.Join([]string{dir.Join("path"), "relative", dir.Join("plugins")}, string(filepath.ListSeparator)))
+	cfg := &configfile.ConfigFile{
+		CLIPluginsExtraDirs: []string{dir.Join("plugins")},
+		CLIPluginsPrefixes:  []string{"acme-"},
+	}
+
+	// Directories in PATH are not searched without an allowlist.
+	candidates := listConfiguredPluginCandidates(cfg)
+	assert.DeepEqual(t, candidates, map[string][]string{
+		"aaa": {dir.Join("plugins", "docker-aaa")},
+		"bbb": {dir.Join("plugins", "acme-bbb")},
+	})
+
+	cfg.CLIPluginsPathAllowlist = []string{"aaa", "ddd", "eee"}
+	candidates = listConfiguredPluginCandidates(cfg)
+	assert.DeepEqual(t, candidates, map[string][]string{
+		"aaa": {dir.Join("plugins", "docker-aaa"), dir.Join("path", "acme-aaa")},
+		"bbb": {dir.Join("plugins", "acme-bbb")},
+		"ddd": {dir.Join("path", "docker-ddd")},
+		"eee": {dir.Join("path", "acme-eee")},
+	})
+}
+
+func TestGetPluginPrefix(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("acme-deploy", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"ACME"}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	_, err := GetPlugin("deploy", cli, &cobra.Command{})
+	assert.Check(t, is.ErrorType(err, IsNotFound))
+
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs: []string{dir.Path()},
+		CLIPluginsPrefixes:  []string{"acme-"},
+	})
+	p, err := GetPlugin("deploy", cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.NilError(t, p.Err)
+	assert.Check(t, is.Equal(p.Name, "deploy"))
+	assert.Check(t, is.Equal(p.Path, dir.Join("acme-deploy")))
+
+	cmd, err := PluginRunCommand(cli, "deploy", &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(cmd.Path, dir.Join("acme-deploy")))
 }
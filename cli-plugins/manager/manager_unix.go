@@ -2,6 +2,12 @@
 
 package manager
 
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
 // defaultSystemPluginDirs are the platform-specific locations to search
 // for plugins in order of preference.
 //
@@ -18,3 +24,37 @@
 	"/usr/lib/docker/cli-plugins",
 	"/usr/libexec/docker/cli-plugins",
 }
+
+// platformPluginEnv are the platform-specific environment variables that are
+// passed to CLI plugins, even if they are not in the allowlist.
+var platformPluginEnv = []string{"HOME", "PATH", "TMPDIR"}
+
+// envKey returns the key to compare the name of an environment variable by.
+// Names of environment variables are case-sensitive.
+func envKey(name string) string {
+	return name
+}
+
+// isExecFormatError returns whether err indicates that a binary could not
+// be executed because it is not in a format for the current platform.
+func isExecFormatError(err error) bool {
+	return errors.Is(err, syscall.ENOEXEC)
+}
+
+// setNewProcessGroup makes cmd start in a new process group, so that
+// killPlugin also kills the processes that were started by the plugin.
+func setNewProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// killPlugin kills the plugin started by cmd, including the processes in its
+// process group if it was started in a new process group.
+func killPlugin(cmd *exec.Cmd) error {
+	if cmd.SysProcAttr != nil && cmd.SysProcAttr.Setpgid {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return cmd.Process.Kill()
+}
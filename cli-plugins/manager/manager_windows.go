@@ -1,8 +1,14 @@
 package manager
 
 import (
+	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/windows"
 )
 
 // defaultSystemPluginDirs are the platform-specific locations to search
@@ -19,3 +25,43 @@
 	filepath.Join(os.Getenv("ProgramData"), "Docker", "cli-plugins"),
 	filepath.Join(os.Getenv("ProgramFiles"), "Docker", "cli-plugins"),
 }
+
+// platformPluginEnv are the platform-specific environment variables that are
+// passed to CLI plugins, even if they are not in the allowlist.
+var platformPluginEnv = []string{
+	"APPDATA",
+	"LOCALAPPDATA",
+	"PATH",
+	"PATHEXT",
+	"ProgramData",
+	"ProgramFiles",
+	"SystemRoot",
+	"TEMP",
+	"TMP",
+	"USERPROFILE",
+}
+
+// envKey returns the key to compare the name of an environment variable by.
+// Names of environment variables are case-insensitive on Windows.
+func envKey(name string) string {
+	return strings.ToUpper(name)
+}
+
+// isExecFormatError returns whether err indicates that a binary could not
+// be executed because it is not in a format for the current platform.
+func isExecFormatError(err error) bool {
+	return errors.Is(err, windows.ERROR_BAD_EXE_FORMAT)
+}
+
+// setNewProcessGroup is a no-op on Windows, where killPlugin kills the
+// process tree of the plugin instead.
+func setNewProcessGroup(*exec.Cmd) {}
+
+// killPlugin kills the plugin started by cmd, including the processes that
+// were started by the plugin.
+func killPlugin(cmd *exec.Cmd) error {
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run(); err != nil {
+		return cmd.Process.Kill()
+	}
+	return nil
+}
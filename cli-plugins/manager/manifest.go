@@ -0,0 +1,207 @@
+package manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/moby/sys/atomicwriter"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// manifestCacheFileName is the name of the file in the config-directory
+	// in which the last fetched plugin manifest is stored.
+	manifestCacheFileName = "cli-plugins-manifest.json"
+
+	// manifestCacheInterval is the minimum time between fetching the plugin
+	// manifest from [ConfigFile.CLIPluginsManifestURL].
+	//
+	// [ConfigFile.CLIPluginsManifestURL]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsManifestURL
+	manifestCacheInterval = time.Hour
+
+	// manifestTimeout is the maximum time to wait for the plugin manifest to
+	// be fetched.
+	manifestTimeout = 5 * time.Second
+)
+
+// approvedPlugin is an entry in the plugin manifest.
+type approvedPlugin struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	SHA256  string `json:"sha256"`
+}
+
+// manifestCache is the plugin manifest as stored in the cache file.
+type manifestCache struct {
+	URL       string           `json:"url"`
+	FetchedAt time.Time        `json:"fetchedAt"`
+	Plugins   []approvedPlugin `json:"plugins"`
+}
+
+// manifestCacheFile returns the path of the file in which the plugin
+// manifest is cached, which is stored next to the given config file.
+func manifestCacheFile(cfg *configfile.ConfigFile) string {
+	if cfg.Filename == "" {
+		return filepath.Join(config.Dir(), manifestCacheFileName)
+	}
+	return filepath.Join(filepath.Dir(cfg.Filename), manifestCacheFileName)
+}
+
+// loadPluginManifest returns the plugin manifest at cfg.CLIPluginsManifestURL
+// as a map from plugin name to approved plugin. The manifest is fetched at
+// most once per manifestCacheInterval; in between, the cached manifest is
+// used. If the manifest cannot be fetched, the cached manifest is used
+// regardless of its age, and an error is only returned if there is none.
+func loadPluginManifest(ctx context.Context, cfg *configfile.ConfigFile) (map[string]approvedPlugin, error) {
+	url := cfg.CLIPluginsManifestURL
+	fileName := manifestCacheFile(cfg)
+	cached, err := readManifestCache(fileName)
+	if err != nil {
+		logrus.WithError(err).Debugf("Failed to read cached plugin manifest from %s. Ignoring.", fileName)
+		cached = nil
+	}
+	if cached != nil && cached.URL != url {
+		cached = nil
+	}
+	if cached != nil && time.Since(cached.FetchedAt) <= manifestCacheInterval {
+		return manifestByName(cached.Plugins), nil
+	}
+
+	entries, err := fetchPluginManifest(ctx, url)
+	if err != nil {
+		if cached != nil {
+			logrus.WithError(err).Debugf("Failed to fetch plugin manifest from %s. Using cached manifest.", url)
+			return manifestByName(cached.Plugins), nil
+		}
+		return nil, err
+	}
+	data, err := json.Marshal(manifestCache{URL: url, FetchedAt: time.Now().UTC(), Plugins: entries})
+	if err == nil {
+		if err := atomicwriter.WriteFile(fileName, data, 0o600); err != nil {
+			logrus.WithError(err).Debugf("Failed to write plugin manifest to %s. Ignoring.", fileName)
+		}
+	}
+	return manifestByName(entries), nil
+}
+
+func readManifestCache(fileName string) (*manifestCache, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var cached manifestCache
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, err
+	}
+	return &cached, nil
+}
+
+func manifestByName(entries []approvedPlugin) map[string]approvedPlugin {
+	manifest := make(map[string]approvedPlugin, len(entries))
+	for _, e := range entries {
+		manifest[e.Name] = e
+	}
+	return manifest
+}
+
+// fetchPluginManifest fetches the list of approved plugins from the given
+// URL.
+func fetchPluginManifest(ctx context.Context, url string) ([]approvedPlugin, error) {
+	ctx, cancel := context.WithTimeout(ctx, manifestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %s", resp.Status)
+	}
+
+	var entries []approvedPlugin
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("invalid plugin manifest: %w", err)
+	}
+	return entries, nil
+}
+
+// checkApproved sets the plugin's Err if the plugin is not in the manifest,
+// or if its version or checksum do not match the approved ones.
+func checkApproved(p *Plugin, manifest map[string]approvedPlugin) {
+	approved, ok := manifest[p.Name]
+	switch {
+	case !ok:
+		p.Err = NewPluginError("plugin %q is not approved: not in the plugin manifest", p.Name)
+	case approved.Version != "" && approved.Version != p.Version:
+		p.Err = NewPluginError("plugin %q is not approved: version %q does not match approved version %q", p.Name, p.Version, approved.Version)
+	case approved.SHA256 != "":
+		sum, err := fileSHA256(p.Path)
+		if err != nil {
+			p.Err = wrapAsPluginError(err, fmt.Sprintf("plugin %q is not approved", p.Name))
+		} else if !strings.EqualFold(sum, approved.SHA256) {
+			p.Err = NewPluginError("plugin %q is not approved: checksum does not match", p.Name)
+		}
+	}
+}
+
+// annotateApproved checks the given plugins against the plugin manifest.
+// Plugins are not checked if the manifest cannot be loaded.
+func annotateApproved(ctx context.Context, cfg *configfile.ConfigFile, plugins []Plugin) {
+	manifest, err := loadPluginManifest(ctx, cfg)
+	if err != nil {
+		logrus.WithError(err).Debugf("Failed to fetch plugin manifest from %s. Ignoring.", cfg.CLIPluginsManifestURL)
+		return
+	}
+	for i := range plugins {
+		if plugins[i].Err == nil {
+			checkApproved(&plugins[i], manifest)
+		}
+	}
+}
+
+// verifyApproved returns an error if the plugin is not approved by the plugin
+// manifest. The plugin is not checked if the manifest cannot be loaded.
+func verifyApproved(ctx context.Context, cfg *configfile.ConfigFile, p Plugin) error {
+	manifest, err := loadPluginManifest(ctx, cfg)
+	if err != nil {
+		logrus.WithError(err).Debugf("Failed to fetch plugin manifest from %s. Ignoring.", cfg.CLIPluginsManifestURL)
+		return nil
+	}
+	checkApproved(&p, manifest)
+	return p.Err
+}
+
+// fileSHA256 returns the SHA256 checksum of the file at path, and can be
+// overridden in tests.
+var fileSHA256 = func(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
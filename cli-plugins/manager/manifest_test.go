@@ -0,0 +1,125 @@
+package manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/test"
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestListPluginsManifest(t *testing.T) {
+	const script = `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing","Version":"1.0.0"}'`
+	sum := sha256.Sum256([]byte(script))
+	checksum := hex.EncodeToString(sum[:])
+
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-approved", script, fs.WithMode(0o777)),
+		fs.WithFile("docker-badversion", script, fs.WithMode(0o777)),
+		fs.WithFile("docker-badchecksum", script, fs.WithMode(0o777)),
+		fs.WithFile("docker-unlisted", script, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`[
+	{"name":"approved","version":"1.0.0","sha256":"` + checksum + `"},
+	{"name":"badversion","version":"2.0.0","sha256":"` + checksum + `"},
+	{"name":"badchecksum","version":"1.0.0","sha256":"0000"}
+]`))
+	}))
+	defer srv.Close()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		Filename:              filepath.Join(t.TempDir(), "config.json"),
+		CLIPluginsExtraDirs:   []string{dir.Path()},
+		CLIPluginsManifestURL: srv.URL,
+	})
+
+	plugins, err := ListPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	errs := make(map[string]string, len(plugins))
+	for _, p := range plugins {
+		if p.Err != nil {
+			errs[p.Name] = p.Err.Error()
+		} else {
+			errs[p.Name] = ""
+		}
+	}
+	assert.Check(t, is.DeepEqual(errs, map[string]string{
+		"approved":    "",
+		"badchecksum": `plugin "badchecksum" is not approved: checksum does not match`,
+		"badversion":  `plugin "badversion" is not approved: version "1.0.0" does not match approved version "2.0.0"`,
+		"unlisted":    `plugin "unlisted" is not approved: not in the plugin manifest`,
+	}))
+
+	// The manifest is cached, so that it is not fetched again when listing
+	// or running plugins.
+	_, err = ListPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	_, err = PluginRunCommand(cli, "approved", &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(requests.Load(), int32(1)))
+
+	_, err = PluginRunCommand(cli, "unlisted", &cobra.Command{})
+	assert.Check(t, is.Error(err, `plugin "unlisted" is not approved: not in the plugin manifest`))
+}
+
+func TestPluginManifestStaleCache(t *testing.T) {
+	cfg := &configfile.ConfigFile{
+		Filename:              filepath.Join(t.TempDir(), "config.json"),
+		CLIPluginsManifestURL: "http://127.0.0.1:0/manifest.json",
+	}
+	data := []byte(`{"url":"http://127.0.0.1:0/manifest.json","fetchedAt":"2000-01-01T00:00:00Z","plugins":[{"name":"approved"}]}`)
+	assert.NilError(t, os.WriteFile(manifestCacheFile(cfg), data, 0o600))
+
+	// The stale manifest is used if a new one cannot be fetched.
+	manifest, err := loadPluginManifest(context.Background(), cfg)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(manifest, map[string]approvedPlugin{"approved": {Name: "approved"}}))
+
+	// The cached manifest is not used for a different URL.
+	cfg.CLIPluginsManifestURL = "http://127.0.0.1:0/other.json"
+	_, err = loadPluginManifest(context.Background(), cfg)
+	assert.Check(t, err != nil)
+}
+
+func TestListPluginsManifestUnavailable(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		Filename:              filepath.Join(t.TempDir(), "config.json"),
+		CLIPluginsExtraDirs:   []string{dir.Path()},
+		CLIPluginsManifestURL: srv.URL,
+	})
+
+	plugins, err := ListPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(plugins, 1))
+	assert.Check(t, plugins[0].Err == nil)
+}
@@ -0,0 +1,58 @@
+package manager
+
+import "sync"
+
+// PluginPhase describes a point in the lifecycle of a plugin at which a
+// PluginEvent is emitted.
+type PluginPhase string
+
+const (
+	// PluginPhaseMetadata is emitted after the plugin's metadata was probed.
+	PluginPhaseMetadata PluginPhase = "metadata"
+	// PluginPhaseExec is emitted when the plugin is about to be executed.
+	PluginPhaseExec PluginPhase = "exec"
+	// PluginPhaseExit is emitted after the plugin exited.
+	PluginPhaseExit PluginPhase = "exit"
+)
+
+// PluginEvent describes a plugin lifecycle event.
+type PluginEvent struct {
+	// Name is the name of the plugin.
+	Name string
+	// Path is the path of the plugin binary.
+	Path string
+	// Phase is the point in the plugin's lifecycle the event was emitted at.
+	Phase PluginPhase
+	// ExitCode is the exit code of the plugin. It's only set for
+	// PluginPhaseExit.
+	ExitCode int
+	// Err is the error (if any) that occurred during this phase.
+	Err error
+}
+
+var (
+	observerMu     sync.RWMutex
+	pluginObserver func(PluginEvent)
+)
+
+// SetPluginObserver sets a function that is called for plugin lifecycle
+// events. Passing nil removes the observer. The observer may be called
+// concurrently, for example when listing plugins.
+func SetPluginObserver(observer func(PluginEvent)) {
+	observerMu.Lock()
+	defer observerMu.Unlock()
+	pluginObserver = observer
+}
+
+// NotifyPluginObserver calls the observer that was set through
+// SetPluginObserver (if any) with the given event. It is used by callers
+// that execute the command returned by PluginRunCommand to report the
+// PluginPhaseExec and PluginPhaseExit events.
+func NotifyPluginObserver(event PluginEvent) {
+	observerMu.RLock()
+	observer := pluginObserver
+	observerMu.RUnlock()
+	if observer != nil {
+		observer(event)
+	}
+}
@@ -6,17 +6,24 @@
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/docker/cli/cli-plugins/metadata"
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
 	"github.com/docker/cli/internal/lazyregexp"
 	"github.com/spf13/cobra"
 )
 
 var pluginNameRe = lazyregexp.New("^[a-z][a-z0-9]*$")
 
+// IsValidPluginName returns whether name is a valid name for a CLI plugin.
+func IsValidPluginName(name string) bool {
+	return pluginNameRe.MatchString(name)
+}
+
 // Plugin represents a potential plugin with all it's metadata.
 type Plugin struct {
 	metadata.Metadata
@@ -29,6 +36,41 @@ type Plugin struct {
 
 	// ShadowedPaths contains the paths of any other plugins which this plugin takes precedence over.
 	ShadowedPaths []string `json:",omitempty"`
+
+	// RawMetadata is the metadata as returned by the plugin, including any
+	// fields that are not known to this version of the CLI.
+	RawMetadata json.RawMessage `json:"-"`
+}
+
+// maxMetadataLineLength is the maximum length of the output of the metadata
+// subcommand to include in the error if the output is not JSON.
+const maxMetadataLineLength = 80
+
+// invalidMetadataError returns the error for metadata that could not be
+// unmarshaled. If the plugin printed something other than a JSON object,
+// such as a usage message or a stack trace, the error includes the first
+// line of the output instead of the JSON syntax error, which is not helpful
+// in that case.
+func invalidMetadataError(meta []byte, err error) error {
+	out := strings.TrimSpace(string(meta))
+	if strings.HasPrefix(out, "{") {
+		return wrapAsPluginError(err, "invalid metadata")
+	}
+	if out == "" {
+		return NewPluginError("invalid metadata output: expected JSON, but the plugin printed nothing")
+	}
+	line, _, _ := strings.Cut(out, "\n")
+	line = strings.TrimSpace(line)
+	if r := []rune(line); len(r) > maxMetadataLineLength {
+		line = string(r[:maxMetadataLineLength]) + "..."
+	}
+	return NewPluginError("invalid metadata output: expected JSON, but the plugin printed %q", line)
+}
+
+// isSupportedSchemaVersion returns whether v is a metadata schema version that
+// is supported by this version of the CLI.
+func isSupportedSchemaVersion(v string) bool {
+	return v == "0.1.0" || v == "0.2.0"
 }
 
 // newPlugin determines if the given candidate is valid and returns a
@@ -49,15 +91,19 @@ func newPlugin(c Candidate, cmds []*cobra.Command) (Plugin, error) {
 		return Plugin{}, fmt.Errorf("unable to determine basename of plugin candidate %q", path)
 	}
 	var err error
-	if fullname, err = trimExeSuffix(fullname); err != nil {
+	if fullname, err = trimPluginSuffix(fullname); err != nil {
 		return Plugin{}, fmt.Errorf("plugin candidate %q: %w", path, err)
 	}
-	if !strings.HasPrefix(fullname, metadata.NamePrefix) {
-		return Plugin{}, fmt.Errorf("plugin candidate %q: does not have %q prefix", path, metadata.NamePrefix)
+	prefix := metadata.NamePrefix
+	if pc, ok := c.(interface{ namePrefix() string }); ok {
+		prefix = pc.namePrefix()
+	}
+	if !strings.HasPrefix(fullname, prefix) {
+		return Plugin{}, fmt.Errorf("plugin candidate %q: does not have %q prefix", path, prefix)
 	}
 
 	p := Plugin{
-		Name: strings.TrimPrefix(fullname, metadata.NamePrefix),
+		Name: strings.TrimPrefix(fullname, prefix),
 		Path: path,
 	}
 
@@ -86,37 +132,100 @@ func newPlugin(c Candidate, cmds []*cobra.Command) (Plugin, error) {
 
 	// We are supposed to check for relevant execute permissions here. Instead we rely on an attempt to execute.
 	meta, err := c.Metadata()
+	NotifyPluginObserver(PluginEvent{Name: p.Name, Path: p.Path, Phase: PluginPhaseMetadata, Err: err})
 	if err != nil {
+		if isUnverifiedPlugin(err) {
+			p.Err = &pluginError{cause: err}
+			return p, nil
+		}
+		if isExecFormatError(err) {
+			p.Err = &pluginError{cause: &archMismatchError{cause: err}}
+			return p, nil
+		}
 		p.Err = wrapAsPluginError(err, "failed to fetch metadata")
 		return p, nil
 	}
 
+	// Unknown fields are ignored, so that plugins can provide metadata
+	// that is only used by newer versions of the CLI.
 	if err := json.Unmarshal(meta, &p.Metadata); err != nil {
-		p.Err = wrapAsPluginError(err, "invalid metadata")
+		p.Err = invalidMetadataError(meta, err)
 		return p, nil
 	}
-	if p.Metadata.SchemaVersion != "0.1.0" {
-		p.Err = NewPluginError("plugin SchemaVersion %q is not valid, must be 0.1.0", p.Metadata.SchemaVersion)
+	p.RawMetadata = meta
+	if !isSupportedSchemaVersion(p.Metadata.SchemaVersion) {
+		p.Err = NewPluginError("plugin SchemaVersion %q is not valid, must be 0.1.0 or 0.2.0", p.Metadata.SchemaVersion)
 		return p, nil
 	}
 	if p.Metadata.Vendor == "" {
 		p.Err = NewPluginError("plugin metadata does not define a vendor")
 		return p, nil
 	}
+	if p.Metadata.MinCLIVersion != "" {
+		if err := checkMinCLIVersion(p.Metadata.MinCLIVersion); err != nil {
+			p.Err = err
+			return p, nil
+		}
+	}
+	if len(p.Metadata.Platforms) > 0 && !supportsPlatform(p.Metadata.Platforms) {
+		p.Err = &pluginError{cause: &incompatibleError{cause: fmt.Errorf("plugin does not support %s/%s (supported platforms: %s)", runtime.GOOS, runtime.GOARCH, strings.Join(p.Metadata.Platforms, ", "))}}
+		return p, nil
+	}
 	return p, nil
 }
 
+// isUnverifiedPlugin returns whether err is the error of a plugin that must
+// not be run, because its signature or pinned checksum could not be verified.
+func isUnverifiedPlugin(err error) bool {
+	var sigErr *SignatureError
+	var checksumErr *ChecksumMismatchError
+	return errors.As(err, &sigErr) || errors.As(err, &checksumErr)
+}
+
+// supportsPlatform returns whether one of the given platforms, in "os" or
+// "os/arch" format, matches the platform the CLI is running on.
+func supportsPlatform(platforms []string) bool {
+	for _, platform := range platforms {
+		goos, goarch, _ := strings.Cut(platform, "/")
+		if goos == runtime.GOOS && (goarch == "" || goarch == runtime.GOARCH) {
+			return true
+		}
+	}
+	return false
+}
+
 // RunHook executes the plugin's hooks command
 // and returns its unprocessed output.
 func (p *Plugin) RunHook(ctx context.Context, hookData HookPluginData) ([]byte, error) {
+	return p.runHook(ctx, hookData, nil, nil)
+}
+
+// runHook is like RunHook, but runs containerized plugins on the daemon of
+// dockerCli, using the global options of the CLI as determined by rootcmd,
+// and runs the plugin through the exec wrapper configured for dockerCli.
+func (p *Plugin) runHook(ctx context.Context, hookData HookPluginData, dockerCli config.Provider, rootcmd *cobra.Command) ([]byte, error) {
 	hDataBytes, err := json.Marshal(hookData)
 	if err != nil {
 		return nil, wrapAsPluginError(err, "failed to marshall hook data")
 	}
 
-	pCmd := exec.CommandContext(ctx, p.Path, p.Name, metadata.HookSubcommandName, string(hDataBytes)) // #nosec G204 -- ignore "Subprocess launched with a potential tainted input or cmd arguments"
-	pCmd.Env = os.Environ()
-	pCmd.Env = append(pCmd.Env, metadata.ReexecEnvvar+"="+os.Args[0])
+	var cfg *configfile.ConfigFile
+	if dockerCli != nil {
+		cfg = dockerCli.ConfigFile()
+	}
+	cmdPath, cmdArgs := p.Path, []string{p.Name, metadata.HookSubcommandName, string(hDataBytes)}
+	if isContainerPlugin(p.Path) {
+		runOpts := newContainerRunOptions(dockerCli, rootcmd, os.Args[1:])
+		cmdPath, cmdArgs, err = containerPluginCommand(p.Name, p.Path, cmdArgs, runOpts)
+		if err != nil {
+			return nil, wrapAsPluginError(err, "failed to execute plugin hook subcommand")
+		}
+	}
+	pCmd := pluginExecCommandContext(ctx, cfg, cmdPath, cmdArgs)
+	pCmd.Env, err = pluginEnv(pCmd.Environ(), cfg, *p, rootcmd)
+	if err != nil {
+		return nil, wrapAsPluginError(err, "failed to execute plugin hook subcommand")
+	}
 	hookCmdOutput, err := pCmd.Output()
 	if err != nil {
 		return nil, wrapAsPluginError(err, "failed to execute plugin hook subcommand")
@@ -0,0 +1,44 @@
+package manager
+
+import (
+	"bytes"
+	"io"
+)
+
+// prefixWriter is an io.Writer that writes a prefix at the start of each
+// line written to the underlying writer. Partial lines are written as-is
+// and are not held back until the line is complete.
+type prefixWriter struct {
+	w       io.Writer
+	prefix  []byte
+	midLine bool
+	buf     []byte
+}
+
+func newPrefixWriter(w io.Writer, prefix string) *prefixWriter {
+	return &prefixWriter{w: w, prefix: []byte(prefix)}
+}
+
+// Write implements io.Writer.
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	buf := p.buf[:0]
+	for rest := b; len(rest) > 0; {
+		if !p.midLine {
+			buf = append(buf, p.prefix...)
+			p.midLine = true
+		}
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			buf = append(buf, rest...)
+			break
+		}
+		buf = append(buf, rest[:i+1]...)
+		rest = rest[i+1:]
+		p.midLine = false
+	}
+	p.buf = buf
+	if _, err := p.w.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
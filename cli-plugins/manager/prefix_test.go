@@ -0,0 +1,20 @@
+package manager
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := newPrefixWriter(&buf, "[aaa] ")
+	for _, s := range []string{"line 1\nline", " 2\n", "", "line 3\n\nline 5"} {
+		n, err := w.Write([]byte(s))
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(n, len(s)))
+	}
+	assert.Check(t, is.Equal(buf.String(), "[aaa] line 1\n[aaa] line 2\n[aaa] line 3\n[aaa] \n[aaa] line 5"))
+}
@@ -0,0 +1,53 @@
+package manager
+
+import (
+	"sort"
+	"time"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/fvbommel/sortorder"
+	"github.com/spf13/cobra"
+)
+
+// ProbeResult is the result of probing a CLI plugin.
+type ProbeResult struct {
+	Plugin
+
+	// Duration is the time it took to fetch the metadata of the plugin.
+	Duration time.Duration
+}
+
+// ProbePlugins fetches the metadata of each CLI plugin on the system, and
+// reports whether the plugin is valid, and how long it took to respond.
+// Unlike ListPlugins, plugins are probed one at a time, so that the duration
+// of a probe is not affected by probing other plugins.
+func ProbePlugins(dockerCli config.Provider, rootcmd *cobra.Command) ([]ProbeResult, error) {
+	cfg := dockerCli.ConfigFile()
+	metadataTimeout := getMetadataTimeout(cfg)
+	verifier := newSignatureVerifier(cfg)
+	candidates := listConfiguredPluginCandidates(cfg)
+	cmds := rootcmd.Commands()
+
+	results := make([]ProbeResult, 0, len(candidates))
+	for name, paths := range candidates {
+		if len(paths) == 0 {
+			continue
+		}
+		c := &candidate{path: paths[0], name: name, metadataTimeout: metadataTimeout, verifier: verifier, checksum: pinnedChecksum(cfg, name), cfg: cfg}
+		start := time.Now()
+		p, err := newPlugin(c, cmds)
+		if err != nil {
+			return nil, err
+		}
+		if IsNotFound(p.Err) {
+			continue
+		}
+		p.ShadowedPaths = paths[1:]
+		results = append(results, ProbeResult{Plugin: p, Duration: time.Since(start)})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return sortorder.NaturalLess(results[i].Name, results[j].Name)
+	})
+	return results, nil
+}
@@ -0,0 +1,43 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/test"
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestProbePlugins(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-bbb", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-notexec", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o644)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	results, err := ProbePlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+
+	byName := make(map[string]ProbeResult)
+	var names []string
+	for _, r := range results {
+		if r.Name == "aaa" || r.Name == "bbb" || r.Name == "notexec" {
+			names = append(names, r.Name)
+			byName[r.Name] = r
+		}
+	}
+	assert.Check(t, is.DeepEqual(names, []string{"aaa", "bbb", "notexec"}))
+	assert.Check(t, byName["aaa"].Err)
+	assert.Check(t, byName["aaa"].Duration > 0)
+	assert.Check(t, is.ErrorContains(byName["notexec"].Err, "failed to fetch metadata"))
+}
@@ -0,0 +1,89 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/docker/cli/cli-plugins/socket"
+	"github.com/docker/cli/cli/streams"
+	"github.com/morikuni/aec"
+)
+
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// ProgressRenderer renders the progress events that a plugin sends over the
+// plugin socket. It is safe for concurrent use.
+type ProgressRenderer struct {
+	mu       sync.Mutex
+	out      io.Writer
+	terminal bool
+	frame    int
+	// active is true if a progress line was written on a terminal that is
+	// not yet terminated with a newline.
+	active bool
+}
+
+// NewProgressRenderer returns a renderer that renders progress events to out.
+// On a terminal, the progress of the operation that was last updated is shown
+// on a single line, with a percentage or a spinner, which is replaced when the
+// operation is done. Otherwise, only completed operations are printed.
+func NewProgressRenderer(out *streams.Out) *ProgressRenderer {
+	return &ProgressRenderer{out: out, terminal: out.IsTerminal()}
+}
+
+// Render renders a progress event. Use it with [socket.HandleProgress].
+func (r *ProgressRenderer) Render(ev socket.ProgressEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	message := ev.Message
+	if message == "" {
+		message = ev.ID
+	}
+	done := ev.Done || ev.Error != ""
+	if !r.terminal {
+		if done {
+			_, _ = fmt.Fprintln(r.out, completedLine(message, ev.Error))
+		}
+		return
+	}
+
+	clearLine := "\r" + aec.EraseLine(aec.EraseModes.All).String()
+	if done {
+		_, _ = fmt.Fprint(r.out, clearLine+completedLine(message, ev.Error)+"\n")
+		r.active = false
+		return
+	}
+	var status string
+	if ev.Total > 0 {
+		percent := ev.Current * 100 / ev.Total
+		if percent > 100 {
+			percent = 100
+		}
+		status = fmt.Sprintf("%3d%%", percent)
+	} else {
+		status = spinnerFrames[r.frame%len(spinnerFrames)]
+		r.frame++
+	}
+	_, _ = fmt.Fprint(r.out, clearLine+status+" "+message)
+	r.active = true
+}
+
+// Finish terminates the progress line on a terminal, if an operation did not
+// complete before the plugin exited.
+func (r *ProgressRenderer) Finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.active {
+		_, _ = fmt.Fprintln(r.out)
+		r.active = false
+	}
+}
+
+func completedLine(message, errMsg string) string {
+	if errMsg != "" {
+		return message + ": failed: " + errMsg
+	}
+	return message + ": done"
+}
@@ -0,0 +1,50 @@
+package manager
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/docker/cli/cli-plugins/socket"
+	"github.com/docker/cli/cli/streams"
+	"github.com/morikuni/aec"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestProgressRenderer(t *testing.T) {
+	events := []socket.ProgressEvent{
+		{ID: "pull", Message: "Pulling image"},
+		{ID: "pull", Message: "Pulling image", Current: 50, Total: 200},
+		{ID: "pull", Message: "Pulling image", Done: true},
+		{ID: "push"},
+		{ID: "push", Error: "denied"},
+		{ID: "build"},
+	}
+
+	t.Run("terminal", func(t *testing.T) {
+		var buf bytes.Buffer
+		r := &ProgressRenderer{out: &buf, terminal: true}
+		for _, ev := range events {
+			r.Render(ev)
+		}
+		r.Finish()
+
+		clearLine := "\r" + aec.EraseLine(aec.EraseModes.All).String()
+		assert.Check(t, is.Equal(buf.String(), clearLine+"| Pulling image"+
+			clearLine+" 25% Pulling image"+
+			clearLine+"Pulling image: done\n"+
+			clearLine+"/ push"+
+			clearLine+"push: failed: denied\n"+
+			clearLine+"- build\n"))
+	})
+
+	t.Run("not a terminal", func(t *testing.T) {
+		var buf bytes.Buffer
+		r := NewProgressRenderer(streams.NewOut(&buf))
+		for _, ev := range events {
+			r.Render(ev)
+		}
+		r.Finish()
+		assert.Check(t, is.Equal(buf.String(), "Pulling image: done\npush: failed: denied\n"))
+	})
+}
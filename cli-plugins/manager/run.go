@@ -0,0 +1,231 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"time"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/cli/streams"
+	"github.com/moby/term"
+	"github.com/spf13/cobra"
+)
+
+// PluginTimeoutError is returned if a plugin was killed because it ran for
+// longer than the maximum runtime set through [ConfigFile.CLIPluginsMaxRuntime].
+//
+// [ConfigFile.CLIPluginsMaxRuntime]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsMaxRuntime
+type PluginTimeoutError struct {
+	Name       string
+	MaxRuntime time.Duration
+}
+
+func (e *PluginTimeoutError) Error() string {
+	return fmt.Sprintf("plugin %q was killed after exceeding the maximum runtime of %s", e.Name, e.MaxRuntime)
+}
+
+// getMaxRuntime returns the maximum runtime of plugins, or zero if plugins
+// can run for an unlimited time. It returns an error if the maximum runtime
+// is not a valid, positive duration, so that a typo does not silently allow
+// plugins to run for an unlimited time.
+func getMaxRuntime(cfg *configfile.ConfigFile) (time.Duration, error) {
+	if cfg == nil || cfg.CLIPluginsMaxRuntime == "" {
+		return 0, nil
+	}
+	maxRuntime, err := time.ParseDuration(cfg.CLIPluginsMaxRuntime)
+	if err != nil || maxRuntime <= 0 {
+		return 0, fmt.Errorf("invalid cliPluginsMaxRuntime %q in the CLI configuration file: must be a positive duration, such as \"10m\"", cfg.CLIPluginsMaxRuntime)
+	}
+	return maxRuntime, nil
+}
+
+// startPlugin starts cmd to run a plugin with a maximum runtime. If the
+// plugin is not attached to a terminal, it is started in a new process group,
+// so that killPlugin also kills the processes that were started by the
+// plugin. Plugins that are attached to a terminal must stay in its foreground
+// process group to use it, and to receive signals such as SIGINT, so for
+// those, only the plugin process itself is killed.
+func startPlugin(cmd *exec.Cmd) error {
+	if !isTerminal(cmd.Stdin) && !isTerminal(cmd.Stdout) && !isTerminal(cmd.Stderr) {
+		setNewProcessGroup(cmd)
+	}
+	return cmd.Start()
+}
+
+// isTerminal returns whether the given stream of a plugin is a terminal.
+func isTerminal(stream interface{}) bool {
+	switch s := stream.(type) {
+	case *os.File:
+		return term.IsTerminal(s.Fd())
+	case interface{ IsTerminal() bool }:
+		return s.IsTerminal()
+	default:
+		return false
+	}
+}
+
+// RunPluginCommand runs cmd, as returned by [PluginRunCommand] for the
+// plugin with the given name, and waits for it to exit. If a maximum runtime
+// is set through [ConfigFile.CLIPluginsMaxRuntime], the plugin is killed if
+// it runs for longer, and a [*PluginTimeoutError] is returned. Otherwise, it
+// is equivalent to cmd.Run().
+//
+// [ConfigFile.CLIPluginsMaxRuntime]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsMaxRuntime
+func RunPluginCommand(dockerCli config.Provider, name string, cmd *exec.Cmd) error {
+	maxRuntime, err := getMaxRuntime(dockerCli.ConfigFile())
+	if err != nil {
+		return err
+	}
+	if maxRuntime == 0 {
+		return cmd.Run()
+	}
+	if err := startPlugin(cmd); err != nil {
+		return err
+	}
+	var timedOut atomic.Bool
+	timer := time.AfterFunc(maxRuntime, func() {
+		timedOut.Store(true)
+		_ = killPlugin(cmd)
+	})
+	err = cmd.Wait()
+	timer.Stop()
+	if timedOut.Load() {
+		return &PluginTimeoutError{Name: name, MaxRuntime: maxRuntime}
+	}
+	return err
+}
+
+// copyPluginInput copies in to the stdin of a plugin until the end of the
+// input, or until done is closed after the plugin exited, and then closes
+// the stdin of the plugin (which Wait also closes once the plugin exited,
+// so that writes fail instead of blocking). A read from in that is in progress when the
+// plugin exits cannot be interrupted, but its data is discarded, and no
+// more input is read after it returns.
+func copyPluginInput(stdin io.WriteCloser, in io.Reader, done <-chan struct{}) {
+	defer stdin.Close()
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := in.Read(buf)
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if n > 0 {
+			if _, err := stdin.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// RunPluginCli is the subset of the CLI that is used by [RunPlugin].
+type RunPluginCli interface {
+	config.Provider
+	In() *streams.In
+	Out() *streams.Out
+	Err() *streams.Out
+}
+
+// RunPlugin runs the named plugin with the given arguments, using the CLI's
+// streams for its stdin, stdout, and stderr, and returns its exit code. A
+// non-zero exit code of the plugin is not considered an error. If the
+// context is cancelled, the plugin is killed, and the context's error is
+// returned. If the plugin runs for longer than the maximum runtime set
+// through [ConfigFile.CLIPluginsMaxRuntime], it is killed, and a
+// [*PluginTimeoutError] is returned.
+//
+// Unlike [PluginRunCommand], RunPlugin does not check if the plugin
+// conflicts with a builtin command, and only passes the given arguments to
+// the plugin. The error returned satisfies the IsNotFound() predicate if no
+// valid plugin was found.
+//
+// [ConfigFile.CLIPluginsMaxRuntime]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsMaxRuntime
+func RunPlugin(ctx context.Context, dockerCli RunPluginCli, name string, args []string) (int, error) {
+	cmd, plugin, err := pluginRunCommand(dockerCli, name, append([]string{name}, args...), &cobra.Command{}, func(err error) {
+		_, _ = fmt.Fprintf(dockerCli.Err(), "WARNING: %v\n", err)
+	})
+	if err != nil {
+		return -1, err
+	}
+	maxRuntime, err := getMaxRuntime(dockerCli.ConfigFile())
+	if err != nil {
+		return -1, err
+	}
+	cmd.Stdout = dockerCli.Out()
+	cmd.Stderr = dockerCli.Err()
+	if cfg := dockerCli.ConfigFile(); cfg != nil && cfg.CLIPluginsPrefixStderr {
+		cmd.Stderr = newPrefixWriter(dockerCli.Err(), "["+name+"] ")
+	}
+
+	// Closed when RunPlugin returns, after the plugin exited, or failed
+	// to start.
+	done := make(chan struct{})
+	defer close(done)
+
+	// Pass the terminal to the plugin directly if it is the CLI's input
+	// stream, so that the CLI does not read input on behalf of the plugin,
+	// which would otherwise swallow the first input that is entered after
+	// the plugin exited. Other input streams are copied through a pipe
+	// instead of setting cmd.Stdin, as Wait would otherwise block until the
+	// input stream is closed, even after the plugin exited.
+	if in := dockerCli.In(); in.IsTerminal() && in.FD() == os.Stdin.Fd() {
+		cmd.Stdin = os.Stdin
+	} else {
+		cmd.Stdin = nil
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return -1, err
+		}
+		go copyPluginInput(stdin, in, done)
+	}
+
+	NotifyPluginObserver(PluginEvent{Name: plugin.Name, Path: plugin.Path, Phase: PluginPhaseExec})
+	if err := startPlugin(cmd); err != nil {
+		NotifyPluginObserver(PluginEvent{Name: plugin.Name, Path: plugin.Path, Phase: PluginPhaseExit, ExitCode: -1, Err: err})
+		return -1, err
+	}
+
+	var timeout <-chan time.Time
+	if maxRuntime > 0 {
+		timer := time.NewTimer(maxRuntime)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var timedOut atomic.Bool
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = killPlugin(cmd)
+		case <-timeout:
+			timedOut.Store(true)
+			_ = killPlugin(cmd)
+		case <-done:
+		}
+	}()
+
+	err = cmd.Wait()
+	exitCode := cmd.ProcessState.ExitCode()
+	NotifyPluginObserver(PluginEvent{Name: plugin.Name, Path: plugin.Path, Phase: PluginPhaseExit, ExitCode: exitCode, Err: err})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return exitCode, ctxErr
+	}
+	if timedOut.Load() {
+		return exitCode, &PluginTimeoutError{Name: name, MaxRuntime: maxRuntime}
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return exitCode, err
+	}
+	return exitCode, nil
+}
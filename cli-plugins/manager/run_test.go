@@ -0,0 +1,266 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/cli/streams"
+	"github.com/docker/cli/internal/test"
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestRunPlugin(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-echo", `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+echo "$@"
+cat
+echo "error output" >&2
+exit "$3"`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+	cli.SetIn(streams.NewIn(io.NopCloser(strings.NewReader("input\n"))))
+
+	exitCode, err := RunPlugin(context.Background(), cli, "echo", []string{"hello", "0"})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(exitCode, 0))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "echo hello 0\ninput\n"))
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "error output\n"))
+
+	cli.ResetOutputBuffers()
+	exitCode, err = RunPlugin(context.Background(), cli, "echo", []string{"hello", "3"})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(exitCode, 3))
+
+	_, err = RunPlugin(context.Background(), cli, "missing", nil)
+	assert.Check(t, IsNotFound(err))
+}
+
+func TestRunPluginOpenStdin(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-true", `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+exit 0`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	// The input stream is never closed; RunPlugin returns once the plugin
+	// exits, instead of waiting for the input stream to be closed.
+	r, w := io.Pipe()
+	defer w.Close()
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+	cli.SetIn(streams.NewIn(r))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := RunPlugin(context.Background(), cli, "true", nil)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.NilError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("RunPlugin did not return after the plugin exited")
+	}
+}
+
+func TestRunPluginStopsReadingInput(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-true", `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+exit 0`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	r, w := io.Pipe()
+	defer w.Close()
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+	cli.SetIn(streams.NewIn(r))
+
+	_, err := RunPlugin(context.Background(), cli, "true", nil)
+	assert.NilError(t, err)
+
+	// The read that was in progress when the plugin exited receives this
+	// input, after which no more input is read.
+	_, err = w.Write([]byte("discarded\n"))
+	assert.NilError(t, err)
+	written := make(chan struct{})
+	go func() {
+		_, _ = w.Write([]byte("not read\n"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("input was read after the plugin exited")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestRunPluginCancelledKillsChildren(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a shell")
+	}
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-fork", `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+sleep 10 &
+wait`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	// The child of the plugin keeps the output pipe open, so RunPlugin
+	// only returns quickly if the child is killed as well.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := RunPlugin(ctx, cli, "fork", nil)
+	assert.Check(t, is.ErrorIs(err, context.DeadlineExceeded))
+	assert.Check(t, time.Since(start) < 5*time.Second)
+}
+
+func TestRunPluginCancelled(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-sleep", `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+exec sleep 10`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	exitCode, err := RunPlugin(ctx, cli, "sleep", nil)
+	assert.Check(t, is.ErrorIs(err, context.Canceled))
+	assert.Check(t, is.Equal(exitCode, -1))
+}
+
+func TestRunPluginMaxRuntime(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-sleep", `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+exec sleep "$2"`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs:  []string{dir.Path()},
+		CLIPluginsMaxRuntime: "100ms",
+	})
+
+	_, err := RunPlugin(context.Background(), cli, "sleep", []string{"10"})
+	var timeoutErr *PluginTimeoutError
+	assert.Assert(t, errors.As(err, &timeoutErr), "expected a PluginTimeoutError, got %v", err)
+	assert.Check(t, is.Equal(timeoutErr.Name, "sleep"))
+	assert.Check(t, is.Error(err, `plugin "sleep" was killed after exceeding the maximum runtime of 100ms`))
+
+	exitCode, err := RunPlugin(context.Background(), cli, "sleep", []string{"0"})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(exitCode, 0))
+}
+
+func TestRunPluginMaxRuntimeKillsChildren(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a shell")
+	}
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-fork", `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+sleep 10 &
+wait`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs:  []string{dir.Path()},
+		CLIPluginsMaxRuntime: "100ms",
+	})
+
+	// The child of the plugin keeps the output pipe open, so RunPlugin
+	// only returns quickly if the child is killed as well.
+	start := time.Now()
+	_, err := RunPlugin(context.Background(), cli, "fork", nil)
+	var timeoutErr *PluginTimeoutError
+	assert.Check(t, errors.As(err, &timeoutErr), "expected a PluginTimeoutError, got %v", err)
+	assert.Check(t, time.Since(start) < 5*time.Second)
+}
+
+func TestRunPluginInvalidMaxRuntime(t *testing.T) {
+	for _, maxRuntime := range []string{"10", "-1m", "forever"} {
+		cli := test.NewFakeCli(nil)
+		cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsMaxRuntime: maxRuntime})
+		err := RunPluginCommand(cli, "sleep", exec.Command("true"))
+		assert.Check(t, is.Error(err, `invalid cliPluginsMaxRuntime "`+maxRuntime+`" in the CLI configuration file: must be a positive duration, such as "10m"`))
+	}
+}
+
+func TestRunPluginCommandMaxRuntime(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-sleep", `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+exec sleep 10`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs:  []string{dir.Path()},
+		CLIPluginsMaxRuntime: "100ms",
+	})
+
+	cmd, err := PluginRunCommand(cli, "sleep", &cobra.Command{})
+	assert.NilError(t, err)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = nil, nil, nil
+
+	start := time.Now()
+	err = RunPluginCommand(cli, "sleep", cmd)
+	var timeoutErr *PluginTimeoutError
+	assert.Assert(t, errors.As(err, &timeoutErr), "expected a PluginTimeoutError, got %v", err)
+	assert.Check(t, is.Equal(timeoutErr.MaxRuntime, 100*time.Millisecond))
+	assert.Check(t, time.Since(start) < 5*time.Second)
+}
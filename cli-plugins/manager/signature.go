@@ -0,0 +1,174 @@
+package manager
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docker/cli/cli/config/configfile"
+)
+
+const (
+	// signaturePolicyEnforce refuses to run plugins of which the signature
+	// cannot be verified.
+	signaturePolicyEnforce = "enforce"
+	// signaturePolicyWarn prints a warning when running plugins of which
+	// the signature cannot be verified.
+	signaturePolicyWarn = "warn"
+
+	// signatureFileSuffix is the suffix of the minisign signature file
+	// next to the plugin binary.
+	signatureFileSuffix = ".minisig"
+
+	untrustedCommentPrefix = "untrusted comment: "
+	trustedCommentPrefix   = "trusted comment: "
+)
+
+// SignatureError is the error for a plugin of which the signature could not
+// be verified. It is returned by PluginRunCommand if the signature policy is
+// set to "enforce" through [ConfigFile.CLIPluginsSignaturePolicy].
+//
+// [ConfigFile.CLIPluginsSignaturePolicy]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsSignaturePolicy
+type SignatureError struct {
+	Path string
+	Err  error
+}
+
+// Error satisfies the core error interface for SignatureError.
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("failed to verify signature of %s: %v", e.Path, e.Err)
+}
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (e *SignatureError) Unwrap() error {
+	return e.Err
+}
+
+// signatureVerifier verifies the minisign signatures of plugin binaries
+// against the trusted keys in the config.
+type signatureVerifier struct {
+	enforce bool
+	keys    map[string]ed25519.PublicKey // keyed by key ID
+	// err is set if the policy or keys in the config are invalid, in which
+	// case no plugin can be verified.
+	err error
+}
+
+// newSignatureVerifier returns a verifier for the signature policy in the
+// config, or nil if plugins are not verified.
+func newSignatureVerifier(cfg *configfile.ConfigFile) *signatureVerifier {
+	if cfg == nil || cfg.CLIPluginsSignaturePolicy == "" {
+		return nil
+	}
+	v := &signatureVerifier{keys: make(map[string]ed25519.PublicKey)}
+	switch cfg.CLIPluginsSignaturePolicy {
+	case signaturePolicyEnforce:
+		v.enforce = true
+	case signaturePolicyWarn:
+	default:
+		// Fail closed if the policy is misspelled.
+		v.enforce = true
+		v.err = fmt.Errorf(`invalid cliPluginsSignaturePolicy %q: must be "enforce" or "warn"`, cfg.CLIPluginsSignaturePolicy)
+		return v
+	}
+	if len(cfg.CLIPluginsTrustedKeys) == 0 {
+		v.err = errors.New("no trusted keys are configured in cliPluginsTrustedKeys")
+		return v
+	}
+	for _, k := range cfg.CLIPluginsTrustedKeys {
+		keyID, pub, err := parseMinisignPublicKey(k)
+		if err != nil {
+			v.err = fmt.Errorf("invalid key %q in cliPluginsTrustedKeys: %w", k, err)
+			return v
+		}
+		v.keys[keyID] = pub
+	}
+	return v
+}
+
+// parseMinisignPublicKey parses a minisign public key, which is the base64
+// encoded second line of a minisign public key file.
+func parseMinisignPublicKey(key string) (keyID string, _ ed25519.PublicKey, _ error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(b) != 2+8+ed25519.PublicKeySize || string(b[:2]) != "Ed" {
+		return "", nil, errors.New("not a minisign public key")
+	}
+	return hex.EncodeToString(b[2:10]), ed25519.PublicKey(b[10:]), nil
+}
+
+// verify verifies the signature of the binary at path, which is read from the
+// minisign signature file next to it ("<path>.minisig"). The error returned
+// is a [*SignatureError].
+func (v *signatureVerifier) verify(path string) error {
+	if err := v.verifyFile(path); err != nil {
+		return &SignatureError{Path: path, Err: err}
+	}
+	return nil
+}
+
+func (v *signatureVerifier) verifyFile(path string) error {
+	if v.err != nil {
+		return v.err
+	}
+	sig, err := os.ReadFile(path + signatureFileSuffix)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("plugin is not signed: %s does not exist", path+signatureFileSuffix)
+		}
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return v.verifyMinisign(data, sig)
+}
+
+// verifyMinisign verifies a minisign signature of data, including the
+// signature of the trusted comment. Only signatures in the legacy format
+// (created with "minisign -S -l"), which sign the content itself instead
+// of its BLAKE2b hash, are supported.
+func (v *signatureVerifier) verifyMinisign(data, sigFile []byte) error {
+	lines := strings.Split(strings.TrimSpace(string(bytes.ReplaceAll(sigFile, []byte("\r\n"), []byte("\n")))), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], untrustedCommentPrefix) || !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return errors.New("invalid signature file: expected a minisign signature")
+	}
+	sig, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return errors.New("invalid signature file: malformed signature")
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return errors.New("invalid signature file: malformed trusted comment signature")
+	}
+
+	switch alg := string(sig[:2]); alg {
+	case "Ed":
+	case "ED":
+		return errors.New(`unsupported signature algorithm: sign the plugin with "minisign -S -l"`)
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", alg)
+	}
+	keyID := hex.EncodeToString(sig[2:10])
+	pub, ok := v.keys[keyID]
+	if !ok {
+		return fmt.Errorf("signed with untrusted key %s", strings.ToUpper(keyID))
+	}
+	signature := sig[10:]
+	if !ed25519.Verify(pub, data, signature) {
+		return errors.New("signature does not match")
+	}
+	trustedComment := strings.TrimPrefix(lines[2], trustedCommentPrefix)
+	if !ed25519.Verify(pub, append(bytes.Clone(signature), trustedComment...), globalSig) {
+		return errors.New("trusted comment signature does not match")
+	}
+	return nil
+}
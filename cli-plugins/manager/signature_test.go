@@ -0,0 +1,142 @@
+package manager
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/test"
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+type minisignKey struct {
+	id   []byte
+	priv ed25519.PrivateKey
+	pub  string
+}
+
+func newMinisignKey(t *testing.T, id string) minisignKey {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	keyID := []byte(id)
+	assert.Assert(t, is.Len(keyID, 8))
+	encoded := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...))
+	return minisignKey{id: keyID, priv: priv, pub: encoded}
+}
+
+// sign returns a minisign signature file for data in the legacy format.
+func (k minisignKey) sign(data []byte, trustedComment string) string {
+	return k.signWithAlgorithm("Ed", data, trustedComment)
+}
+
+func (k minisignKey) signWithAlgorithm(alg string, data []byte, trustedComment string) string {
+	signature := ed25519.Sign(k.priv, data)
+	globalSig := ed25519.Sign(k.priv, append(append([]byte{}, signature...), trustedComment...))
+	return "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte(alg), k.id...), signature...)) + "\n" +
+		"trusted comment: " + trustedComment + "\n" +
+		base64.StdEncoding.EncodeToString(globalSig) + "\n"
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	key := newMinisignKey(t, "trusted1")
+	otherKey := newMinisignKey(t, "other123")
+	data := []byte("plugin binary")
+
+	v := newSignatureVerifier(&configfile.ConfigFile{
+		CLIPluginsSignaturePolicy: "enforce",
+		CLIPluginsTrustedKeys:     []string{key.pub},
+	})
+	assert.NilError(t, v.err)
+
+	assert.Check(t, v.verifyMinisign(data, []byte(key.sign(data, "timestamp:1700000000"))))
+
+	assert.Check(t, is.Error(v.verifyMinisign([]byte("tampered binary"), []byte(key.sign(data, "c"))), "signature does not match"))
+	assert.Check(t, is.ErrorContains(v.verifyMinisign(data, []byte(otherKey.sign(data, "c"))), "signed with untrusted key"))
+
+	tampered := strings.Replace(key.sign(data, "original"), "trusted comment: original", "trusted comment: tampered", 1)
+	assert.Check(t, is.Error(v.verifyMinisign(data, []byte(tampered)), "trusted comment signature does not match"))
+
+	prehashed := key.signWithAlgorithm("ED", data, "c")
+	assert.Check(t, is.ErrorContains(v.verifyMinisign(data, []byte(prehashed)), "unsupported signature algorithm"))
+
+	assert.Check(t, is.ErrorContains(v.verifyMinisign(data, []byte("not a signature")), "invalid signature file"))
+}
+
+func TestNewSignatureVerifier(t *testing.T) {
+	key := newMinisignKey(t, "trusted1")
+
+	assert.Check(t, is.Nil(newSignatureVerifier(&configfile.ConfigFile{})))
+
+	v := newSignatureVerifier(&configfile.ConfigFile{CLIPluginsSignaturePolicy: "warn", CLIPluginsTrustedKeys: []string{key.pub}})
+	assert.Check(t, !v.enforce)
+	assert.Check(t, v.err)
+
+	v = newSignatureVerifier(&configfile.ConfigFile{CLIPluginsSignaturePolicy: "enforced", CLIPluginsTrustedKeys: []string{key.pub}})
+	assert.Check(t, v.enforce, "invalid policies should fail closed")
+	assert.Check(t, is.Error(v.err, `invalid cliPluginsSignaturePolicy "enforced": must be "enforce" or "warn"`))
+
+	v = newSignatureVerifier(&configfile.ConfigFile{CLIPluginsSignaturePolicy: "enforce"})
+	assert.Check(t, is.Error(v.err, "no trusted keys are configured in cliPluginsTrustedKeys"))
+
+	v = newSignatureVerifier(&configfile.ConfigFile{CLIPluginsSignaturePolicy: "enforce", CLIPluginsTrustedKeys: []string{"bm90IGEga2V5"}})
+	assert.Check(t, is.Error(v.err, `invalid key "bm90IGEga2V5" in cliPluginsTrustedKeys: not a minisign public key`))
+}
+
+func TestPluginRunCommandSignaturePolicy(t *testing.T) {
+	key := newMinisignKey(t, "trusted1")
+	const plugin = `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-signed", plugin, fs.WithMode(0o777)),
+		fs.WithFile("docker-signed.minisig", key.sign([]byte(plugin), "signed")),
+		fs.WithFile("docker-unsigned", plugin, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs:       []string{dir.Path()},
+		CLIPluginsSignaturePolicy: "enforce",
+		CLIPluginsTrustedKeys:     []string{key.pub},
+	})
+
+	_, err := PluginRunCommand(cli, "signed", &cobra.Command{})
+	assert.NilError(t, err)
+
+	_, err = PluginRunCommand(cli, "unsigned", &cobra.Command{})
+	var sigErr *SignatureError
+	assert.Check(t, errors.As(err, &sigErr))
+	assert.Check(t, is.Error(err, "failed to verify signature of "+dir.Join("docker-unsigned")+": plugin is not signed: "+dir.Join("docker-unsigned.minisig")+" does not exist"))
+
+	plugins, err := ListPlugins(cli, &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(plugins, 2))
+	assert.Check(t, plugins[0].Err)
+	assert.Check(t, errors.As(plugins[1].Err, &sigErr))
+
+	// Plugins that are replaced after they were signed are refused.
+	assert.NilError(t, os.WriteFile(dir.Join("docker-signed"), []byte(plugin+"\n"), 0o777))
+	_, err = PluginRunCommand(cli, "signed", &cobra.Command{})
+	assert.Check(t, is.ErrorContains(err, "signature does not match"))
+
+	// With the "warn" policy, unsigned plugins are run, and a warning is
+	// returned to the caller.
+	cli.ConfigFile().CLIPluginsSignaturePolicy = "warn"
+	_, err = PluginRunCommand(cli, "unsigned", &cobra.Command{})
+	assert.NilError(t, err)
+	_, warnings, err := PluginRunCommandWithWarnings(cli, "unsigned", &cobra.Command{})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(warnings, 1))
+	assert.Check(t, errors.As(warnings[0], &sigErr))
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), ""))
+}
@@ -0,0 +1,87 @@
+package manager
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/fvbommel/sortorder"
+	"github.com/spf13/cobra"
+)
+
+// SortBy specifies the order of the plugins returned by [ListPluginsSorted].
+type SortBy string
+
+const (
+	// SortByName sorts plugins by name. This is the order in which
+	// [ListPlugins] returns plugins.
+	SortByName SortBy = "name"
+	// SortByPath sorts plugins by the precedence of the plugin directory
+	// they were found in, starting with the directory that has the
+	// highest precedence.
+	SortByPath SortBy = "path"
+	// SortByVendor sorts plugins by vendor.
+	SortByVendor SortBy = "vendor"
+)
+
+// ListPluginsSorted is like [ListPlugins], but returns the plugins in the
+// given order. The sort is stable, and plugins that compare equal are
+// sorted by name. An empty order sorts plugins by name.
+func ListPluginsSorted(dockerCli config.Provider, rootcmd *cobra.Command, by SortBy) ([]Plugin, error) {
+	switch by {
+	case "", SortByName, SortByPath, SortByVendor:
+	default:
+		return nil, fmt.Errorf("invalid sort order %q: must be one of %q, %q, or %q", by, SortByName, SortByPath, SortByVendor)
+	}
+	plugins, err := ListPlugins(dockerCli, rootcmd)
+	if err != nil {
+		return nil, err
+	}
+	sortPlugins(plugins, by, getPluginDirs(dockerCli.ConfigFile()))
+	return plugins, nil
+}
+
+// sortPlugins sorts plugins, which must already be sorted by name, in the
+// given order, using the given plugin directories (in order of precedence)
+// to sort by path.
+func sortPlugins(plugins []Plugin, by SortBy, pluginDirs []string) {
+	switch by {
+	case SortByPath:
+		precedence := make([]int, len(plugins))
+		for i, p := range plugins {
+			precedence[i] = dirPrecedence(p.Path, pluginDirs)
+		}
+		sort.Stable(byPrecedence{plugins: plugins, precedence: precedence})
+	case SortByVendor:
+		sort.SliceStable(plugins, func(i, j int) bool {
+			return sortorder.NaturalLess(plugins[i].Vendor, plugins[j].Vendor)
+		})
+	}
+}
+
+// dirPrecedence returns the index of the plugin directory containing path,
+// or len(pluginDirs) if path is not in any of the directories.
+func dirPrecedence(path string, pluginDirs []string) int {
+	for i, d := range pluginDirs {
+		if strings.HasPrefix(path, filepath.Clean(d)+string(filepath.Separator)) {
+			return i
+		}
+	}
+	return len(pluginDirs)
+}
+
+type byPrecedence struct {
+	plugins    []Plugin
+	precedence []int
+}
+
+func (s byPrecedence) Len() int { return len(s.plugins) }
+
+func (s byPrecedence) Less(i, j int) bool { return s.precedence[i] < s.precedence[j] }
+
+func (s byPrecedence) Swap(i, j int) {
+	s.plugins[i], s.plugins[j] = s.plugins[j], s.plugins[i]
+	s.precedence[i], s.precedence[j] = s.precedence[j], s.precedence[i]
+}
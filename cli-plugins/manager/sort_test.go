@@ -0,0 +1,67 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/test"
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestListPluginsSorted(t *testing.T) {
+	plugin := func(vendor string) string {
+		return `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"` + vendor + `"}'`
+	}
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("plugins1",
+			fs.WithFile("docker-sortccc", plugin("vendor-a"), fs.WithMode(0o777)),
+			fs.WithFile("docker-sortbbb", plugin("vendor-b"), fs.WithMode(0o777)),
+		),
+		fs.WithDir("plugins2",
+			fs.WithFile("docker-sortaaa", plugin("vendor-b"), fs.WithMode(0o777)),
+			fs.WithFile("docker-sortddd", plugin("vendor-a"), fs.WithMode(0o777)),
+		),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Join("plugins1"), dir.Join("plugins2")}})
+
+	testCases := []struct {
+		by       SortBy
+		expected []string
+	}{
+		{by: "", expected: []string{"sortaaa", "sortbbb", "sortccc", "sortddd"}},
+		{by: SortByName, expected: []string{"sortaaa", "sortbbb", "sortccc", "sortddd"}},
+		{by: SortByPath, expected: []string{"sortbbb", "sortccc", "sortaaa", "sortddd"}},
+		{by: SortByVendor, expected: []string{"sortccc", "sortddd", "sortaaa", "sortbbb"}},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.by), func(t *testing.T) {
+			plugins, err := ListPluginsSorted(cli, &cobra.Command{}, tc.by)
+			assert.NilError(t, err)
+
+			// Only check the plugins created for this test, as plugins
+			// installed on the system may also be listed.
+			var names []string
+			for _, p := range plugins {
+				if p.Name == "sortaaa" || p.Name == "sortbbb" || p.Name == "sortccc" || p.Name == "sortddd" {
+					names = append(names, p.Name)
+				}
+			}
+			assert.Check(t, is.DeepEqual(names, tc.expected))
+		})
+	}
+}
+
+func TestListPluginsSortedInvalid(t *testing.T) {
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{})
+
+	_, err := ListPluginsSorted(cli, &cobra.Command{}, "size")
+	assert.Check(t, is.Error(err, `invalid sort order "size": must be one of "name", "path", or "vendor"`))
+}
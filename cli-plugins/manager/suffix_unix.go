@@ -5,7 +5,3 @@
 func trimExeSuffix(s string) (string, error) {
 	return s, nil
 }
-
-func addExeSuffix(s string) string {
-	return s
-}
@@ -14,7 +14,3 @@ func trimExeSuffix(s string) (string, error) {
 	}
 	return strings.TrimSuffix(s, ext), nil
 }
-
-func addExeSuffix(s string) string {
-	return s + ".exe"
-}
@@ -0,0 +1,222 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/moby/sys/atomicwriter"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// updateCheckFileName is the name of the file in the config-directory
+	// in which the results of checking for plugin updates are stored.
+	updateCheckFileName = "cli-plugins-update-check.json"
+
+	// updateCheckInterval is the minimum time between checking whether a
+	// newer version of a plugin is available.
+	updateCheckInterval = 24 * time.Hour
+
+	// updateCheckTimeout is the maximum time to wait for the latest release
+	// of a plugin to be fetched from its ReleaseURL.
+	updateCheckTimeout = 2 * time.Second
+
+	// updateCheckWait is the maximum time to wait for a check that is still
+	// in progress after the plugin exited, before it is cancelled.
+	updateCheckWait = 500 * time.Millisecond
+)
+
+// updateCheckEntry is the result of checking the latest release of a plugin.
+// The entry is only used for the ReleaseURL it was fetched from.
+type updateCheckEntry struct {
+	CheckedAt  time.Time `json:"checkedAt"`
+	ReleaseURL string    `json:"releaseURL"`
+	Latest     string    `json:"latest,omitempty"`
+}
+
+// updateCheckFile returns the path of the file in which the results of
+// checking for plugin updates are stored, which is stored next to the given
+// config file.
+func updateCheckFile(cfg *configfile.ConfigFile) string {
+	if cfg == nil || cfg.Filename == "" {
+		return filepath.Join(config.Dir(), updateCheckFileName)
+	}
+	return filepath.Join(filepath.Dir(cfg.Filename), updateCheckFileName)
+}
+
+func readUpdateChecks(fileName string) (map[string]updateCheckEntry, error) {
+	entries := make(map[string]updateCheckEntry)
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return entries, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// updateCheckEnabled returns whether the latest release of plugin p should
+// be checked, which requires the check to be enabled through
+// [ConfigFile.CLIPluginsUpdateCheck], and the plugin to have a ReleaseURL.
+//
+// [ConfigFile.CLIPluginsUpdateCheck]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsUpdateCheck
+func updateCheckEnabled(cfg *configfile.ConfigFile, p Plugin) bool {
+	return cfg != nil && cfg.CLIPluginsUpdateCheck && p.Err == nil && p.ReleaseURL != ""
+}
+
+// StartPluginUpdateCheck checks in the background whether a newer version of
+// plugin p is available from the ReleaseURL in its metadata, if enabled
+// through [ConfigFile.CLIPluginsUpdateCheck]. The latest release is fetched
+// at most once per day, and stored for [PluginUpdateHint]. It is meant to be
+// called when the plugin is started, so that the check runs while the plugin
+// runs.
+//
+// The returned function must be called before the CLI exits. It waits for
+// the check to finish, and cancels it if it did not finish shortly after,
+// so that the CLI does not exit while the result is being written. It can be
+// called more than once.
+//
+// [ConfigFile.CLIPluginsUpdateCheck]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsUpdateCheck
+func StartPluginUpdateCheck(ctx context.Context, dockerCli config.Provider, p Plugin) (wait func()) {
+	cfg := dockerCli.ConfigFile()
+	if !updateCheckEnabled(cfg, p) {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		refreshPluginUpdate(ctx, cfg, p)
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			defer cancel()
+			select {
+			case <-done:
+				return
+			case <-time.After(updateCheckWait):
+			}
+			cancel()
+			<-done
+		})
+	}
+}
+
+// refreshPluginUpdate fetches the latest release of plugin p, unless it was
+// fetched from the same ReleaseURL in the last day. Failures are recorded as
+// an entry without a latest version, so that the check is not retried until
+// the next interval. Nothing is recorded if ctx is cancelled.
+func refreshPluginUpdate(ctx context.Context, cfg *configfile.ConfigFile, p Plugin) {
+	fileName := updateCheckFile(cfg)
+	entries, err := readUpdateChecks(fileName)
+	if err != nil {
+		// Start over if the file is corrupt.
+		logrus.WithError(err).Debugf("Failed to read plugin update checks from %s. Ignoring.", fileName)
+		entries = make(map[string]updateCheckEntry)
+	}
+	if entry, ok := entries[p.Name]; ok && entry.ReleaseURL == p.ReleaseURL && time.Since(entry.CheckedAt) <= updateCheckInterval {
+		return
+	}
+
+	entry := updateCheckEntry{CheckedAt: time.Now().UTC(), ReleaseURL: p.ReleaseURL}
+	latest, err := fetchLatestRelease(ctx, p.ReleaseURL)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		logrus.WithError(err).Debugf("Failed to fetch latest release of plugin %s from %s. Ignoring.", p.Name, p.ReleaseURL)
+	}
+	entry.Latest = latest
+	entries[p.Name] = entry
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return
+	}
+	// The file is written atomically, so that it is never left half-written,
+	// even if the CLI exits while it is written.
+	if err := atomicwriter.WriteFile(fileName, data, 0o600); err != nil {
+		logrus.WithError(err).Debugf("Failed to write plugin update checks to %s. Ignoring.", fileName)
+	}
+}
+
+// PluginUpdateHint returns a one-line hint for the user if a newer version of
+// plugin p is available, according to the latest release that was fetched by
+// [StartPluginUpdateCheck]. It does not fetch the latest release itself; an
+// empty string is returned if the check is disabled, has no result (yet), or
+// the plugin is up to date.
+func PluginUpdateHint(dockerCli config.Provider, p Plugin) string {
+	cfg := dockerCli.ConfigFile()
+	if !updateCheckEnabled(cfg, p) {
+		return ""
+	}
+	entries, err := readUpdateChecks(updateCheckFile(cfg))
+	if err != nil {
+		return ""
+	}
+	entry, ok := entries[p.Name]
+	if !ok || entry.ReleaseURL != p.ReleaseURL {
+		return ""
+	}
+	current, ok := parseSemver(p.Version)
+	if !ok {
+		return ""
+	}
+	latest, ok := parseSemver(entry.Latest)
+	if !ok || compareSemver(latest, current) <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("A new version of CLI plugin %q is available: %s (installed: %s)", p.Name, entry.Latest, p.Version)
+}
+
+// fetchLatestRelease fetches the version of the latest release of a plugin
+// from the given URL, which returns a JSON object with the version in a
+// "version" or "tag_name" field.
+func fetchLatestRelease(ctx context.Context, url string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, updateCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %s", resp.Status)
+	}
+
+	var release struct {
+		Version string `json:"version"`
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&release); err != nil {
+		return "", fmt.Errorf("invalid release: %w", err)
+	}
+	if release.Version != "" {
+		return release.Version, nil
+	}
+	if release.TagName != "" {
+		return release.TagName, nil
+	}
+	return "", errors.New("invalid release: no version")
+}
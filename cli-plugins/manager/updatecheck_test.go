@@ -0,0 +1,147 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docker/cli/cli-plugins/metadata"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestPluginUpdateCheck(t *testing.T) {
+	var requests atomic.Int32
+	latest := `{"tag_name":"v1.1.0"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(latest))
+	}))
+	defer srv.Close()
+
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		Filename:              dir.Join("config.json"),
+		CLIPluginsUpdateCheck: true,
+	})
+	ctx := context.Background()
+	p := Plugin{Name: "aaa", Metadata: metadata.Metadata{Version: "v1.0.0", ReleaseURL: srv.URL}}
+
+	// There is no hint until the latest release was fetched.
+	assert.Check(t, is.Equal(PluginUpdateHint(cli, p), ""))
+
+	const expected = `A new version of CLI plugin "aaa" is available: v1.1.0 (installed: v1.0.0)`
+	StartPluginUpdateCheck(ctx, cli, p)()
+	assert.Check(t, is.Equal(PluginUpdateHint(cli, p), expected))
+	StartPluginUpdateCheck(ctx, cli, p)()
+	assert.Check(t, is.Equal(requests.Load(), int32(1)), "latest release should only be fetched once per interval")
+
+	// Upgrading the plugin is detected without fetching the latest release.
+	p.Version = "v1.1.0"
+	assert.Check(t, is.Equal(PluginUpdateHint(cli, p), ""))
+
+	// The latest release is fetched again after the interval.
+	fileName := dir.Join(updateCheckFileName)
+	entries, err := readUpdateChecks(fileName)
+	assert.NilError(t, err)
+	entry := entries["aaa"]
+	entry.CheckedAt = entry.CheckedAt.Add(-2 * updateCheckInterval)
+	entries["aaa"] = entry
+	writeUpdateChecks(t, fileName, entries)
+	latest = `{"version":"v1.2.0"}`
+	StartPluginUpdateCheck(ctx, cli, p)()
+	assert.Check(t, is.Equal(requests.Load(), int32(2)))
+	assert.Check(t, is.Equal(PluginUpdateHint(cli, p), `A new version of CLI plugin "aaa" is available: v1.2.0 (installed: v1.1.0)`))
+
+	// Nothing is checked if disabled, or if the plugin has no ReleaseURL.
+	cli.ConfigFile().CLIPluginsUpdateCheck = false
+	StartPluginUpdateCheck(ctx, cli, p)()
+	assert.Check(t, is.Equal(PluginUpdateHint(cli, p), ""))
+	cli.ConfigFile().CLIPluginsUpdateCheck = true
+	p.ReleaseURL = ""
+	StartPluginUpdateCheck(ctx, cli, p)()
+	assert.Check(t, is.Equal(PluginUpdateHint(cli, p), ""))
+	assert.Check(t, is.Equal(requests.Load(), int32(2)))
+}
+
+func TestPluginUpdateCheckFailure(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		Filename:              filepath.Join(t.TempDir(), "config.json"),
+		CLIPluginsUpdateCheck: true,
+	})
+	p := Plugin{Name: "aaa", Metadata: metadata.Metadata{Version: "v1.0.0", ReleaseURL: srv.URL}}
+	for i := 0; i < 2; i++ {
+		StartPluginUpdateCheck(context.Background(), cli, p)()
+		assert.Check(t, is.Equal(PluginUpdateHint(cli, p), ""))
+	}
+	assert.Check(t, is.Equal(requests.Load(), int32(1)), "failed checks should not be retried until the next interval")
+}
+
+func TestPluginUpdateCheckCancelled(t *testing.T) {
+	// The server does not respond until the check was cancelled.
+	released := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-released:
+		}
+	}))
+	defer srv.Close()
+	defer close(released)
+
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	fileName := dir.Join(updateCheckFileName)
+	previous := map[string]updateCheckEntry{
+		"bbb": {CheckedAt: time.Now().UTC(), ReleaseURL: "https://example.com/bbb", Latest: "v2.0.0"},
+	}
+	writeUpdateChecks(t, fileName, previous)
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		Filename:              dir.Join("config.json"),
+		CLIPluginsUpdateCheck: true,
+	})
+	p := Plugin{Name: "aaa", Metadata: metadata.Metadata{Version: "v1.0.0", ReleaseURL: srv.URL}}
+
+	start := time.Now()
+	StartPluginUpdateCheck(context.Background(), cli, p)()
+	assert.Check(t, time.Since(start) < updateCheckTimeout, "check should be cancelled shortly after the plugin exited")
+
+	// The cut-off check leaves the stored results as they were, and does
+	// not leave any other (temporary) files behind.
+	entries, err := readUpdateChecks(fileName)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(entries, 1))
+	assert.Check(t, is.Equal(entries["bbb"].Latest, "v2.0.0"))
+	files, err := os.ReadDir(dir.Path())
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(files, 1))
+}
+
+func writeUpdateChecks(t *testing.T, fileName string, entries map[string]updateCheckEntry) {
+	t.Helper()
+	data, err := json.Marshal(entries)
+	assert.NilError(t, err)
+	assert.NilError(t, os.WriteFile(fileName, data, 0o600))
+}
@@ -0,0 +1,58 @@
+package manager
+
+import (
+	"context"
+	"errors"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/sirupsen/logrus"
+)
+
+// UpgradeUnknown is reported by [CheckUpgrades] for plugins for which it
+// could not be determined whether a newer version is available.
+const UpgradeUnknown = "unknown"
+
+// CheckUpgrades compares the version of each of the given plugins against
+// the plugin index at [ConfigFile.CLIPluginsIndexURL]. The index uses the
+// same format as the plugin manifest. It returns a map from plugin name to
+// the version in the index if that version is newer than the version of the
+// plugin, or an empty string if the plugin is up to date. [UpgradeUnknown]
+// is returned for plugins that are not in the index, or whose version can't
+// be compared, and for all plugins if the index can't be fetched.
+//
+// [ConfigFile.CLIPluginsIndexURL]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsIndexURL
+func CheckUpgrades(ctx context.Context, dockerCli config.Provider, plugins []Plugin) (map[string]string, error) {
+	cfg := dockerCli.ConfigFile()
+	if cfg == nil || cfg.CLIPluginsIndexURL == "" {
+		return nil, errors.New("no CLI plugin index configured: set cliPluginsIndexURL in the CLI configuration file")
+	}
+	index, err := fetchPluginManifest(ctx, cfg.CLIPluginsIndexURL)
+	if err != nil {
+		logrus.WithError(err).Debugf("Failed to fetch plugin index from %s", cfg.CLIPluginsIndexURL)
+	}
+	entries := manifestByName(index)
+	upgrades := make(map[string]string, len(plugins))
+	for _, p := range plugins {
+		upgrades[p.Name] = checkUpgrade(p, entries)
+	}
+	return upgrades, nil
+}
+
+func checkUpgrade(p Plugin, index map[string]approvedPlugin) string {
+	entry, ok := index[p.Name]
+	if !ok {
+		return UpgradeUnknown
+	}
+	current, ok := parseSemver(p.Version)
+	if !ok {
+		return UpgradeUnknown
+	}
+	latest, ok := parseSemver(entry.Version)
+	if !ok {
+		return UpgradeUnknown
+	}
+	if compareSemver(latest, current) > 0 {
+		return entry.Version
+	}
+	return ""
+}
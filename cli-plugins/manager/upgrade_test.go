@@ -0,0 +1,76 @@
+package manager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/cli/cli-plugins/metadata"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestCheckUpgrades(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[
+	{"name":"newer","version":"1.2.0"},
+	{"name":"equal","version":"1.0.0"},
+	{"name":"older","version":"0.9.0"},
+	{"name":"prerelease","version":"1.0.0-rc.1"},
+	{"name":"invalid","version":"latest"}
+]`))
+	}))
+	defer srv.Close()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsIndexURL: srv.URL})
+
+	plugin := func(name, version string) Plugin {
+		return Plugin{Name: name, Metadata: metadata.Metadata{Version: version}}
+	}
+	plugins := []Plugin{
+		plugin("newer", "v1.0.0"),
+		plugin("equal", "1.0.0"),
+		plugin("older", "1.0.0"),
+		plugin("prerelease", "1.0.0-beta.2"),
+		plugin("invalid", "1.0.0"),
+		plugin("unversioned", ""),
+		plugin("unlisted", "1.0.0"),
+	}
+	upgrades, err := CheckUpgrades(context.Background(), cli, plugins)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(upgrades, map[string]string{
+		"newer":       "1.2.0",
+		"equal":       "",
+		"older":       "",
+		"prerelease":  "1.0.0-rc.1",
+		"invalid":     UpgradeUnknown,
+		"unversioned": UpgradeUnknown,
+		"unlisted":    UpgradeUnknown,
+	}))
+}
+
+func TestCheckUpgradesIndexUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsIndexURL: srv.URL})
+
+	upgrades, err := CheckUpgrades(context.Background(), cli, []Plugin{{Name: "aaa", Metadata: metadata.Metadata{Version: "1.0.0"}}})
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(upgrades, map[string]string{"aaa": UpgradeUnknown}))
+}
+
+func TestCheckUpgradesNoIndex(t *testing.T) {
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{})
+
+	_, err := CheckUpgrades(context.Background(), cli, nil)
+	assert.Check(t, is.ErrorContains(err, "no CLI plugin index configured"))
+}
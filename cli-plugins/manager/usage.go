@@ -0,0 +1,97 @@
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/moby/sys/atomicwriter"
+	"github.com/sirupsen/logrus"
+)
+
+// pluginUsageFileName is the name of the file in the config-directory in
+// which usage of CLI plugins is recorded.
+const pluginUsageFileName = "cli-plugins-usage.json"
+
+// PluginUsageStats describes how a CLI plugin has been used.
+type PluginUsageStats struct {
+	// LastUsed is the time at which the plugin was last run.
+	LastUsed time.Time `json:"lastUsed"`
+	// Invocations is the number of times the plugin was run.
+	Invocations int `json:"invocations"`
+}
+
+// PluginUsage returns the recorded usage of CLI plugins, keyed by plugin
+// name. Usage is only recorded if enabled through [ConfigFile.CLIPluginsRecordUsage].
+//
+// [ConfigFile.CLIPluginsRecordUsage]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsRecordUsage
+func PluginUsage(dockerCli config.Provider) (map[string]PluginUsageStats, error) {
+	return readPluginUsage(pluginUsageFile(dockerCli.ConfigFile()))
+}
+
+// pluginUsageFile returns the path of the file in which plugin usage is
+// recorded, which is stored next to the given config file.
+func pluginUsageFile(cfg *configfile.ConfigFile) string {
+	if cfg == nil || cfg.Filename == "" {
+		return filepath.Join(config.Dir(), pluginUsageFileName)
+	}
+	return filepath.Join(filepath.Dir(cfg.Filename), pluginUsageFileName)
+}
+
+func readPluginUsage(fileName string) (map[string]PluginUsageStats, error) {
+	usage := make(map[string]PluginUsageStats)
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return usage, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// RecordPluginUsage records that the named plugin is run, if enabled through
+// [ConfigFile.CLIPluginsRecordUsage]. It should only be called once the plugin
+// was started, so that help and completion requests, and runs that failed
+// to start, are not counted.
+//
+// [ConfigFile.CLIPluginsRecordUsage]: https://pkg.go.dev/github.com/docker/cli/cli/config/configfile#ConfigFile.CLIPluginsRecordUsage
+func RecordPluginUsage(dockerCli config.Provider, name string) {
+	recordPluginUsage(dockerCli.ConfigFile(), name)
+}
+
+// recordPluginUsage records that the named plugin is run, if enabled in the
+// configuration. Recording is best-effort; errors are logged and otherwise
+// ignored, so that they don't prevent the plugin from running.
+func recordPluginUsage(cfg *configfile.ConfigFile, name string) {
+	if cfg == nil || !cfg.CLIPluginsRecordUsage {
+		return
+	}
+	fileName := pluginUsageFile(cfg)
+	usage, err := readPluginUsage(fileName)
+	if err != nil {
+		// Start over if the file is corrupt.
+		logrus.WithError(err).Debugf("Failed to read plugin usage from %s. Ignoring.", fileName)
+		usage = make(map[string]PluginUsageStats)
+	}
+	stats := usage[name]
+	stats.LastUsed = time.Now().UTC()
+	stats.Invocations++
+	usage[name] = stats
+
+	data, err := json.Marshal(usage)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to record plugin usage. Ignoring.")
+		return
+	}
+	if err := atomicwriter.WriteFile(fileName, data, 0o600); err != nil {
+		logrus.WithError(err).Debugf("Failed to write plugin usage to %s. Ignoring.", fileName)
+	}
+}
@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/test"
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestRecordPluginUsage(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("plugins",
+			fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+		),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(nil)
+	cli.SetConfigFile(&configfile.ConfigFile{
+		Filename:            dir.Join("config.json"),
+		CLIPluginsExtraDirs: []string{dir.Join("plugins")},
+	})
+
+	// Usage is not recorded by default.
+	RecordPluginUsage(cli, "aaa")
+	usage, err := PluginUsage(cli)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(usage, 0))
+
+	// Building the command to run the plugin, for example to show its
+	// help, is not counted as using the plugin.
+	cli.ConfigFile().CLIPluginsRecordUsage = true
+	_, err = PluginRunCommand(cli, "aaa", &cobra.Command{})
+	assert.NilError(t, err)
+	usage, err = PluginUsage(cli)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(usage, 0))
+
+	start := time.Now().UTC()
+	RecordPluginUsage(cli, "aaa")
+	usage, err = PluginUsage(cli)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(usage["aaa"].Invocations, 1))
+	assert.Check(t, !usage["aaa"].LastUsed.Before(start))
+
+	lastUsed := usage["aaa"].LastUsed
+	RecordPluginUsage(cli, "aaa")
+	usage, err = PluginUsage(cli)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(usage["aaa"].Invocations, 2))
+	assert.Check(t, !usage["aaa"].LastUsed.Before(lastUsed))
+}
+
+func TestRecordPluginUsageIgnoresErrors(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(), fs.WithFile(pluginUsageFileName, "not json"))
+	defer dir.Remove()
+
+	cfg := &configfile.ConfigFile{Filename: dir.Join("config.json"), CLIPluginsRecordUsage: true}
+	recordPluginUsage(cfg, "aaa")
+
+	usage, err := readPluginUsage(dir.Join(pluginUsageFileName))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(usage["aaa"].Invocations, 1))
+}
@@ -0,0 +1,112 @@
+package manager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/cli/cli/version"
+)
+
+// checkMinCLIVersion verifies that the running CLI is at least the minimum
+// version required by the plugin. Development builds, of which the version
+// is not a valid semantic version, are assumed to be compatible.
+func checkMinCLIVersion(minVersion string) error {
+	minimum, ok := parseSemver(minVersion)
+	if !ok {
+		return NewPluginError("plugin MinCLIVersion %q is not a valid version", minVersion)
+	}
+	current, ok := parseSemver(version.Version)
+	if !ok {
+		return nil
+	}
+	if compareSemver(current, minimum) < 0 {
+		return &pluginError{cause: &incompatibleError{cause: fmt.Errorf("plugin requires CLI >= %s (current version is %s)", minVersion, version.Version)}}
+	}
+	return nil
+}
+
+type semver struct {
+	core       [3]int
+	prerelease []string
+}
+
+// parseSemver parses a semantic version, optionally prefixed with "v".
+// Minor and patch versions may be omitted, and build metadata is ignored.
+func parseSemver(v string) (semver, bool) {
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "+")
+	v, pre, hasPre := strings.Cut(v, "-")
+
+	var sv semver
+	parts := strings.Split(v, ".")
+	if len(parts) > len(sv.core) {
+		return semver{}, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return semver{}, false
+		}
+		sv.core[i] = n
+	}
+	if hasPre {
+		if pre == "" {
+			return semver{}, false
+		}
+		sv.prerelease = strings.Split(pre, ".")
+	}
+	return sv, true
+}
+
+// compareSemver compares two versions following the semantic versioning
+// precedence rules. It returns -1 if a < b, 1 if a > b, and 0 otherwise.
+func compareSemver(a, b semver) int {
+	for i := range a.core {
+		if c := compareInt(a.core[i], b.core[i]); c != 0 {
+			return c
+		}
+	}
+	// A pre-release version has a lower precedence than the release.
+	switch {
+	case len(a.prerelease) == 0 && len(b.prerelease) == 0:
+		return 0
+	case len(a.prerelease) == 0:
+		return 1
+	case len(b.prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(a.prerelease) && i < len(b.prerelease); i++ {
+		if c := comparePrerelease(a.prerelease[i], b.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInt(len(a.prerelease), len(b.prerelease))
+}
+
+// comparePrerelease compares pre-release identifiers. Numeric identifiers
+// are compared numerically, and have a lower precedence than alphanumeric
+// identifiers, which are compared lexically.
+func comparePrerelease(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareInt(an, bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,54 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/docker/cli/cli/version"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestCompareSemver(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "28.1.0", b: "28.1.0", expected: 0},
+		{a: "v28.1.0", b: "28.1", expected: 0},
+		{a: "28.1.0+build", b: "28.1.0", expected: 0},
+		{a: "28.1.0", b: "28.0.4", expected: 1},
+		{a: "9.0.0", b: "10.0.0", expected: -1},
+		{a: "28.1.0-rc.1", b: "28.1.0", expected: -1},
+		{a: "28.1.0-rc.2", b: "28.1.0-rc.10", expected: -1},
+		{a: "28.1.0-rc.1", b: "28.1.0-beta.1", expected: 1},
+		{a: "28.1.0-rc", b: "28.1.0-rc.1", expected: -1},
+		{a: "28.1.0-1", b: "28.1.0-rc", expected: -1},
+	} {
+		a, ok := parseSemver(tc.a)
+		assert.Assert(t, ok, tc.a)
+		b, ok := parseSemver(tc.b)
+		assert.Assert(t, ok, tc.b)
+		assert.Check(t, is.Equal(compareSemver(a, b), tc.expected), "%s <=> %s", tc.a, tc.b)
+	}
+}
+
+func TestParseSemverInvalid(t *testing.T) {
+	for _, v := range []string{"", "unknown-version", "28.1.0.1", "28.x", "28.1.0-", "-1.0.0"} {
+		_, ok := parseSemver(v)
+		assert.Check(t, !ok, v)
+	}
+}
+
+func TestCheckMinCLIVersion(t *testing.T) {
+	defer func(orig string) { version.Version = orig }(version.Version)
+
+	version.Version = "28.1.0"
+	assert.Check(t, checkMinCLIVersion("28.0.0"))
+	assert.Check(t, checkMinCLIVersion("28.1.0"))
+	assert.Check(t, is.Error(checkMinCLIVersion("28.2.0"), "plugin requires CLI >= 28.2.0 (current version is 28.1.0)"))
+	assert.Check(t, is.Error(checkMinCLIVersion("latest"), `plugin MinCLIVersion "latest" is not a valid version`))
+
+	// Development builds are assumed to be compatible.
+	version.Version = "unknown-version"
+	assert.Check(t, checkMinCLIVersion("99.0.0"))
+}
@@ -23,7 +23,10 @@
 
 // Metadata provided by the plugin.
 type Metadata struct {
-	// SchemaVersion describes the version of this struct. Mandatory, must be "0.1.0"
+	// SchemaVersion describes the version of this struct. Mandatory, must be
+	// "0.1.0" or "0.2.0". Plugins that set MinAPIVersion or Platforms should
+	// use "0.2.0", so that older versions of the CLI, which ignore these
+	// fields, refuse to run the plugin.
 	SchemaVersion string `json:",omitempty"`
 	// Vendor is the name of the plugin vendor. Mandatory
 	Vendor string `json:",omitempty"`
@@ -31,6 +34,47 @@ type Metadata struct {
 	Version string `json:",omitempty"`
 	// ShortDescription should be suitable for a single line help message.
 	ShortDescription string `json:",omitempty"`
+	// ShortDescriptions optionally provides localized versions of
+	// ShortDescription, keyed by language tag (for example, "fr" or
+	// "pt-BR"). The CLI picks the entry matching the user's locale, and
+	// falls back to ShortDescription if there is no match.
+	ShortDescriptions map[string]string `json:",omitempty"`
 	// URL is a pointer to the plugin's homepage.
 	URL string `json:",omitempty"`
+	// ReleaseURL is an optional URL that returns the latest release of the
+	// plugin as a JSON object with its version in a "version" or "tag_name"
+	// field, such as the "latest release" endpoint of the GitHub API. The
+	// CLI uses it to notify users that a newer version of the plugin is
+	// available, if enabled in the CLI configuration.
+	ReleaseURL string `json:",omitempty"`
+	// SkipPersistentPreRun disables setting up the API client in the
+	// plugin's PersistentPreRunE hook. Plugins which do not use the
+	// Docker API client can set this to prevent the CLI from setting
+	// up a client and connecting to the daemon. The CLI is still
+	// initialized, and the plugin's commands are still instrumented.
+	SkipPersistentPreRun bool `json:",omitempty"`
+	// Requires is an optional list of names of other plugins that must
+	// be installed for this plugin to run.
+	Requires []string `json:",omitempty"`
+	// Hidden hides the plugin from the list of commands in the CLI's help
+	// output. Hidden plugins can still be invoked.
+	Hidden bool `json:",omitempty"`
+	// MinCLIVersion is the optional minimum version of the CLI that is
+	// required to run this plugin, for example "28.1.0".
+	MinCLIVersion string `json:",omitempty"`
+	// MinAPIVersion is the optional minimum version of the Docker Engine
+	// API that is required by this plugin, for example "1.47". It is
+	// checked when the plugin initializes its API client in its
+	// PersistentPreRunE hook, and therefore not for plugins that set
+	// SkipPersistentPreRun. Requires SchemaVersion "0.2.0".
+	MinAPIVersion string `json:",omitempty"`
+	// Platforms optionally lists the platforms that the plugin supports,
+	// either as an OS ("linux") or as OS and architecture ("linux/amd64"),
+	// using the values of GOOS and GOARCH. The CLI refuses to run the plugin
+	// on other platforms. Requires SchemaVersion "0.2.0".
+	Platforms []string `json:",omitempty"`
+	// Capabilities is an optional, free-form list of what the plugin
+	// intends to do, for example "network" or "filesystem". It is
+	// informational only, and not enforced by the CLI.
+	Capabilities []string `json:",omitempty"`
 }
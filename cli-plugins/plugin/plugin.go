@@ -6,7 +6,9 @@
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli-plugins/metadata"
@@ -14,6 +16,7 @@
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/connhelper"
 	"github.com/docker/cli/cli/debug"
+	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/client"
 	"github.com/spf13/cobra"
 	"go.opentelemetry.io/otel"
@@ -29,8 +32,41 @@
 // called.
 var PersistentPreRunE func(*cobra.Command, []string) error
 
+// RunOption configures how a plugin is run by [Run] and [RunPlugin].
+type RunOption func(*runOptions)
+
+type runOptions struct {
+	cancelOnSignal bool
+	shutdownHook   func()
+}
+
+// WithCancelOnSignal makes the plugin cancel the context of the plugin
+// command (cmd.Context()) when the plugin receives SIGINT or SIGTERM,
+// instead of being terminated by the signal. The command is expected to
+// return once its context is cancelled.
+func WithCancelOnSignal() RunOption {
+	return func(o *runOptions) {
+		o.cancelOnSignal = true
+	}
+}
+
+// WithShutdownHook is like [WithCancelOnSignal], and additionally calls fn
+// after the plugin command returned if the plugin received SIGINT or SIGTERM.
+// It can be used to release resources, such as open connections, before the
+// plugin exits.
+func WithShutdownHook(fn func()) RunOption {
+	return func(o *runOptions) {
+		o.cancelOnSignal = true
+		o.shutdownHook = fn
+	}
+}
+
 // RunPlugin executes the specified plugin command
-func RunPlugin(dockerCli *command.DockerCli, plugin *cobra.Command, meta metadata.Metadata) error {
+func RunPlugin(dockerCli *command.DockerCli, plugin *cobra.Command, meta metadata.Metadata, opts ...RunOption) error {
+	var runOpts runOptions
+	for _, o := range opts {
+		o(&runOpts)
+	}
 	tcmd := newPluginCommand(dockerCli, plugin, meta)
 
 	var persistentPreRunOnce sync.Once
@@ -42,12 +78,18 @@ func RunPlugin(dockerCli *command.DockerCli, plugin *cobra.Command, meta metadat
 			// Set up the context to cancel based on signalling via CLI socket.
 			socket.ConnectAndWait(cancel)
 
+			// Plugins that opted out of the persistent pre-run are still
+			// initialized and instrumented, but no client is configured
+			// for them, and they don't connect to the daemon.
 			var opts []command.CLIOption
-			if os.Getenv("DOCKER_CLI_PLUGIN_USE_DIAL_STDIO") != "" {
+			if !meta.SkipPersistentPreRun && os.Getenv("DOCKER_CLI_PLUGIN_USE_DIAL_STDIO") != "" {
 				opts = append(opts, withPluginClientConn(plugin.Name()))
 			}
 			opts = append(opts, command.WithEnableGlobalMeterProvider(), command.WithEnableGlobalTracerProvider())
 			retErr = tcmd.Initialize(opts...)
+			if retErr == nil && !meta.SkipPersistentPreRun && meta.MinAPIVersion != "" {
+				retErr = checkMinAPIVersion(dockerCli, meta.MinAPIVersion)
+			}
 			ogRunE := cmd.RunE
 			if ogRunE == nil {
 				ogRun := cmd.Run
@@ -77,11 +119,21 @@ func RunPlugin(dockerCli *command.DockerCli, plugin *cobra.Command, meta metadat
 	// We've parsed global args already, so reset args to those
 	// which remain.
 	cmd.SetArgs(args)
-	return cmd.Execute()
+	if !runOpts.cancelOnSignal {
+		return cmd.Execute()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = cmd.ExecuteContext(ctx)
+	if ctx.Err() != nil && runOpts.shutdownHook != nil {
+		runOpts.shutdownHook()
+	}
+	return err
 }
 
 // Run is the top-level entry point to the CLI plugin framework. It should be called from your plugin's `main()` function.
-func Run(makeCmd func(command.Cli) *cobra.Command, meta metadata.Metadata) {
+func Run(makeCmd func(command.Cli) *cobra.Command, meta metadata.Metadata, opts ...RunOption) {
 	otel.SetErrorHandler(debug.OTELErrorHandler)
 
 	dockerCli, err := command.NewDockerCli()
@@ -92,7 +144,7 @@ func Run(makeCmd func(command.Cli) *cobra.Command, meta metadata.Metadata) {
 
 	plugin := makeCmd(dockerCli)
 
-	if err := RunPlugin(dockerCli, plugin, meta); err != nil {
+	if err := RunPlugin(dockerCli, plugin, meta, opts...); err != nil {
 		var stErr cli.StatusError
 		if errors.As(err, &stErr) {
 			// StatusError should only be used for errors, and all errors should
@@ -108,6 +160,15 @@ func Run(makeCmd func(command.Cli) *cobra.Command, meta metadata.Metadata) {
 	}
 }
 
+// checkMinAPIVersion verifies that the API version negotiated with the daemon
+// is at least the minimum version required by the plugin.
+func checkMinAPIVersion(dockerCli *command.DockerCli, minVersion string) error {
+	if current := dockerCli.CurrentVersion(); versions.LessThan(current, minVersion) {
+		return fmt.Errorf("plugin requires Docker API >= %s (current version is %s)", minVersion, current)
+	}
+	return nil
+}
+
 func withPluginClientConn(name string) command.CLIOption {
 	return func(cli *command.DockerCli) error {
 		cmd := "docker"
@@ -0,0 +1,70 @@
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/docker/cli/cli-plugins/metadata"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestRunPluginSkipPersistentPreRun(t *testing.T) {
+	// The daemon only supports an API version that is older than the
+	// minimum version that is required by the plugin.
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Api-Version", "1.40")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	t.Setenv("DOCKER_HOST", "tcp://"+srv.Listener.Addr().String())
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	t.Setenv("DOCKER_CLI_PLUGIN_USE_DIAL_STDIO", "")
+
+	origArgs := os.Args
+	defer func() { os.Args = origArgs }()
+	os.Args = []string{"docker-test", "test"}
+
+	for _, skip := range []bool{false, true} {
+		t.Run(fmt.Sprintf("skip=%t", skip), func(t *testing.T) {
+			requests.Store(0)
+			dockerCli, err := command.NewDockerCli()
+			assert.NilError(t, err)
+
+			var initialized, ran bool
+			cmd := &cobra.Command{
+				Use: "test",
+				Run: func(*cobra.Command, []string) {
+					ran = true
+					initialized = dockerCli.ContextStore() != nil
+				},
+			}
+			err = RunPlugin(dockerCli, cmd, metadata.Metadata{
+				SchemaVersion:        "0.2.0",
+				Vendor:               "e2e-testing",
+				MinAPIVersion:        "1.45",
+				SkipPersistentPreRun: skip,
+			})
+			if skip {
+				// The CLI is initialized, but the plugin doesn't connect to
+				// the daemon.
+				assert.NilError(t, err)
+				assert.Check(t, ran)
+				assert.Check(t, initialized)
+				assert.Check(t, is.Equal(requests.Load(), int32(0)))
+			} else {
+				assert.Check(t, is.Error(err, "plugin requires Docker API >= 1.45 (current version is 1.40)"))
+				assert.Check(t, !ran)
+				assert.Check(t, requests.Load() > 0)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package socket
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+)
+
+// ProgressEvent is a progress update of a long-running operation, which a
+// plugin sends to the host CLI over the plugin socket, so that the CLI can
+// render the progress consistently for all plugins. Events are sent as
+// newline-delimited JSON.
+type ProgressEvent struct {
+	// ID identifies the operation. Events with the same ID update the
+	// progress of the same operation.
+	ID string `json:"id"`
+	// Message describes the operation, for example "Pulling base image".
+	Message string `json:"message,omitempty"`
+	// Current and Total are the progress of the operation, for example in
+	// bytes or steps. The progress is rendered as a percentage if Total is
+	// set, or as a spinner otherwise.
+	Current int64 `json:"current,omitempty"`
+	Total   int64 `json:"total,omitempty"`
+	// Done marks the operation as completed.
+	Done bool `json:"done,omitempty"`
+	// Error is set if the operation failed. It implies Done.
+	Error string `json:"error,omitempty"`
+}
+
+// maxProgressEventSize is the maximum size of a progress event; larger
+// events are discarded by the host CLI.
+const maxProgressEventSize = 64 * 1024
+
+var (
+	hostConnMu sync.Mutex
+	hostConn   net.Conn
+)
+
+func setHostConn(conn net.Conn) {
+	hostConnMu.Lock()
+	defer hostConnMu.Unlock()
+	hostConn = conn
+}
+
+// ReportProgress sends a progress event to the host CLI over the plugin
+// socket. It is a no-op if the plugin is not connected to the socket, for
+// example if it was executed by an older CLI binary, or not through the CLI.
+// [ConnectAndWait] must be called first, which is done by the plugin's
+// PersistentPreRunE hook.
+func ReportProgress(ev ProgressEvent) error {
+	if ev.ID == "" {
+		return errors.New("progress event must have an ID")
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	hostConnMu.Lock()
+	defer hostConnMu.Unlock()
+	if hostConn == nil {
+		return nil
+	}
+	_, err = hostConn.Write(append(data, '\n'))
+	return err
+}
+
+// HandleProgress returns a connection handler for [NewPluginServer] which
+// reads the progress events that the plugin sends over the connection, and
+// calls fn for each event until the connection is closed. Malformed events
+// are ignored.
+func HandleProgress(fn func(ProgressEvent)) func(net.Conn) {
+	return func(conn net.Conn) {
+		scanner := bufio.NewScanner(conn)
+		scanner.Buffer(make([]byte, 0, 4096), maxProgressEventSize)
+		for scanner.Scan() {
+			var ev ProgressEvent
+			if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil || ev.ID == "" {
+				continue
+			}
+			fn(ev)
+		}
+	}
+}
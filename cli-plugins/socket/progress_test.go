@@ -0,0 +1,71 @@
+package socket
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/poll"
+)
+
+func TestReportProgress(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []ProgressEvent
+	)
+	srv, err := NewPluginServer(HandleProgress(func(ev ProgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, ev)
+	}))
+	assert.NilError(t, err)
+	defer srv.Close()
+
+	// Not connected yet, so progress is not reported.
+	setHostConn(nil)
+	assert.NilError(t, ReportProgress(ProgressEvent{ID: "ignored"}))
+
+	t.Setenv(EnvKey, srv.Addr().String())
+	ConnectAndWait(func() {})
+	defer setHostConn(nil)
+
+	assert.Check(t, is.Error(ReportProgress(ProgressEvent{}), "progress event must have an ID"))
+	assert.NilError(t, ReportProgress(ProgressEvent{ID: "pull", Message: "Pulling", Current: 1, Total: 2}))
+	assert.NilError(t, ReportProgress(ProgressEvent{ID: "pull", Message: "Pulling", Done: true}))
+
+	poll.WaitOn(t, func(t poll.LogT) poll.Result {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(events) < 2 {
+			return poll.Continue("waiting for progress events")
+		}
+		return poll.Success()
+	}, poll.WithDelay(time.Millisecond), poll.WithTimeout(time.Second))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Check(t, is.DeepEqual(events, []ProgressEvent{
+		{ID: "pull", Message: "Pulling", Current: 1, Total: 2},
+		{ID: "pull", Message: "Pulling", Done: true},
+	}))
+}
+
+func TestHandleProgressIgnoresMalformedEvents(t *testing.T) {
+	server, client := net.Pipe()
+	var events []ProgressEvent
+	done := make(chan struct{})
+	go func() {
+		HandleProgress(func(ev ProgressEvent) { events = append(events, ev) })(server)
+		close(done)
+	}()
+
+	_, err := client.Write([]byte("not json\n{\"message\":\"no id\"}\n{\"id\":\"a\",\"error\":\"boom\"}\n"))
+	assert.NilError(t, err)
+	assert.NilError(t, client.Close())
+	<-done
+
+	assert.Check(t, is.DeepEqual(events, []ProgressEvent{{ID: "a", Error: "boom"}}))
+}
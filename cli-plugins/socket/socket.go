@@ -138,7 +138,8 @@ func randomID() string {
 
 // ConnectAndWait connects to the socket passed via well-known env var,
 // if present, and attempts to read from it until it receives an EOF, at which
-// point cb is called.
+// point cb is called. The connection is also used to send progress events to
+// the host CLI through [ReportProgress].
 func ConnectAndWait(cb func()) {
 	socketAddr, ok := os.LookupEnv(EnvKey)
 	if !ok {
@@ -155,12 +156,14 @@ func ConnectAndWait(cb func()) {
 	if err != nil {
 		return
 	}
+	setHostConn(conn)
 
 	go func() {
 		b := make([]byte, 1)
 		for {
 			_, err := conn.Read(b)
 			if errors.Is(err, io.EOF) {
+				setHostConn(nil)
 				cb()
 				return
 			}
@@ -381,7 +381,7 @@ func allManagementSubCommands(cmd *cobra.Command) []*cobra.Command {
 	cmds := []*cobra.Command{}
 	for _, sub := range cmd.Commands() {
 		if isPlugin(sub) {
-			if invalidPluginReason(sub) == "" {
+			if invalidPluginReason(sub) == "" && !sub.Hidden {
 				cmds = append(cmds, sub)
 			}
 			continue
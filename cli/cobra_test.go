@@ -78,6 +78,15 @@ func TestInvalidPlugin(t *testing.T) {
 	assert.DeepEqual(t, invalidPlugins(root), []*cobra.Command{sub1}, cmpopts.IgnoreUnexported(cobra.Command{}))
 }
 
+func TestHiddenPlugin(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	visible := &cobra.Command{Use: "visible", Annotations: map[string]string{metadata.CommandAnnotationPlugin: "true"}}
+	hidden := &cobra.Command{Use: "hidden", Hidden: true, Annotations: map[string]string{metadata.CommandAnnotationPlugin: "true"}}
+	root.AddCommand(visible, hidden)
+
+	assert.DeepEqual(t, managementSubCommands(root), []*cobra.Command{visible}, cmpopts.IgnoreUnexported(cobra.Command{}))
+}
+
 func TestCommandAliases(t *testing.T) {
 	root := &cobra.Command{Use: "root"}
 	sub := &cobra.Command{Use: "subcommand", Aliases: []string{"alias1", "alias2"}}
@@ -6,6 +6,7 @@
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/network"
@@ -44,6 +45,7 @@ type fakeClient struct {
 	containerRenameFunc     func(ctx context.Context, oldName, newName string) error
 	containerCommitFunc     func(ctx context.Context, container string, options container.CommitOptions) (container.CommitResponse, error)
 	containerPauseFunc      func(ctx context.Context, container string) error
+	eventsFunc              func(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error)
 	Version                 string
 }
 
@@ -54,6 +56,13 @@ func (f *fakeClient) ContainerList(_ context.Context, options container.ListOpti
 	return []container.Summary{}, nil
 }
 
+func (f *fakeClient) Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error) {
+	if f.eventsFunc != nil {
+		return f.eventsFunc(ctx, options)
+	}
+	return make(chan events.Message), make(chan error)
+}
+
 func (f *fakeClient) ContainerInspect(_ context.Context, containerID string) (container.InspectResponse, error) {
 	if f.inspectFunc != nil {
 		return f.inspectFunc(containerID)
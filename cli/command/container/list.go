@@ -1,8 +1,12 @@
 package container
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
@@ -12,7 +16,10 @@
 	"github.com/docker/cli/opts"
 	"github.com/docker/cli/templates"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -26,8 +33,16 @@ type psOptions struct {
 	last        int
 	format      string
 	filter      opts.FilterOpt
+
+	watch         bool
+	watchInterval time.Duration
 }
 
+// watchDebounce is the time to wait after a container event before refreshing
+// the list of containers in watch mode, so that a burst of events, such as
+// the events of a container that is recreated, results in a single refresh.
+const watchDebounce = 100 * time.Millisecond
+
 // NewPsCommand creates a new cobra.Command for `docker ps`
 func NewPsCommand(dockerCLI command.Cli) *cobra.Command {
 	options := psOptions{filter: opts.NewFilterOpt()}
@@ -57,6 +72,8 @@ func NewPsCommand(dockerCLI command.Cli) *cobra.Command {
 	flags.IntVarP(&options.last, "last", "n", -1, "Show n last created containers (includes all states)")
 	flags.StringVar(&options.format, "format", "", flagsHelper.FormatHelp)
 	flags.VarP(&options.filter, "filter", "f", "Filter output based on conditions provided")
+	flags.BoolVarP(&options.watch, "watch", "w", false, "Watch for changes and refresh the list")
+	flags.DurationVar(&options.watchInterval, "watch-interval", 2*time.Second, "Interval to refresh the list in watch mode, in addition to refreshing on container events")
 
 	return cmd
 }
@@ -126,6 +143,12 @@ func runPs(ctx context.Context, dockerCLI command.Cli, options *psOptions) error
 	if err != nil {
 		return err
 	}
+	if options.watch {
+		if options.watchInterval <= 0 {
+			return errors.New("--watch-interval must be a positive duration")
+		}
+		return watchPs(ctx, dockerCLI, options, listOptions)
+	}
 
 	containers, err := dockerCLI.Client().ContainerList(ctx, *listOptions)
 	if err != nil {
@@ -139,3 +162,96 @@ func runPs(ctx context.Context, dockerCLI command.Cli, options *psOptions) error
 	}
 	return formatter.ContainerWrite(containerCtx, containers)
 }
+
+// watchPs prints the list of containers, and refreshes it when a container
+// event is received, and every watchInterval, until ctx is cancelled. If the
+// daemon does not send events, the list is only refreshed every interval. On
+// a terminal, the list is redrawn in place; otherwise, the complete list is
+// printed on each refresh, as it is for custom formats and --quiet.
+func watchPs(ctx context.Context, dockerCLI command.Cli, options *psOptions, listOptions *container.ListOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	apiClient := dockerCLI.Client()
+	// The filters of the list cannot be used to filter events, as most of
+	// them are not valid for events; refresh on all container events instead.
+	eventChan, errChan := apiClient.Events(ctx, events.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("type", string(events.ContainerEventType))),
+	})
+
+	ticker := time.NewTicker(options.watchInterval)
+	defer ticker.Stop()
+
+	var buf bytes.Buffer
+	containerCtx := formatter.Context{
+		Output: &buf,
+		Format: formatter.NewContainerFormat(options.format, options.quiet, listOptions.Size),
+		Trunc:  !options.noTrunc,
+	}
+
+	// Only tables are redrawn in place; custom formats and the IDs that are
+	// printed with --quiet are printed in full, so that they can be parsed.
+	inPlace := dockerCLI.Out().IsTerminal() && containerCtx.Format.IsTable()
+	if inPlace {
+		// Clear the screen before the first draw, as "docker stats" does.
+		_, _ = fmt.Fprint(&buf, "\033[2J")
+	}
+
+	var refresh <-chan time.Time
+	for {
+		containers, err := apiClient.ContainerList(ctx, *listOptions)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if inPlace {
+			// Move the cursor to the top-left, and overwrite the previous
+			// list, as "docker stats" does.
+			_, _ = fmt.Fprint(&buf, "\033[H")
+		}
+		if err := formatter.ContainerWrite(containerCtx, containers); err != nil {
+			return err
+		}
+		if inPlace {
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			buf.Reset()
+			for _, line := range lines {
+				// Clear the remainder of lines that were longer before.
+				_, _ = fmt.Fprintln(&buf, line+"\033[K")
+			}
+			// Clear the lines of containers that are no longer listed.
+			_, _ = fmt.Fprint(&buf, "\033[J")
+		} else {
+			_, _ = fmt.Fprintln(&buf)
+		}
+		_, _ = dockerCLI.Out().Write(buf.Bytes())
+		buf.Reset()
+
+		refresh = nil
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-eventChan:
+				if refresh == nil {
+					refresh = time.After(watchDebounce)
+				}
+			case err := <-errChan:
+				if ctx.Err() != nil {
+					return nil
+				}
+				// Keep refreshing on the interval if the daemon does not
+				// support events, or stopped sending them.
+				logrus.WithError(err).Debug("Failed to receive container events; refreshing the list on the watch interval only")
+				eventChan, errChan = nil, nil
+			case <-refresh:
+				break wait
+			case <-ticker.C:
+				break wait
+			}
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package container
 
 import (
+	"context"
 	"errors"
 	"io"
 	"testing"
@@ -10,6 +11,7 @@
 	"github.com/docker/cli/internal/test/builders"
 	"github.com/docker/cli/opts"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 	"gotest.tools/v3/golden"
@@ -351,3 +353,124 @@ func TestContainerListWithFormat(t *testing.T) {
 		golden.Assert(t, cli.OutBuffer().String(), "container-list-quiet.golden")
 	})
 }
+
+func TestContainerListWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	eventC := make(chan events.Message, 1)
+	var calls int
+	cli := test.NewFakeCli(&fakeClient{
+		eventsFunc: func(_ context.Context, options events.ListOptions) (<-chan events.Message, <-chan error) {
+			assert.Check(t, is.DeepEqual(options.Filters.Get("type"), []string{"container"}))
+			return eventC, make(chan error)
+		},
+		containerListFunc: func(container.ListOptions) ([]container.Summary, error) {
+			calls++
+			if calls == 1 {
+				eventC <- events.Message{Type: events.ContainerEventType, Action: events.ActionStart}
+				return []container.Summary{*builders.Container("c1")}, nil
+			}
+			cancel()
+			return []container.Summary{*builders.Container("c1"), *builders.Container("c2")}, nil
+		},
+	})
+	cmd := newListCommand(cli)
+	// Only refresh on events.
+	cmd.SetArgs([]string{"--watch", "--watch-interval", "1h", "--format", "{{.Names}}"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.NilError(t, cmd.ExecuteContext(ctx))
+	assert.Check(t, is.Equal(calls, 2))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "c1\n\nc1\nc2\n\n"))
+}
+
+func TestContainerListWatchTerminal(t *testing.T) {
+	testCases := []struct {
+		doc      string
+		args     []string
+		expected string
+	}{
+		{
+			doc:  "table",
+			args: []string{"--format", "table {{.Names}}"},
+			expected: "\033[2J\033[HNAMES\033[K\nc1\033[K\n\033[J" +
+				"\033[HNAMES\033[K\nc1\033[K\nc2\033[K\n\033[J",
+		},
+		{
+			doc:      "custom format",
+			args:     []string{"--format", "{{.Names}}"},
+			expected: "c1\n\nc1\nc2\n\n",
+		},
+		{
+			doc:      "quiet",
+			args:     []string{"--quiet"},
+			expected: "container_id\n\ncontainer_id\ncontainer_id\n\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			eventC := make(chan events.Message, 1)
+			var calls int
+			cli := test.NewFakeCli(&fakeClient{
+				eventsFunc: func(context.Context, events.ListOptions) (<-chan events.Message, <-chan error) {
+					return eventC, make(chan error)
+				},
+				containerListFunc: func(container.ListOptions) ([]container.Summary, error) {
+					calls++
+					if calls == 1 {
+						eventC <- events.Message{Type: events.ContainerEventType, Action: events.ActionStart}
+						return []container.Summary{*builders.Container("c1")}, nil
+					}
+					cancel()
+					return []container.Summary{*builders.Container("c1"), *builders.Container("c2")}, nil
+				},
+			})
+			cli.Out().SetIsTerminal(true)
+			cmd := newListCommand(cli)
+			cmd.SetArgs(append([]string{"--watch", "--watch-interval", "1h"}, tc.args...))
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.NilError(t, cmd.ExecuteContext(ctx))
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), tc.expected))
+		})
+	}
+}
+
+func TestContainerListWatchWithoutEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls int
+	cli := test.NewFakeCli(&fakeClient{
+		eventsFunc: func(context.Context, events.ListOptions) (<-chan events.Message, <-chan error) {
+			errC := make(chan error, 1)
+			errC <- errors.New("events are not supported")
+			return nil, errC
+		},
+		containerListFunc: func(container.ListOptions) ([]container.Summary, error) {
+			calls++
+			if calls == 3 {
+				cancel()
+			}
+			return []container.Summary{*builders.Container("c1")}, nil
+		},
+	})
+	cmd := newListCommand(cli)
+	cmd.SetArgs([]string{"--watch", "--watch-interval", "10ms", "--format", "{{.Names}}"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.NilError(t, cmd.ExecuteContext(ctx))
+	assert.Check(t, is.Equal(calls, 3))
+}
+
+func TestContainerListWatchInvalidInterval(t *testing.T) {
+	cmd := newListCommand(test.NewFakeCli(&fakeClient{}))
+	cmd.SetArgs([]string{"--watch", "--watch-interval", "0s"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.ErrorContains(t, cmd.Execute(), "--watch-interval must be a positive duration")
+}
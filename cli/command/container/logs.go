@@ -1,15 +1,24 @@
 package container
 
 import (
+	"bytes"
 	"context"
 	"io"
+	"strings"
+	"sync"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/cli/internal/tui"
+	"github.com/docker/cli/opts"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/morikuni/aec"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 )
 
 type logsOptions struct {
@@ -19,21 +28,43 @@ type logsOptions struct {
 	timestamps bool
 	details    bool
 	tail       string
+	filter     opts.FilterOpt
 
-	container string
+	containers []string
+}
+
+// logPrefixColors are the colors of the prefixes of the log lines of each
+// container when fetching the logs of multiple containers, which are used
+// in turn.
+var logPrefixColors = []aec.ANSI{
+	aec.CyanF,
+	aec.YellowF,
+	aec.GreenF,
+	aec.MagentaF,
+	aec.BlueF,
+	aec.LightCyanF,
+	aec.LightYellowF,
+	aec.LightGreenF,
+	aec.LightMagentaF,
+	aec.LightBlueF,
 }
 
 // NewLogsCommand creates a new cobra.Command for `docker logs`
 func NewLogsCommand(dockerCli command.Cli) *cobra.Command {
-	var opts logsOptions
+	options := logsOptions{filter: opts.NewFilterOpt()}
 
 	cmd := &cobra.Command{
-		Use:   "logs [OPTIONS] CONTAINER",
+		Use:   "logs [OPTIONS] CONTAINER [CONTAINER...]",
 		Short: "Fetch the logs of a container",
-		Args:  cli.ExactArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("filter") {
+				return nil
+			}
+			return cli.RequiresMinArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			opts.container = args[0]
-			return runLogs(cmd.Context(), dockerCli, &opts)
+			options.containers = args
+			return runLogs(cmd.Context(), dockerCli, &options)
 		},
 		Annotations: map[string]string{
 			"aliases": "docker container logs, docker logs",
@@ -42,22 +73,115 @@ func NewLogsCommand(dockerCli command.Cli) *cobra.Command {
 	}
 
 	flags := cmd.Flags()
-	flags.BoolVarP(&opts.follow, "follow", "f", false, "Follow log output")
-	flags.StringVar(&opts.since, "since", "", `Show logs since timestamp (e.g. "2013-01-02T13:23:37Z") or relative (e.g. "42m" for 42 minutes)`)
-	flags.StringVar(&opts.until, "until", "", `Show logs before a timestamp (e.g. "2013-01-02T13:23:37Z") or relative (e.g. "42m" for 42 minutes)`)
+	flags.BoolVarP(&options.follow, "follow", "f", false, "Follow log output")
+	flags.StringVar(&options.since, "since", "", `Show logs since timestamp (e.g. "2013-01-02T13:23:37Z") or relative (e.g. "42m" for 42 minutes)`)
+	flags.StringVar(&options.until, "until", "", `Show logs before a timestamp (e.g. "2013-01-02T13:23:37Z") or relative (e.g. "42m" for 42 minutes)`)
 	flags.SetAnnotation("until", "version", []string{"1.35"})
-	flags.BoolVarP(&opts.timestamps, "timestamps", "t", false, "Show timestamps")
-	flags.BoolVar(&opts.details, "details", false, "Show extra details provided to logs")
-	flags.StringVarP(&opts.tail, "tail", "n", "all", "Number of lines to show from the end of the logs")
+	flags.BoolVarP(&options.timestamps, "timestamps", "t", false, "Show timestamps")
+	flags.BoolVar(&options.details, "details", false, "Show extra details provided to logs")
+	flags.StringVarP(&options.tail, "tail", "n", "all", "Number of lines to show from the end of the logs")
+	flags.Var(&options.filter, "filter", "Fetch the logs of the containers that match the filter, as for \"docker ps --all\"")
 	return cmd
 }
 
 func runLogs(ctx context.Context, dockerCli command.Cli, opts *logsOptions) error {
-	c, err := dockerCli.Client().ContainerInspect(ctx, opts.container)
+	if len(opts.containers) == 1 && opts.filter.Value().Len() == 0 {
+		c, err := dockerCli.Client().ContainerInspect(ctx, opts.containers[0])
+		if err != nil {
+			return err
+		}
+		return copyContainerLogs(ctx, dockerCli, opts, c, dockerCli.Out(), dockerCli.Err())
+	}
+
+	containers, err := resolveLogsContainers(ctx, dockerCli, opts)
 	if err != nil {
 		return err
 	}
 
+	// Align the log lines of all containers by padding the prefixes to the
+	// length of the longest name.
+	var width int
+	for _, c := range containers {
+		if n := len(logsContainerName(c)); n > width {
+			width = n
+		}
+	}
+
+	// Each stream is colored based on whether it is a terminal, because
+	// the output of either stream can be redirected.
+	var mu sync.Mutex
+	outColors, errColors := tui.NewOutput(dockerCli.Out()), tui.NewOutput(dockerCli.Err())
+	eg, egCtx := errgroup.WithContext(ctx)
+	for i, c := range containers {
+		c := c
+		name := logsContainerName(c)
+		color := logPrefixColors[i%len(logPrefixColors)]
+		prefix := name + strings.Repeat(" ", width-len(name)) + " | "
+		stdout := &logPrefixWriter{w: dockerCli.Out(), mu: &mu, prefix: []byte(outColors.Color(color).Apply(prefix))}
+		stderr := &logPrefixWriter{w: dockerCli.Err(), mu: &mu, prefix: []byte(errColors.Color(color).Apply(prefix))}
+		eg.Go(func() error {
+			err := copyContainerLogs(egCtx, dockerCli, opts, c, stdout, stderr)
+			// Print the last line, if it was not terminated with a newline.
+			if flushErr := stdout.flush(); err == nil {
+				err = flushErr
+			}
+			if flushErr := stderr.flush(); err == nil {
+				err = flushErr
+			}
+			if err != nil {
+				return errors.Wrapf(err, "failed to fetch the logs of container %s", name)
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+// resolveLogsContainers returns the containers with the names or IDs that are
+// passed as arguments, followed by the containers that match the filter, if
+// any, that are not passed as argument.
+func resolveLogsContainers(ctx context.Context, dockerCli command.Cli, opts *logsOptions) ([]container.InspectResponse, error) {
+	apiClient := dockerCli.Client()
+	containers := make([]container.InspectResponse, 0, len(opts.containers))
+	seen := make(map[string]struct{})
+	for _, name := range opts.containers {
+		c, err := apiClient.ContainerInspect(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[c.ID]; ok {
+			continue
+		}
+		seen[c.ID] = struct{}{}
+		containers = append(containers, c)
+	}
+
+	if opts.filter.Value().Len() > 0 {
+		list, err := apiClient.ContainerList(ctx, container.ListOptions{All: true, Filters: opts.filter.Value()})
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range list {
+			if _, ok := seen[s.ID]; ok {
+				continue
+			}
+			c, err := apiClient.ContainerInspect(ctx, s.ID)
+			if err != nil {
+				return nil, err
+			}
+			seen[c.ID] = struct{}{}
+			containers = append(containers, c)
+		}
+	}
+	if len(containers) == 0 {
+		return nil, errors.New("no containers match the filter")
+	}
+	return containers, nil
+}
+
+// copyContainerLogs copies the logs of container c to stdout and stderr. The
+// logs of containers with a TTY are copied to stdout.
+func copyContainerLogs(ctx context.Context, dockerCli command.Cli, opts *logsOptions, c container.InspectResponse, stdout, stderr io.Writer) error {
 	responseBody, err := dockerCli.Client().ContainerLogs(ctx, c.ID, container.LogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
@@ -73,10 +197,65 @@ func runLogs(ctx context.Context, dockerCli command.Cli, opts *logsOptions) erro
 	}
 	defer responseBody.Close()
 
-	if c.Config.Tty {
-		_, err = io.Copy(dockerCli.Out(), responseBody)
+	if c.Config != nil && c.Config.Tty {
+		_, err = io.Copy(stdout, responseBody)
 	} else {
-		_, err = stdcopy.StdCopy(dockerCli.Out(), dockerCli.Err(), responseBody)
+		_, err = stdcopy.StdCopy(stdout, stderr, responseBody)
+	}
+	return err
+}
+
+func logsContainerName(c container.InspectResponse) string {
+	if c.ContainerJSONBase != nil && c.Name != "" {
+		return strings.TrimPrefix(c.Name, "/")
+	}
+	return stringid.TruncateID(c.ID)
+}
+
+// logPrefixWriter prefixes each line that is written to it, and writes
+// complete lines only, so that the lines of multiple containers that are
+// written to the same stream are not mixed.
+type logPrefixWriter struct {
+	w      io.Writer
+	mu     *sync.Mutex // shared by the writers of all containers
+	prefix []byte
+	buf    []byte
+}
+
+// Write implements io.Writer.
+func (p *logPrefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	i := bytes.LastIndexByte(p.buf, '\n')
+	if i < 0 {
+		return len(b), nil
+	}
+	if err := p.writeLines(p.buf[:i+1]); err != nil {
+		return 0, err
+	}
+	p.buf = p.buf[:copy(p.buf, p.buf[i+1:])]
+	return len(b), nil
+}
+
+// flush writes the remaining partial line, if any, terminated by a newline.
+func (p *logPrefixWriter) flush() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+	err := p.writeLines(append(p.buf, '\n'))
+	p.buf = p.buf[:0]
+	return err
+}
+
+func (p *logPrefixWriter) writeLines(lines []byte) error {
+	out := make([]byte, 0, len(lines)+len(p.prefix)*bytes.Count(lines, []byte{'\n'}))
+	for len(lines) > 0 {
+		i := bytes.IndexByte(lines, '\n')
+		out = append(out, p.prefix...)
+		out = append(out, lines[:i+1]...)
+		lines = lines[i+1:]
 	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := p.w.Write(out)
 	return err
 }
@@ -1,13 +1,18 @@
 package container
 
 import (
+	"bytes"
 	"context"
 	"io"
+	"sort"
 	"strings"
 	"testing"
 
 	"github.com/docker/cli/internal/test"
+	"github.com/docker/cli/opts"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/morikuni/aec"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 )
@@ -37,7 +42,7 @@ func TestRunLogs(t *testing.T) {
 		{
 			doc:         "successful logs",
 			expectedOut: "foo",
-			options:     &logsOptions{},
+			options:     &logsOptions{containers: []string{"container-id"}},
 			client:      &fakeClient{logFunc: logFn("foo"), inspectFunc: inspectFn},
 		},
 	}
@@ -57,3 +62,112 @@ func TestRunLogs(t *testing.T) {
 		})
 	}
 }
+
+func TestRunLogsMultipleContainers(t *testing.T) {
+	inspectFn := func(containerID string) (container.InspectResponse, error) {
+		name := "/" + strings.TrimPrefix(containerID, "id-")
+		return container.InspectResponse{
+			Config:            &container.Config{Tty: true},
+			ContainerJSONBase: &container.ContainerJSONBase{ID: "id-" + strings.TrimPrefix(name, "/"), Name: name},
+		}, nil
+	}
+	logsFn := func(containerID string, _ container.LogsOptions) (io.ReadCloser, error) {
+		// The last line of the logs of "web" is not terminated with a newline.
+		if containerID == "id-web" {
+			return io.NopCloser(strings.NewReader("hello\nworld")), nil
+		}
+		return io.NopCloser(strings.NewReader("ready\n")), nil
+	}
+
+	t.Run("arguments", func(t *testing.T) {
+		cli := test.NewFakeCli(&fakeClient{logFunc: logsFn, inspectFunc: inspectFn})
+		err := runLogs(context.TODO(), cli, &logsOptions{containers: []string{"web", "database", "web"}})
+		assert.NilError(t, err)
+
+		lines := strings.Split(strings.TrimSuffix(cli.OutBuffer().String(), "\n"), "\n")
+		sort.Strings(lines)
+		assert.Check(t, is.DeepEqual(lines, []string{
+			"database | ready",
+			"web      | hello",
+			"web      | world",
+		}))
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		var listOpts container.ListOptions
+		cli := test.NewFakeCli(&fakeClient{
+			logFunc:     logsFn,
+			inspectFunc: inspectFn,
+			containerListFunc: func(options container.ListOptions) ([]container.Summary, error) {
+				listOpts = options
+				return []container.Summary{{ID: "id-web"}, {ID: "id-database"}}, nil
+			},
+		})
+		filter := opts.NewFilterOpt()
+		assert.NilError(t, filter.Set("label=com.example.app=shop"))
+		err := runLogs(context.TODO(), cli, &logsOptions{containers: []string{"web"}, filter: filter})
+		assert.NilError(t, err)
+		assert.Check(t, listOpts.All)
+		assert.Check(t, is.DeepEqual(listOpts.Filters.Get("label"), []string{"com.example.app=shop"}))
+
+		lines := strings.Split(strings.TrimSuffix(cli.OutBuffer().String(), "\n"), "\n")
+		sort.Strings(lines)
+		assert.Check(t, is.DeepEqual(lines, []string{
+			"database | ready",
+			"web      | hello",
+			"web      | world",
+		}))
+	})
+
+	t.Run("colors", func(t *testing.T) {
+		cli := test.NewFakeCli(&fakeClient{
+			inspectFunc: func(containerID string) (container.InspectResponse, error) {
+				return container.InspectResponse{
+					Config:            &container.Config{},
+					ContainerJSONBase: &container.ContainerJSONBase{ID: containerID, Name: "/" + containerID},
+				}, nil
+			},
+			logFunc: func(string, container.LogsOptions) (io.ReadCloser, error) {
+				var buf bytes.Buffer
+				_, _ = stdcopy.NewStdWriter(&buf, stdcopy.Stdout).Write([]byte("out\n"))
+				_, _ = stdcopy.NewStdWriter(&buf, stdcopy.Stderr).Write([]byte("err\n"))
+				return io.NopCloser(&buf), nil
+			},
+		})
+		// Only stdout is a terminal, so only the prefixes on stdout are
+		// colored.
+		cli.Out().SetIsTerminal(true)
+		err := runLogs(context.TODO(), cli, &logsOptions{containers: []string{"web", "db"}})
+		assert.NilError(t, err)
+
+		lines := strings.Split(strings.TrimSuffix(cli.OutBuffer().String(), "\n"), "\n")
+		sort.Strings(lines)
+		assert.Check(t, is.DeepEqual(lines, []string{
+			aec.YellowF.Apply("db  | ") + "out",
+			aec.CyanF.Apply("web | ") + "out",
+		}))
+		lines = strings.Split(strings.TrimSuffix(cli.ErrBuffer().String(), "\n"), "\n")
+		sort.Strings(lines)
+		assert.Check(t, is.DeepEqual(lines, []string{"db  | err", "web | err"}))
+	})
+
+	t.Run("no matching containers", func(t *testing.T) {
+		cli := test.NewFakeCli(&fakeClient{
+			containerListFunc: func(container.ListOptions) ([]container.Summary, error) {
+				return nil, nil
+			},
+		})
+		filter := opts.NewFilterOpt()
+		assert.NilError(t, filter.Set("label=com.example.app=shop"))
+		err := runLogs(context.TODO(), cli, &logsOptions{filter: filter})
+		assert.Check(t, is.Error(err, "no containers match the filter"))
+	})
+}
+
+func TestNewLogsCommandArgs(t *testing.T) {
+	cmd := NewLogsCommand(test.NewFakeCli(&fakeClient{}))
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.ErrorContains(t, cmd.Execute(), "requires at least 1 argument")
+}
@@ -51,6 +51,9 @@ type Context struct {
 	Format Format
 	// Trunc when set to true will truncate the output of certain fields such as Container ID.
 	Trunc bool
+	// Funcs are additional functions to make available to the template,
+	// on top of the basic template functions.
+	Funcs template.FuncMap
 
 	// internal element
 	finalFormat string
@@ -74,7 +77,7 @@ func (c *Context) preFormat() {
 }
 
 func (c *Context) parseFormat() (*template.Template, error) {
-	tmpl, err := templates.Parse(c.finalFormat)
+	tmpl, err := templates.New("").Funcs(c.Funcs).Parse(c.finalFormat)
 	if err != nil {
 		return nil, errors.Wrap(err, "template parsing error")
 	}
@@ -0,0 +1,264 @@
+package formatter
+
+import (
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli-plugins/manager"
+	units "github.com/docker/go-units"
+)
+
+const (
+	defaultPluginQuietFormat = "{{.Name}}"
+	defaultPluginTableFormat = "table {{.Name}}\t{{.Version}}\t{{.Vendor}}\t{{.Description}}"
+
+	pluginVersionHeader = "VERSION"
+	pluginVendorHeader  = "VENDOR"
+	pluginPathHeader    = "PATH"
+	shadowedPathsHeader = "SHADOWED PATHS"
+	lastUsedHeader      = "LAST USED"
+	latencyHeader       = "LATENCY"
+	upgradeHeader       = "UPGRADE"
+	capabilitiesHeader  = "CAPABILITIES"
+
+	defaultPluginProbeTableFormat = "table {{.Name}}\t{{.Status}}\t{{.Latency}}\t{{.Error}}"
+
+	probeStatusPass = "pass"
+	probeStatusFail = "fail"
+)
+
+// PluginFormatOptions are the options for rendering CLI plugins with
+// [NewPluginFormatWithOptions] and [PluginWriteWithOptions].
+type PluginFormatOptions struct {
+	Quiet bool
+
+	// Usage is the usage of the plugins, as returned by [manager.PluginUsage].
+	// If set, the default formats include when the plugin was last used.
+	Usage map[string]manager.PluginUsageStats
+
+	// Upgrades are the available upgrades of the plugins, as returned by
+	// [manager.CheckUpgrades]. If set, the default formats include the newer
+	// version of the plugin that is available, if any.
+	Upgrades map[string]string
+}
+
+// NewPluginFormat returns a Format for rendering CLI plugins using a Context.
+func NewPluginFormat(source string, quiet bool) Format {
+	return NewPluginFormatWithOptions(source, PluginFormatOptions{Quiet: quiet})
+}
+
+// NewPluginFormatWithOptions returns a Format for rendering CLI plugins
+// using a Context.
+func NewPluginFormatWithOptions(source string, opts PluginFormatOptions) Format {
+	switch source {
+	case TableFormatKey:
+		if opts.Quiet {
+			return defaultPluginQuietFormat
+		}
+		format := defaultPluginTableFormat
+		if opts.Usage != nil {
+			format += `\t{{.LastUsed}}`
+		}
+		if opts.Upgrades != nil {
+			format += `\t{{.Upgrade}}`
+		}
+		return Format(format)
+	case RawFormatKey:
+		if opts.Quiet {
+			return `name: {{.Name}}`
+		}
+		format := `name: {{.Name}}\nversion: {{.Version}}\nvendor: {{.Vendor}}\ndescription: {{.Description}}\npath: {{.Path}}\n`
+		if opts.Usage != nil {
+			format += `last_used: {{.LastUsed}}\n`
+		}
+		if opts.Upgrades != nil {
+			format += `upgrade: {{.Upgrade}}\n`
+		}
+		return Format(format)
+	}
+	return Format(source)
+}
+
+// PluginWrite writes formatted CLI plugins using the Context.
+func PluginWrite(ctx Context, plugins []manager.Plugin) error {
+	return PluginWriteWithOptions(ctx, plugins, PluginFormatOptions{})
+}
+
+// PluginWriteWithOptions writes formatted CLI plugins using the Context,
+// including their usage and available upgrades, if set in opts.
+func PluginWriteWithOptions(ctx Context, plugins []manager.Plugin, opts PluginFormatOptions) error {
+	render := func(format func(subContext SubContext) error) error {
+		for _, p := range plugins {
+			if err := format(&pluginContext{p: p, usage: opts.Usage[p.Name], upgrade: opts.Upgrades[p.Name]}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return ctx.Write(newPluginContext(), render)
+}
+
+type pluginContext struct {
+	HeaderContext
+	p       manager.Plugin
+	usage   manager.PluginUsageStats
+	upgrade string
+}
+
+func newPluginContext() *pluginContext {
+	pluginCtx := pluginContext{}
+	pluginCtx.Header = SubHeaderContext{
+		"Name":          NameHeader,
+		"Version":       pluginVersionHeader,
+		"Vendor":        pluginVendorHeader,
+		"Description":   DescriptionHeader,
+		"Path":          pluginPathHeader,
+		"ShadowedPaths": shadowedPathsHeader,
+		"Error":         ErrorHeader,
+		"LastUsed":      lastUsedHeader,
+		"Upgrade":       upgradeHeader,
+		"Capabilities":  capabilitiesHeader,
+	}
+	return &pluginCtx
+}
+
+func (c *pluginContext) MarshalJSON() ([]byte, error) {
+	return MarshalJSON(c)
+}
+
+func (c *pluginContext) Name() string {
+	return c.p.Name
+}
+
+func (c *pluginContext) Version() string {
+	return c.p.Version
+}
+
+func (c *pluginContext) Vendor() string {
+	return c.p.Vendor
+}
+
+func (c *pluginContext) Description() string {
+	return c.p.ShortDescription
+}
+
+func (c *pluginContext) Path() string {
+	return c.p.Path
+}
+
+func (c *pluginContext) ShadowedPaths() string {
+	return strings.Join(c.p.ShadowedPaths, ", ")
+}
+
+// Capabilities returns the comma-separated list of capabilities declared
+// by the plugin.
+func (c *pluginContext) Capabilities() string {
+	return strings.Join(c.p.Capabilities, ", ")
+}
+
+// Error returns the error (if any) that made the plugin invalid.
+func (c *pluginContext) Error() string {
+	if c.p.Err == nil {
+		return ""
+	}
+	return c.p.Err.Error()
+}
+
+// LastUsed returns when the plugin was last used, relative to now. It is
+// empty if no usage was recorded for the plugin.
+func (c *pluginContext) LastUsed() string {
+	if c.usage.LastUsed.IsZero() {
+		return ""
+	}
+	return units.HumanDuration(time.Now().UTC().Sub(c.usage.LastUsed)) + " ago"
+}
+
+// Upgrade returns the newer version of the plugin that is available, or
+// "unknown" if it could not be determined. It is empty if the plugin is
+// up to date.
+func (c *pluginContext) Upgrade() string {
+	return c.upgrade
+}
+
+// NewPluginProbeFormat returns a Format for rendering the results of
+// probing CLI plugins using a Context.
+func NewPluginProbeFormat(source string, quiet bool) Format {
+	switch source {
+	case TableFormatKey:
+		if quiet {
+			return defaultPluginQuietFormat
+		}
+		return defaultPluginProbeTableFormat
+	case RawFormatKey:
+		if quiet {
+			return `name: {{.Name}}`
+		}
+		return `name: {{.Name}}\nstatus: {{.Status}}\nlatency: {{.Latency}}\nerror: {{.Error}}\npath: {{.Path}}\n`
+	}
+	return Format(source)
+}
+
+// PluginProbeWrite writes the results of probing CLI plugins, as returned
+// by [manager.ProbePlugins], using the Context.
+func PluginProbeWrite(ctx Context, results []manager.ProbeResult) error {
+	render := func(format func(subContext SubContext) error) error {
+		for _, r := range results {
+			if err := format(&pluginProbeContext{r: r}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return ctx.Write(newPluginProbeContext(), render)
+}
+
+type pluginProbeContext struct {
+	HeaderContext
+	r manager.ProbeResult
+}
+
+func newPluginProbeContext() *pluginProbeContext {
+	probeCtx := pluginProbeContext{}
+	probeCtx.Header = SubHeaderContext{
+		"Name":    NameHeader,
+		"Status":  StatusHeader,
+		"Latency": latencyHeader,
+		"Error":   ErrorHeader,
+		"Path":    pluginPathHeader,
+	}
+	return &probeCtx
+}
+
+func (c *pluginProbeContext) MarshalJSON() ([]byte, error) {
+	return MarshalJSON(c)
+}
+
+func (c *pluginProbeContext) Name() string {
+	return c.r.Name
+}
+
+// Status returns "pass" if the plugin returned valid metadata, and "fail"
+// otherwise.
+func (c *pluginProbeContext) Status() string {
+	if c.r.Err != nil {
+		return probeStatusFail
+	}
+	return probeStatusPass
+}
+
+// Latency returns the time it took to fetch the metadata of the plugin.
+func (c *pluginProbeContext) Latency() string {
+	return c.r.Duration.Round(time.Millisecond).String()
+}
+
+// Error returns the reason the probe failed, if any.
+func (c *pluginProbeContext) Error() string {
+	if c.r.Err == nil {
+		return ""
+	}
+	return c.r.Err.Error()
+}
+
+func (c *pluginProbeContext) Path() string {
+	return c.r.Path
+}
@@ -0,0 +1,161 @@
+// FIXME(thaJeztah): remove once we are a module; the go:build directive prevents go from downgrading language version to go1.16:
+//go:build go1.23
+
+package formatter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/docker/cli/cli-plugins/manager"
+	"github.com/docker/cli/cli-plugins/metadata"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/golden"
+)
+
+func TestPluginContextWrite(t *testing.T) {
+	plugins := []manager.Plugin{
+		{
+			Name: "buildx",
+			Path: "/usr/libexec/docker/cli-plugins/docker-buildx",
+			Metadata: metadata.Metadata{
+				SchemaVersion:    "0.1.0",
+				Vendor:           "Docker Inc.",
+				Version:          "v0.20.0",
+				ShortDescription: "Docker Buildx",
+			},
+		},
+		{
+			Name: "compose",
+			Path: "/usr/libexec/docker/cli-plugins/docker-compose",
+			Metadata: metadata.Metadata{
+				SchemaVersion:    "0.1.0",
+				Vendor:           "Docker Inc.",
+				Version:          "v2.33.0",
+				ShortDescription: "Docker Compose",
+				Capabilities:     []string{"network", "filesystem"},
+			},
+		},
+		{
+			Name: "invalid",
+			Path: "/usr/libexec/docker/cli-plugins/docker-invalid",
+			Err:  manager.NewPluginError("plugin candidate %q did not match %q", "invalid", "^[a-z][a-z0-9]*$"),
+		},
+	}
+
+	usage := map[string]manager.PluginUsageStats{
+		"buildx": {LastUsed: time.Now().UTC().Add(-2 * time.Hour), Invocations: 3},
+	}
+	upgrades := map[string]string{
+		"buildx":  "v0.21.0",
+		"compose": "",
+		"invalid": manager.UpgradeUnknown,
+	}
+
+	cases := []struct {
+		context  Context
+		expected string
+	}{
+		{
+			context:  Context{Format: NewPluginFormat("table", false)},
+			expected: string(golden.Get(t, "plugin-context-write-table.golden")),
+		},
+		{
+			context:  Context{Format: NewPluginFormat("table", true)},
+			expected: "buildx\ncompose\ninvalid\n",
+		},
+		{
+			context:  Context{Format: NewPluginFormat("{{.Name}}", false)},
+			expected: string(golden.Get(t, "plugin-context-write-name.golden")),
+		},
+		{
+			context:  Context{Format: NewPluginFormat("raw", true)},
+			expected: "name: buildx\nname: compose\nname: invalid\n",
+		},
+		{
+			context:  Context{Format: NewPluginFormatWithOptions("table", PluginFormatOptions{Usage: usage})},
+			expected: string(golden.Get(t, "plugin-context-write-table-last-used.golden")),
+		},
+		{
+			context:  Context{Format: NewPluginFormatWithOptions("table", PluginFormatOptions{Upgrades: upgrades})},
+			expected: string(golden.Get(t, "plugin-context-write-table-upgrade.golden")),
+		},
+		{
+			context:  Context{Format: NewPluginFormat("{{.Name}}: {{.Capabilities}}", false)},
+			expected: "buildx: \ncompose: network, filesystem\ninvalid: \n",
+		},
+		{
+			context:  Context{Format: NewPluginFormat("{{.Name}}: {{.Error}}", false)},
+			expected: "buildx: \ncompose: \ninvalid: plugin candidate \"invalid\" did not match \"^[a-z][a-z0-9]*$\"\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.context.Format), func(t *testing.T) {
+			var out bytes.Buffer
+			tc.context.Output = &out
+			err := PluginWriteWithOptions(tc.context, plugins, PluginFormatOptions{Usage: usage, Upgrades: upgrades})
+			assert.NilError(t, err)
+			assert.Equal(t, out.String(), tc.expected)
+		})
+	}
+
+	t.Run("without options", func(t *testing.T) {
+		var out bytes.Buffer
+		ctx := Context{Format: NewPluginFormat("table", false), Output: &out}
+		assert.NilError(t, PluginWrite(ctx, plugins))
+		golden.Assert(t, out.String(), "plugin-context-write-table.golden")
+	})
+}
+
+func TestPluginProbeContextWrite(t *testing.T) {
+	results := []manager.ProbeResult{
+		{
+			Plugin: manager.Plugin{
+				Name:     "buildx",
+				Path:     "/usr/libexec/docker/cli-plugins/docker-buildx",
+				Metadata: metadata.Metadata{SchemaVersion: "0.1.0", Vendor: "Docker Inc."},
+			},
+			Duration: 12345 * time.Microsecond,
+		},
+		{
+			Plugin: manager.Plugin{
+				Name: "broken",
+				Path: "/usr/libexec/docker/cli-plugins/docker-broken",
+				Err:  manager.NewPluginError("failed to fetch metadata: permission denied"),
+			},
+			Duration: 2 * time.Millisecond,
+		},
+	}
+
+	cases := []struct {
+		context  Context
+		expected string
+	}{
+		{
+			context:  Context{Format: NewPluginProbeFormat("table", false)},
+			expected: string(golden.Get(t, "plugin-probe-context-write-table.golden")),
+		},
+		{
+			context:  Context{Format: NewPluginProbeFormat("table", true)},
+			expected: "buildx\nbroken\n",
+		},
+		{
+			context: Context{Format: NewPluginProbeFormat("json", false)},
+			expected: `{"Error":"","Latency":"12ms","Name":"buildx","Path":"/usr/libexec/docker/cli-plugins/docker-buildx","Status":"pass"}
+{"Error":"failed to fetch metadata: permission denied","Latency":"2ms","Name":"broken","Path":"/usr/libexec/docker/cli-plugins/docker-broken","Status":"fail"}
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.context.Format), func(t *testing.T) {
+			var out bytes.Buffer
+			tc.context.Output = &out
+			err := PluginProbeWrite(tc.context, results)
+			assert.NilError(t, err)
+			assert.Equal(t, out.String(), tc.expected)
+		})
+	}
+}
@@ -12,15 +12,19 @@ type fakeClient struct {
 	client.Client
 	infoFunc           func() (system.Info, error)
 	nodeInspectFunc    func() (swarm.Node, []byte, error)
+	nodeInspectByID    func(nodeID string) (swarm.Node, []byte, error)
 	nodeListFunc       func() ([]swarm.Node, error)
-	nodeRemoveFunc     func() error
+	nodeRemoveFunc     func(nodeID string) error
 	nodeUpdateFunc     func(nodeID string, version swarm.Version, node swarm.NodeSpec) error
 	taskInspectFunc    func(taskID string) (swarm.Task, []byte, error)
 	taskListFunc       func(options swarm.TaskListOptions) ([]swarm.Task, error)
 	serviceInspectFunc func(ctx context.Context, serviceID string, opts swarm.ServiceInspectOptions) (swarm.Service, []byte, error)
 }
 
-func (cli *fakeClient) NodeInspectWithRaw(context.Context, string) (swarm.Node, []byte, error) {
+func (cli *fakeClient) NodeInspectWithRaw(_ context.Context, nodeID string) (swarm.Node, []byte, error) {
+	if cli.nodeInspectByID != nil {
+		return cli.nodeInspectByID(nodeID)
+	}
 	if cli.nodeInspectFunc != nil {
 		return cli.nodeInspectFunc()
 	}
@@ -34,9 +38,9 @@ func (cli *fakeClient) NodeList(context.Context, swarm.NodeListOptions) ([]swarm
 	return []swarm.Node{}, nil
 }
 
-func (cli *fakeClient) NodeRemove(context.Context, string, swarm.NodeRemoveOptions) error {
+func (cli *fakeClient) NodeRemove(_ context.Context, nodeID string, _ swarm.NodeRemoveOptions) error {
 	if cli.nodeRemoveFunc != nil {
-		return cli.nodeRemoveFunc()
+		return cli.nodeRemoveFunc(nodeID)
 	}
 	return nil
 }
@@ -2,37 +2,151 @@
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
+	flagsHelper "github.com/docker/cli/cli/flags"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
 	"github.com/spf13/cobra"
 )
 
+type demoteOptions struct {
+	format string
+	yes    bool
+}
+
 func newDemoteCommand(dockerCli command.Cli) *cobra.Command {
-	return &cobra.Command{
-		Use:   "demote NODE [NODE...]",
+	var options demoteOptions
+
+	cmd := &cobra.Command{
+		Use:   "demote [OPTIONS] NODE [NODE...]",
 		Short: "Demote one or more nodes from manager in the swarm",
 		Args:  cli.RequiresMinArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runDemote(cmd.Context(), dockerCli, args)
+			return runDemote(cmd.Context(), dockerCli, args, options)
 		},
 		ValidArgsFunction: completeNodeNames(dockerCli),
 	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&options.format, "format", "", flagsHelper.InspectFormatHelp)
+	flags.BoolVarP(&options.yes, "yes", "y", false, "Do not prompt for confirmation")
+	return cmd
 }
 
-func runDemote(ctx context.Context, dockerCli command.Cli, nodes []string) error {
+func runDemote(ctx context.Context, dockerCli command.Cli, args []string, options demoteOptions) error {
+	nodes, err := readNodeIDs(dockerCli.In(), args)
+	if err != nil {
+		return err
+	}
+	if err := confirmRoleChange(ctx, dockerCli, nodes, "Demote", swarm.NodeRoleWorker, options.yes); err != nil {
+		return err
+	}
 	demote := func(node *swarm.Node) error {
 		if node.Spec.Role == swarm.NodeRoleWorker {
-			_, _ = fmt.Fprintf(dockerCli.Out(), "Node %s is already a worker.\n", node.ID)
 			return errNoRoleChange
 		}
 		node.Spec.Role = swarm.NodeRoleWorker
 		return nil
 	}
+	if options.format != "" {
+		results := updateNodesResults(ctx, dockerCli.Client(), nodes, demote, "demoted", 1)
+		if options.format == "json" {
+			return writeDemoteJSON(ctx, dockerCli, results)
+		}
+		err := writeNodeResults(dockerCli.Out(), options.format, "update", results)
+		// The quorum is printed on stderr, so that the output only
+		// contains the results of the nodes.
+		printQuorumStatus(ctx, dockerCli, dockerCli.Err())
+		return err
+	}
+
+	demoteVerbose := func(node *swarm.Node) error {
+		err := demote(node)
+		if err == errNoRoleChange {
+			_, _ = fmt.Fprintf(dockerCli.Out(), "Node %s is already a worker.\n", node.ID)
+		}
+		return err
+	}
 	success := func(nodeID string) {
 		_, _ = fmt.Fprintf(dockerCli.Out(), "Manager %s demoted in the swarm.\n", nodeID)
 	}
-	return updateNodes(ctx, dockerCli, nodes, demote, success)
+	if err := updateNodes(ctx, dockerCli, nodes, demoteVerbose, success); err != nil {
+		return err
+	}
+	printQuorumStatus(ctx, dockerCli, dockerCli.Out())
+	return nil
+}
+
+// demoteJSON is the output of "docker node demote --format json".
+type demoteJSON struct {
+	Results []nodeResult  `json:"results"`
+	Quorum  *quorumStatus `json:"quorum,omitempty"`
+}
+
+// writeDemoteJSON writes the results, and the quorum status of the swarm
+// after demoting the nodes, as a single JSON object. The quorum is omitted
+// if it could not be determined.
+func writeDemoteJSON(ctx context.Context, dockerCli command.Cli, results []nodeResult) error {
+	out := demoteJSON{Results: results}
+	if quorum, err := getQuorumStatus(ctx, dockerCli.Client()); err != nil {
+		_, _ = fmt.Fprintln(dockerCli.Err(), "WARNING: failed to check the quorum of the swarm:", err)
+	} else {
+		out.Quorum = &quorum
+	}
+	if err := json.NewEncoder(dockerCli.Out()).Encode(out); err != nil {
+		return err
+	}
+	return nodeResultsError("update", results)
+}
+
+// printQuorumStatus prints the quorum status of the swarm to out, or a
+// warning if it could not be determined.
+func printQuorumStatus(ctx context.Context, dockerCli command.Cli, out io.Writer) {
+	quorum, err := getQuorumStatus(ctx, dockerCli.Client())
+	if err != nil {
+		_, _ = fmt.Fprintln(dockerCli.Err(), "WARNING: failed to check the quorum of the swarm:", err)
+		return
+	}
+	_, _ = fmt.Fprintln(out, quorum.String())
+}
+
+// quorumStatus describes the managers of the swarm, and whether enough of
+// them are reachable to maintain the quorum.
+type quorumStatus struct {
+	Managers  int  `json:"managers"`
+	Reachable int  `json:"reachable"`
+	Healthy   bool `json:"healthy"`
+}
+
+func (q quorumStatus) String() string {
+	if q.Healthy {
+		return fmt.Sprintf("%d managers remaining, quorum OK", q.Managers)
+	}
+	return fmt.Sprintf("%d managers remaining, quorum LOST: %d of %d managers reachable", q.Managers, q.Reachable, q.Managers)
+}
+
+// getQuorumStatus lists the managers of the swarm, and counts how many of
+// them are reachable. The quorum is healthy if a majority of the managers
+// is reachable.
+func getQuorumStatus(ctx context.Context, apiClient client.NodeAPIClient) (quorumStatus, error) {
+	managers, err := apiClient.NodeList(ctx, swarm.NodeListOptions{
+		Filters: filters.NewArgs(filters.Arg("role", string(swarm.NodeRoleManager))),
+	})
+	if err != nil {
+		return quorumStatus{}, err
+	}
+	q := quorumStatus{Managers: len(managers)}
+	for _, m := range managers {
+		if m.ManagerStatus != nil && m.ManagerStatus.Reachability == swarm.ReachabilityReachable {
+			q.Reachable++
+		}
+	}
+	q.Healthy = q.Reachable > q.Managers/2
+	return q, nil
 }
@@ -3,12 +3,15 @@
 import (
 	"errors"
 	"io"
+	"strings"
 	"testing"
 
+	"github.com/docker/cli/cli/streams"
 	"github.com/docker/cli/internal/test"
 	"github.com/docker/cli/internal/test/builders"
 	"github.com/docker/docker/api/types/swarm"
 	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
 )
 
 func TestNodeDemoteErrors(t *testing.T) {
@@ -82,3 +85,111 @@ func TestNodeDemoteMultipleNode(t *testing.T) {
 	cmd.SetArgs([]string{"nodeID1", "nodeID2"})
 	assert.NilError(t, cmd.Execute())
 }
+
+func TestNodeDemoteQuorum(t *testing.T) {
+	testCases := []struct {
+		doc      string
+		managers []swarm.Node
+		expected string
+	}{
+		{
+			doc: "healthy",
+			managers: []swarm.Node{
+				*builders.Node(builders.Manager(builders.Leader())),
+				*builders.Node(builders.Manager()),
+				*builders.Node(builders.Manager(func(m *swarm.ManagerStatus) { m.Reachability = swarm.ReachabilityUnreachable })),
+			},
+			expected: "3 managers remaining, quorum OK\n",
+		},
+		{
+			doc: "lost",
+			managers: []swarm.Node{
+				*builders.Node(builders.Manager(builders.Leader())),
+				*builders.Node(builders.Manager(func(m *swarm.ManagerStatus) { m.Reachability = swarm.ReachabilityUnreachable })),
+			},
+			expected: "2 managers remaining, quorum LOST: 1 of 2 managers reachable\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{
+				nodeInspectFunc: func() (swarm.Node, []byte, error) {
+					return *builders.Node(builders.Manager()), []byte{}, nil
+				},
+				nodeListFunc: func() ([]swarm.Node, error) {
+					return tc.managers, nil
+				},
+			})
+			cmd := newDemoteCommand(cli)
+			cmd.SetArgs([]string{"nodeID"})
+			assert.NilError(t, cmd.Execute())
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), "Manager nodeID demoted in the swarm.\n"+tc.expected))
+		})
+	}
+}
+
+func TestNodeDemoteQuorumFormatJSON(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			return *builders.Node(builders.Manager()), []byte{}, nil
+		},
+		nodeListFunc: func() ([]swarm.Node, error) {
+			return []swarm.Node{*builders.Node(builders.Manager(builders.Leader()))}, nil
+		},
+	})
+	cmd := newDemoteCommand(cli)
+	cmd.SetArgs([]string{"--format", "json", "nodeID", "nodeID2"})
+	assert.NilError(t, cmd.Execute())
+
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), `{"results":[{"node":"nodeID","action":"demoted"},{"node":"nodeID2","action":"demoted"}],"quorum":{"managers":1,"reachable":1,"healthy":true}}
+`))
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), ""))
+}
+
+func TestNodeDemoteQuorumFormatTemplate(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			return *builders.Node(builders.Manager()), []byte{}, nil
+		},
+		nodeListFunc: func() ([]swarm.Node, error) {
+			return []swarm.Node{*builders.Node(builders.Manager(builders.Leader()))}, nil
+		},
+	})
+	cmd := newDemoteCommand(cli)
+	cmd.SetArgs([]string{"--format", "{{.Node}}: {{.Action}}", "nodeID"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "nodeID: demoted\n"))
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "1 managers remaining, quorum OK\n"))
+}
+
+func TestNodeDemoteQuorumError(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			return *builders.Node(builders.Manager()), []byte{}, nil
+		},
+		nodeListFunc: func() ([]swarm.Node, error) {
+			return nil, errors.New("error listing nodes")
+		},
+	})
+	cmd := newDemoteCommand(cli)
+	cmd.SetArgs([]string{"nodeID"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "Manager nodeID demoted in the swarm.\n"))
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "WARNING: failed to check the quorum of the swarm: error listing nodes\n"))
+}
+
+func TestNodeDemoteConfirmDeclined(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		nodeUpdateFunc: func(string, swarm.Version, swarm.NodeSpec) error {
+			return errors.New("node should not be updated")
+		},
+	})
+	cli.SetIn(streams.NewIn(io.NopCloser(strings.NewReader("n\n"))))
+	cli.In().SetIsTerminal(true)
+	cmd := newDemoteCommand(cli)
+	cmd.SetArgs([]string{"nodeID"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "node demote has been cancelled")
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "Demote node nodeID to worker? [y/N] "))
+}
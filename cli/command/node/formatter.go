@@ -1,10 +1,16 @@
+// FIXME(thaJeztah): remove once we are a module; the go:build directive prevents go from downgrading language version to go1.16:
+//go:build go1.23
+
 package node
 
 import (
 	"encoding/base64"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/docker/cli/cli/command/formatter"
 	"github.com/docker/cli/cli/command/inspect"
@@ -75,8 +81,11 @@
 	hostnameHeader      = "HOSTNAME"
 	availabilityHeader  = "AVAILABILITY"
 	managerStatusHeader = "MANAGER STATUS"
+	leaderHeader        = "LEADER"
+	reachabilityHeader  = "REACHABILITY"
 	engineVersionHeader = "ENGINE VERSION"
 	tlsStatusHeader     = "TLS STATUS"
+	updatedAtHeader     = "UPDATED AT"
 )
 
 // NewFormat returns a Format for rendering using a node Context
@@ -117,12 +126,34 @@ func FormatWrite(ctx formatter.Context, nodes []swarm.Node, info system.Info) er
 		"Status":        formatter.StatusHeader,
 		"Availability":  availabilityHeader,
 		"ManagerStatus": managerStatusHeader,
+		"Leader":        leaderHeader,
+		"Reachability":  reachabilityHeader,
 		"EngineVersion": engineVersionHeader,
 		"TLSStatus":     tlsStatusHeader,
+		"CreatedAt":     formatter.CreatedAtHeader,
+		"UpdatedAt":     updatedAtHeader,
+	}
+	if ctx.Funcs == nil {
+		ctx.Funcs = template.FuncMap{}
 	}
+	ctx.Funcs["duration"] = humanDuration
 	return ctx.Write(&nodeCtx, render)
 }
 
+// humanDuration is the "duration" template function, which renders a
+// timestamp as a human-readable duration relative to now ("3 days ago").
+// Other values, such as the column header, are rendered as-is.
+func humanDuration(v any) string {
+	t, ok := v.(time.Time)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	if t.IsZero() {
+		return ""
+	}
+	return units.HumanDuration(time.Now().UTC().Sub(t)) + " ago"
+}
+
 type nodeContext struct {
 	formatter.HeaderContext
 	n    swarm.Node
@@ -165,6 +196,24 @@ func (c *nodeContext) ManagerStatus() string {
 	return formatter.PrettyPrint(reachability)
 }
 
+// Leader returns "true" if the node is the swarm leader, and "false" for
+// other managers. It returns an empty string for worker nodes.
+func (c *nodeContext) Leader() string {
+	if c.n.ManagerStatus == nil {
+		return ""
+	}
+	return strconv.FormatBool(c.n.ManagerStatus.Leader)
+}
+
+// Reachability returns the raft reachability of a manager node. It returns
+// an empty string for worker nodes.
+func (c *nodeContext) Reachability() string {
+	if c.n.ManagerStatus == nil {
+		return ""
+	}
+	return formatter.PrettyPrint(c.n.ManagerStatus.Reachability)
+}
+
 func (c *nodeContext) TLSStatus() string {
 	if c.info.Swarm.Cluster == nil || reflect.DeepEqual(c.info.Swarm.Cluster.TLSInfo, swarm.TLSInfo{}) || reflect.DeepEqual(c.n.Description.TLSInfo, swarm.TLSInfo{}) {
 		return "Unknown"
@@ -175,6 +224,14 @@ func (c *nodeContext) TLSStatus() string {
 	return "Needs Rotation"
 }
 
+func (c *nodeContext) CreatedAt() time.Time {
+	return c.n.CreatedAt
+}
+
+func (c *nodeContext) UpdatedAt() time.Time {
+	return c.n.UpdatedAt
+}
+
 func (c *nodeContext) EngineVersion() string {
 	return c.n.Description.Engine.EngineVersion
 }
@@ -182,7 +239,13 @@ func (c *nodeContext) EngineVersion() string {
 // InspectFormatWrite renders the context for a list of nodes
 func InspectFormatWrite(ctx formatter.Context, refs []string, getRef inspect.GetRefFunc) error {
 	if ctx.Format != nodeInspectPrettyTemplate {
-		return inspect.Inspect(ctx.Output, refs, string(ctx.Format), getRef)
+		return inspect.Inspect(ctx.Output, refs, string(ctx.Format), func(ref string) (any, []byte, error) {
+			element, raw, err := getRef(ref)
+			if node, ok := element.(swarm.Node); ok {
+				element = inspectNode{Node: node}
+			}
+			return element, raw, err
+		})
 	}
 	render := func(format func(subContext formatter.SubContext) error) error {
 		for _, ref := range refs {
@@ -203,6 +266,32 @@ func InspectFormatWrite(ctx formatter.Context, refs []string, getRef inspect.Get
 	return ctx.Write(&nodeInspectContext{}, render)
 }
 
+// inspectNode wraps a node to provide shorthands for commonly used fields
+// in inspect templates. It marshals to the same JSON as the node itself.
+type inspectNode struct {
+	swarm.Node
+}
+
+// Role returns the role of the node ("worker" or "manager").
+func (n inspectNode) Role() string {
+	return string(n.Spec.Role)
+}
+
+// Availability returns the availability of the node ("active", "pause", or "drain").
+func (n inspectNode) Availability() string {
+	return string(n.Spec.Availability)
+}
+
+// State returns the state of the node, for example "ready" or "down".
+func (n inspectNode) State() string {
+	return string(n.Status.State)
+}
+
+// Addr returns the IP address of the node.
+func (n inspectNode) Addr() string {
+	return n.Status.Addr
+}
+
 type nodeInspectContext struct {
 	swarm.Node
 	formatter.SubContext
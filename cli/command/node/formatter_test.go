@@ -43,6 +43,21 @@ func TestNodeContext(t *testing.T) {
 		{nodeContext{
 			n: swarm.Node{ManagerStatus: &swarm.ManagerStatus{Leader: true}},
 		}, "Leader", ctx.ManagerStatus},
+		{nodeContext{
+			n: swarm.Node{ManagerStatus: &swarm.ManagerStatus{Leader: true, Reachability: swarm.ReachabilityReachable}},
+		}, "true", ctx.Leader},
+		{nodeContext{
+			n: swarm.Node{ManagerStatus: &swarm.ManagerStatus{Reachability: swarm.ReachabilityUnreachable}},
+		}, "false", ctx.Leader},
+		{nodeContext{
+			n: swarm.Node{},
+		}, "", ctx.Leader},
+		{nodeContext{
+			n: swarm.Node{ManagerStatus: &swarm.ManagerStatus{Reachability: swarm.ReachabilityUnreachable}},
+		}, "Unreachable", ctx.Reachability},
+		{nodeContext{
+			n: swarm.Node{},
+		}, "", ctx.Reachability},
 	}
 
 	for _, c := range cases {
@@ -223,17 +238,17 @@ func TestNodeContextWriteJSON(t *testing.T) {
 	}{
 		{
 			expected: []map[string]any{
-				{"Availability": "", "Hostname": "foobar_baz", "ID": "nodeID1", "ManagerStatus": "", "Status": "", "Self": false, "TLSStatus": "Unknown", "EngineVersion": "1.2.3"},
-				{"Availability": "", "Hostname": "foobar_bar", "ID": "nodeID2", "ManagerStatus": "", "Status": "", "Self": false, "TLSStatus": "Unknown", "EngineVersion": ""},
-				{"Availability": "", "Hostname": "foobar_boo", "ID": "nodeID3", "ManagerStatus": "", "Status": "", "Self": false, "TLSStatus": "Unknown", "EngineVersion": "18.03.0-ce"},
+				{"Availability": "", "Hostname": "foobar_baz", "ID": "nodeID1", "ManagerStatus": "", "Leader": "", "Reachability": "", "Status": "", "Self": false, "TLSStatus": "Unknown", "EngineVersion": "1.2.3", "CreatedAt": "0001-01-01T00:00:00Z", "UpdatedAt": "0001-01-01T00:00:00Z"},
+				{"Availability": "", "Hostname": "foobar_bar", "ID": "nodeID2", "ManagerStatus": "", "Leader": "", "Reachability": "", "Status": "", "Self": false, "TLSStatus": "Unknown", "EngineVersion": "", "CreatedAt": "0001-01-01T00:00:00Z", "UpdatedAt": "0001-01-01T00:00:00Z"},
+				{"Availability": "", "Hostname": "foobar_boo", "ID": "nodeID3", "ManagerStatus": "", "Leader": "", "Reachability": "", "Status": "", "Self": false, "TLSStatus": "Unknown", "EngineVersion": "18.03.0-ce", "CreatedAt": "0001-01-01T00:00:00Z", "UpdatedAt": "0001-01-01T00:00:00Z"},
 			},
 			info: system.Info{},
 		},
 		{
 			expected: []map[string]any{
-				{"Availability": "", "Hostname": "foobar_baz", "ID": "nodeID1", "ManagerStatus": "", "Status": "", "Self": false, "TLSStatus": "Ready", "EngineVersion": "1.2.3"},
-				{"Availability": "", "Hostname": "foobar_bar", "ID": "nodeID2", "ManagerStatus": "", "Status": "", "Self": false, "TLSStatus": "Needs Rotation", "EngineVersion": ""},
-				{"Availability": "", "Hostname": "foobar_boo", "ID": "nodeID3", "ManagerStatus": "", "Status": "", "Self": false, "TLSStatus": "Unknown", "EngineVersion": "18.03.0-ce"},
+				{"Availability": "", "Hostname": "foobar_baz", "ID": "nodeID1", "ManagerStatus": "", "Leader": "", "Reachability": "", "Status": "", "Self": false, "TLSStatus": "Ready", "EngineVersion": "1.2.3", "CreatedAt": "0001-01-01T00:00:00Z", "UpdatedAt": "0001-01-01T00:00:00Z"},
+				{"Availability": "", "Hostname": "foobar_bar", "ID": "nodeID2", "ManagerStatus": "", "Leader": "", "Reachability": "", "Status": "", "Self": false, "TLSStatus": "Needs Rotation", "EngineVersion": "", "CreatedAt": "0001-01-01T00:00:00Z", "UpdatedAt": "0001-01-01T00:00:00Z"},
+				{"Availability": "", "Hostname": "foobar_boo", "ID": "nodeID3", "ManagerStatus": "", "Leader": "", "Reachability": "", "Status": "", "Self": false, "TLSStatus": "Unknown", "EngineVersion": "18.03.0-ce", "CreatedAt": "0001-01-01T00:00:00Z", "UpdatedAt": "0001-01-01T00:00:00Z"},
 			},
 			info: system.Info{
 				Swarm: swarm.Info{
@@ -352,3 +367,39 @@ func TestNodeInspectWriteContext(t *testing.T) {
 `
 	assert.Check(t, is.Equal(expected, out.String()))
 }
+
+func TestNodeInspectWriteShorthands(t *testing.T) {
+	node := swarm.Node{
+		ID: "nodeID1",
+		Status: swarm.NodeStatus{
+			State: swarm.NodeStateReady,
+			Addr:  "1.1.1.1",
+		},
+		Spec: swarm.NodeSpec{
+			Availability: swarm.NodeAvailabilityDrain,
+			Role:         swarm.NodeRoleManager,
+		},
+	}
+	getRef := func(string) (any, []byte, error) {
+		return node, nil, nil
+	}
+
+	out := bytes.NewBufferString("")
+	err := InspectFormatWrite(formatter.Context{
+		Format: NewFormat("{{.ID}} {{.Role}} {{.Availability}} {{.State}} {{.Addr}}", false),
+		Output: out,
+	}, []string{"nodeID1"}, getRef)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(out.String(), "nodeID1 manager drain ready 1.1.1.1\n"))
+
+	// The JSON output is not affected by the shorthands.
+	out.Reset()
+	err = InspectFormatWrite(formatter.Context{
+		Format: NewFormat("{{json .}}", false),
+		Output: out,
+	}, []string{"nodeID1"}, getRef)
+	assert.NilError(t, err)
+	expected, err := json.Marshal(node)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(out.String(), string(expected)+"\n"))
+}
@@ -6,12 +6,17 @@
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
 	"strings"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/command/formatter"
 	flagsHelper "github.com/docker/cli/cli/flags"
+	"github.com/docker/docker/api/types/swarm"
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +24,8 @@ type inspectOptions struct {
 	nodeIds []string
 	format  string
 	pretty  bool
+
+	resolveInterfaces bool
 }
 
 func newInspectCommand(dockerCli command.Cli) *cobra.Command {
@@ -38,6 +45,7 @@ func newInspectCommand(dockerCli command.Cli) *cobra.Command {
 	flags := cmd.Flags()
 	flags.StringVarP(&opts.format, "format", "f", "", flagsHelper.InspectFormatHelp)
 	flags.BoolVar(&opts.pretty, "pretty", false, "Print the information in a human friendly format")
+	flags.BoolVar(&opts.resolveInterfaces, "resolve-interfaces", false, "Annotate the addresses of the local node with the name of their network interface")
 	return cmd
 }
 
@@ -48,12 +56,30 @@ func runInspect(ctx context.Context, dockerCli command.Cli, opts inspectOptions)
 		opts.format = "pretty"
 	}
 
+	var localNodeID string
+	if opts.resolveInterfaces && opts.format != "" && opts.format != formatter.JSONFormatKey {
+		// The interfaces are those of the host the CLI runs on, which are
+		// only meaningful if the daemon runs on the same host.
+		if isLocalDaemon(dockerCli) {
+			// Resolving interfaces is best-effort; if the local node cannot
+			// be determined, addresses are printed as-is.
+			if info, err := client.Info(ctx); err == nil {
+				localNodeID = info.Swarm.NodeID
+			}
+		} else {
+			_, _ = fmt.Fprintln(dockerCli.Err(), "WARNING: --resolve-interfaces is ignored, because the daemon does not run on the local host")
+		}
+	}
+
 	getRef := func(ref string) (any, []byte, error) {
 		nodeRef, err := Reference(ctx, client, ref)
 		if err != nil {
 			return nil, nil, err
 		}
 		node, _, err := client.NodeInspectWithRaw(ctx, nodeRef)
+		if err == nil && localNodeID != "" && node.ID == localNodeID {
+			annotateInterfaces(&node)
+		}
 		return node, nil, err
 	}
 	f := opts.format
@@ -74,3 +100,78 @@ func runInspect(ctx context.Context, dockerCli command.Cli, opts inspectOptions)
 	}
 	return nil
 }
+
+// isLocalDaemon returns whether the CLI connects to the daemon through a
+// local socket, in which case the daemon runs on the same host as the CLI.
+func isLocalDaemon(dockerCli command.Cli) bool {
+	host := dockerCli.DockerEndpoint().Host
+	return strings.HasPrefix(host, "unix://") || strings.HasPrefix(host, "npipe://")
+}
+
+// localInterfaceAddrs returns the IP addresses of the network interfaces of
+// the local host, keyed by interface name. It is a variable so that it can be
+// replaced in tests.
+var localInterfaceAddrs = func() (map[string][]netip.Addr, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]netip.Addr, len(ifaces))
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				if ip, ok := netip.AddrFromSlice(ipNet.IP); ok {
+					result[iface.Name] = append(result[iface.Name], ip.Unmap())
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// annotateInterfaces annotates the status and manager addresses of the node
+// with the name of the local network interface they map to, for example
+// "10.0.0.1 (eth0)". Addresses that cannot be resolved are left untouched.
+func annotateInterfaces(node *swarm.Node) {
+	ifaces, err := localInterfaceAddrs()
+	if err != nil {
+		return
+	}
+	node.Status.Addr = annotateInterface(ifaces, node.Status.Addr)
+	if node.ManagerStatus != nil {
+		node.ManagerStatus.Addr = annotateInterface(ifaces, node.ManagerStatus.Addr)
+	}
+}
+
+// annotateInterface returns addr, followed by the name of the interface in
+// ifaces that has the address. The address may include a port. addr is
+// returned as-is if no interface has the address.
+func annotateInterface(ifaces map[string][]netip.Addr, addr string) string {
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return addr
+	}
+	ip = ip.Unmap()
+
+	// Iterate interfaces in a stable order, in case multiple interfaces
+	// have the same address.
+	names := make([]string, 0, len(ifaces))
+	for name := range ifaces {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if slices.Contains(ifaces[name], ip) {
+			return addr + " (" + name + ")"
+		}
+	}
+	return addr
+}
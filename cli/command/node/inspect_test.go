@@ -4,13 +4,16 @@
 	"errors"
 	"fmt"
 	"io"
+	"net/netip"
 	"testing"
 
+	"github.com/docker/cli/cli/context/docker"
 	"github.com/docker/cli/internal/test"
 	"github.com/docker/cli/internal/test/builders"
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/api/types/system"
 	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
 	"gotest.tools/v3/golden"
 )
 
@@ -118,3 +121,100 @@ func TestNodeInspectPretty(t *testing.T) {
 		})
 	}
 }
+
+func TestNodeInspectResolveInterfaces(t *testing.T) {
+	defer func(orig func() (map[string][]netip.Addr, error)) { localInterfaceAddrs = orig }(localInterfaceAddrs)
+	localInterfaceAddrs = func() (map[string][]netip.Addr, error) {
+		return map[string][]netip.Addr{
+			"eth0": {netip.MustParseAddr("10.0.0.1")},
+			"lo":   {netip.MustParseAddr("127.0.0.1"), netip.MustParseAddr("::1")},
+		}, nil
+	}
+
+	const addrFormat = "{{.Status.Addr}} {{.ManagerStatus.Addr}}"
+	testCases := []struct {
+		name        string
+		host        string
+		format      string
+		localID     string
+		infoErr     error
+		expected    string
+		expectedErr string
+	}{
+		{
+			name:     "local node",
+			localID:  "nodeID",
+			expected: "127.0.0.1 (lo) 127.0.0.1 (lo)",
+		},
+		{
+			name:     "remote node",
+			localID:  "otherNodeID",
+			expected: "127.0.0.1 127.0.0.1",
+		},
+		{
+			name:     "info error",
+			infoErr:  errors.New("error asking for node info"),
+			expected: "127.0.0.1 127.0.0.1",
+		},
+		{
+			name:        "remote daemon",
+			host:        "tcp://10.0.0.1:2376",
+			localID:     "nodeID",
+			expected:    "127.0.0.1 127.0.0.1",
+			expectedErr: "WARNING: --resolve-interfaces is ignored, because the daemon does not run on the local host\n",
+		},
+		{
+			name:     "json",
+			format:   "json",
+			localID:  "nodeID",
+			expected: `"Addr":"127.0.0.1"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{
+				nodeInspectFunc: func() (swarm.Node, []byte, error) {
+					return *builders.Node(builders.Manager()), []byte{}, nil
+				},
+				infoFunc: func() (system.Info, error) {
+					return system.Info{Swarm: swarm.Info{NodeID: tc.localID}}, tc.infoErr
+				},
+			})
+			host := tc.host
+			if host == "" {
+				host = "unix:///var/run/docker.sock"
+			}
+			cli.SetDockerEndpoint(docker.Endpoint{EndpointMeta: docker.EndpointMeta{Host: host}})
+			format := tc.format
+			if format == "" {
+				format = addrFormat
+			}
+			cmd := newInspectCommand(cli)
+			cmd.SetArgs([]string{"--resolve-interfaces", "--format", format, "nodeID"})
+			assert.NilError(t, cmd.Execute())
+			assert.Check(t, is.Contains(cli.OutBuffer().String(), tc.expected))
+			assert.Check(t, is.Equal(cli.ErrBuffer().String(), tc.expectedErr))
+		})
+	}
+}
+
+func TestAnnotateInterface(t *testing.T) {
+	ifaces := map[string][]netip.Addr{
+		"eth0": {netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("fe80::1")},
+		"eth1": {netip.MustParseAddr("10.0.0.1")},
+	}
+	testCases := []struct {
+		addr     string
+		expected string
+	}{
+		{addr: "10.0.0.1", expected: "10.0.0.1 (eth0)"},
+		{addr: "10.0.0.1:2377", expected: "10.0.0.1:2377 (eth0)"},
+		{addr: "[fe80::1]:2377", expected: "[fe80::1]:2377 (eth0)"},
+		{addr: "10.0.0.2", expected: "10.0.0.2"},
+		{addr: "not-an-address", expected: "not-an-address"},
+		{addr: "", expected: ""},
+	}
+	for _, tc := range testCases {
+		assert.Check(t, is.Equal(annotateInterface(ifaces, tc.addr), tc.expected))
+	}
+}
@@ -1,8 +1,13 @@
+// FIXME(thaJeztah): remove once we are a module; the go:build directive prevents go from downgrading language version to go1.16:
+//go:build go1.23
+
 package node
 
 import (
 	"context"
+	"slices"
 	"sort"
+	"strings"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
@@ -13,6 +18,7 @@
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/api/types/system"
 	"github.com/fvbommel/sortorder"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -53,12 +59,38 @@ func newListCommand(dockerCli command.Cli) *cobra.Command {
 func runList(ctx context.Context, dockerCli command.Cli, options listOptions) error {
 	client := dockerCli.Client()
 
+	// The "label" filter is applied client-side, so that it matches both
+	// node labels and engine labels; the daemon only matches engine labels.
+	nodeFilters := options.filter.Value().Clone()
+	labelFilters := nodeFilters.Get("label")
+	for _, lf := range labelFilters {
+		nodeFilters.Del("label", lf)
+	}
+
+	// The "role" filter is applied client-side as well, using the role in
+	// the spec of the node.
+	roleFilters := nodeFilters.Get("role")
+	for _, rf := range roleFilters {
+		switch swarm.NodeRole(rf) {
+		case swarm.NodeRoleManager, swarm.NodeRoleWorker:
+		default:
+			return errors.Errorf(`invalid filter 'role=%s': only "manager" and "worker" are supported`, rf)
+		}
+		nodeFilters.Del("role", rf)
+	}
+
 	nodes, err := client.NodeList(
 		ctx,
-		swarm.NodeListOptions{Filters: options.filter.Value()})
+		swarm.NodeListOptions{Filters: nodeFilters})
 	if err != nil {
 		return err
 	}
+	if len(labelFilters) > 0 {
+		nodes = filterNodesByLabels(nodes, labelFilters)
+	}
+	if len(roleFilters) > 0 {
+		nodes = filterNodesByRoles(nodes, roleFilters)
+	}
 
 	info := system.Info{}
 	if len(nodes) > 0 && !options.quiet {
@@ -86,3 +118,42 @@ func runList(ctx context.Context, dockerCli command.Cli, options listOptions) er
 	})
 	return FormatWrite(nodesCtx, nodes, info)
 }
+
+// filterNodesByLabels returns the nodes that match all the given label
+// filters ("key" or "key=value"). A filter matches if either the node
+// labels or the engine labels of the node match.
+func filterNodesByLabels(nodes []swarm.Node, labelFilters []string) []swarm.Node {
+	filtered := make([]swarm.Node, 0, len(nodes))
+	for _, node := range nodes {
+		matches := true
+		for _, lf := range labelFilters {
+			if !matchLabel(node.Spec.Labels, lf) && !matchLabel(node.Description.Engine.Labels, lf) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
+// filterNodesByRoles returns the nodes that have any of the given roles.
+func filterNodesByRoles(nodes []swarm.Node, roles []string) []swarm.Node {
+	filtered := make([]swarm.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if slices.Contains(roles, string(node.Spec.Role)) {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
+// matchLabel returns whether labels contain the given key, and, if the
+// filter has a value, whether the label has that value.
+func matchLabel(labels map[string]string, labelFilter string) bool {
+	key, value, hasValue := strings.Cut(labelFilter, "=")
+	v, ok := labels[key]
+	return ok && (!hasValue || v == value)
+}
@@ -4,6 +4,7 @@
 	"errors"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/docker/cli/cli/config/configfile"
 	"github.com/docker/cli/internal/test"
@@ -139,3 +140,143 @@ func TestNodeListFormat(t *testing.T) {
 	assert.NilError(t, cmd.Execute())
 	golden.Assert(t, cli.OutBuffer().String(), "node-list-format-flag.golden")
 }
+
+func TestNodeListFormatDuration(t *testing.T) {
+	createdAt := func(d time.Duration) func(*swarm.Node) {
+		return func(node *swarm.Node) {
+			node.CreatedAt = time.Now().UTC().Add(-d)
+		}
+	}
+	cli := test.NewFakeCli(&fakeClient{
+		nodeListFunc: func() ([]swarm.Node, error) {
+			return []swarm.Node{
+				*builders.Node(builders.NodeID("nodeID1"), builders.Hostname("nodeHostname1"), createdAt(72*time.Hour)),
+				*builders.Node(builders.NodeID("nodeID2"), builders.Hostname("nodeHostname2"), createdAt(2*time.Hour)),
+			}, nil
+		},
+	})
+	cmd := newListCommand(cli)
+	assert.Check(t, cmd.Flags().Set("format", "table {{.Hostname}}\t{{duration .CreatedAt}}"))
+	assert.NilError(t, cmd.Execute())
+	golden.Assert(t, cli.OutBuffer().String(), "node-list-format-duration.golden")
+}
+
+func TestNodeListFilterLabel(t *testing.T) {
+	engineLabels := func(labels map[string]string) func(*swarm.Node) {
+		return func(node *swarm.Node) {
+			node.Description.Engine.Labels = labels
+		}
+	}
+	nodes := []swarm.Node{
+		*builders.Node(builders.NodeID("nodeID1"), builders.Hostname("node1"), builders.NodeLabels(map[string]string{"role": "db", "zone": "a"})),
+		*builders.Node(builders.NodeID("nodeID2"), builders.Hostname("node2"), builders.NodeLabels(map[string]string{"role": "web", "zone": "a"})),
+		*builders.Node(builders.NodeID("nodeID3"), builders.Hostname("node3"), engineLabels(map[string]string{"role": "db"})),
+	}
+
+	testCases := []struct {
+		doc      string
+		filters  []string
+		expected string
+	}{
+		{
+			doc:      "node and engine labels",
+			filters:  []string{"label=role=db"},
+			expected: "nodeID1\nnodeID3\n",
+		},
+		{
+			doc:      "key only",
+			filters:  []string{"label=zone"},
+			expected: "nodeID1\nnodeID2\n",
+		},
+		{
+			doc:      "multiple filters",
+			filters:  []string{"label=role=db", "label=zone=a"},
+			expected: "nodeID1\n",
+		},
+		{
+			doc:      "no match",
+			filters:  []string{"label=role=cache"},
+			expected: "",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{
+				nodeListFunc: func() ([]swarm.Node, error) {
+					return nodes, nil
+				},
+			})
+			cmd := newListCommand(cli)
+			for _, f := range tc.filters {
+				assert.Check(t, cmd.Flags().Set("filter", f))
+			}
+			assert.Check(t, cmd.Flags().Set("quiet", "true"))
+			assert.NilError(t, cmd.Execute())
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), tc.expected))
+		})
+	}
+}
+
+func TestNodeListFilterRole(t *testing.T) {
+	nodes := []swarm.Node{
+		*builders.Node(builders.NodeID("nodeID1"), builders.Hostname("node1"), builders.Manager(builders.Leader())),
+		*builders.Node(builders.NodeID("nodeID2"), builders.Hostname("node2")),
+		*builders.Node(builders.NodeID("nodeID3"), builders.Hostname("node3"), builders.Manager()),
+		*builders.Node(builders.NodeID("nodeID4"), builders.Hostname("node4"), builders.NodeLabels(map[string]string{"zone": "a"})),
+	}
+
+	testCases := []struct {
+		doc           string
+		filters       []string
+		expected      string
+		expectedError string
+	}{
+		{
+			doc:      "managers",
+			filters:  []string{"role=manager"},
+			expected: "nodeID1\nnodeID3\n",
+		},
+		{
+			doc:      "workers",
+			filters:  []string{"role=worker"},
+			expected: "nodeID2\nnodeID4\n",
+		},
+		{
+			doc:      "both roles",
+			filters:  []string{"role=manager", "role=worker"},
+			expected: "nodeID1\nnodeID2\nnodeID3\nnodeID4\n",
+		},
+		{
+			doc:      "combined with label",
+			filters:  []string{"role=worker", "label=zone=a"},
+			expected: "nodeID4\n",
+		},
+		{
+			doc:           "invalid role",
+			filters:       []string{"role=leader"},
+			expectedError: `invalid filter 'role=leader': only "manager" and "worker" are supported`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{
+				nodeListFunc: func() ([]swarm.Node, error) {
+					return nodes, nil
+				},
+			})
+			cmd := newListCommand(cli)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			for _, f := range tc.filters {
+				assert.Check(t, cmd.Flags().Set("filter", f))
+			}
+			assert.Check(t, cmd.Flags().Set("quiet", "true"))
+			if tc.expectedError != "" {
+				assert.Error(t, cmd.Execute(), tc.expectedError)
+				return
+			}
+			assert.NilError(t, cmd.Execute())
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), tc.expected))
+		})
+	}
+}
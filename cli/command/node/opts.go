@@ -1,6 +1,8 @@
 package node
 
 import (
+	"time"
+
 	"github.com/docker/cli/opts"
 )
 
@@ -8,6 +10,9 @@ type nodeOptions struct {
 	annotations
 	role         string
 	availability string
+	waitDrained  bool
+	waitTimeout  time.Duration
+	selector     opts.ListOpts
 }
 
 type annotations struct {
@@ -19,5 +24,6 @@ func newNodeOptions() *nodeOptions {
 		annotations: annotations{
 			labels: opts.NewListOpts(nil),
 		},
+		selector: opts.NewListOpts(nil),
 	}
 }
@@ -3,36 +3,235 @@
 import (
 	"context"
 	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
+	flagsHelper "github.com/docker/cli/cli/flags"
+	"github.com/docker/cli/opts"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
+type promoteOptions struct {
+	format      string
+	labelAdd    opts.ListOpts
+	labelRemove opts.ListOpts
+	wait        bool
+	waitTimeout time.Duration
+	yes         bool
+	parallel    int
+	dryRun      bool
+}
+
+// waitPollInterval is the interval at which nodes are inspected when waiting
+// for a role change to be observed.
+var waitPollInterval = time.Second
+
 func newPromoteCommand(dockerCli command.Cli) *cobra.Command {
-	return &cobra.Command{
-		Use:   "promote NODE [NODE...]",
+	options := promoteOptions{
+		labelAdd:    opts.NewListOpts(nil),
+		labelRemove: opts.NewListOpts(nil),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "promote [OPTIONS] NODE [NODE...]",
 		Short: "Promote one or more nodes to manager in the swarm",
 		Args:  cli.RequiresMinArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runPromote(cmd.Context(), dockerCli, args)
+			return runPromote(cmd.Context(), dockerCli, args, options)
 		},
 		ValidArgsFunction: completeNodeNames(dockerCli),
 	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&options.format, "format", "", flagsHelper.InspectFormatHelp)
+	flags.Var(&options.labelAdd, flagLabelAdd, `Add or update a node label ("key=value")`)
+	flags.Var(&options.labelRemove, flagLabelRemove, "Remove a node label")
+	flags.BoolVar(&options.wait, "wait", false, "Wait until the nodes are observed to be managers")
+	flags.DurationVar(&options.waitTimeout, "wait-timeout", time.Minute, "Maximum time to wait when using --wait")
+	flags.BoolVarP(&options.yes, "yes", "y", false, "Do not prompt for confirmation")
+	flags.IntVar(&options.parallel, "parallel", 1, "Maximum number of nodes to promote at the same time")
+	flags.BoolVar(&options.dryRun, "dry-run", false, "Show which nodes would be promoted, without promoting them")
+	return cmd
 }
 
-func runPromote(ctx context.Context, dockerCli command.Cli, nodes []string) error {
+func runPromote(ctx context.Context, dockerCli command.Cli, args []string, options promoteOptions) error {
+	if options.parallel < 1 {
+		return errors.Errorf("invalid --parallel value %d: must be at least 1", options.parallel)
+	}
+	if options.dryRun && (options.wait || options.format != "") {
+		return errors.New("--dry-run cannot be combined with --wait or --format")
+	}
+	nodes, err := readNodeIDs(dockerCli.In(), args)
+	if err != nil {
+		return err
+	}
 	promote := func(node *swarm.Node) error {
+		// Apply the labels first, so that they are also updated on nodes
+		// that are already a manager.
+		oldLabels := make(map[string]string, len(node.Spec.Annotations.Labels))
+		for k, v := range node.Spec.Annotations.Labels {
+			oldLabels[k] = v
+		}
+		if err := mergeLabels(&node.Spec, options.labelAdd.GetSlice(), options.labelRemove.GetSlice()); err != nil {
+			return err
+		}
 		if node.Spec.Role == swarm.NodeRoleManager {
-			_, _ = fmt.Fprintf(dockerCli.Out(), "Node %s is already a manager.\n", node.ID)
-			return errNoRoleChange
+			if labelsEqual(oldLabels, node.Spec.Annotations.Labels) {
+				return errNoRoleChange
+			}
+			return errOnlyLabelsChanged
 		}
 		node.Spec.Role = swarm.NodeRoleManager
 		return nil
 	}
+	if options.dryRun {
+		return dryRunPromote(ctx, dockerCli, nodes, promote)
+	}
+	if err := confirmRoleChange(ctx, dockerCli, nodes, "Promote", swarm.NodeRoleManager, options.yes); err != nil {
+		return err
+	}
+	if options.format != "" || options.parallel > 1 {
+		results := updateNodesResults(ctx, dockerCli.Client(), nodes, promote, "promoted", options.parallel)
+		if options.wait {
+			for i, result := range results {
+				if result.Action != "promoted" {
+					continue
+				}
+				if err := waitForManager(ctx, dockerCli.Client(), result.Node, options.waitTimeout); err != nil {
+					results[i].Action = actionFailed
+					results[i].Error = err.Error()
+				}
+			}
+		}
+		if options.format == "" {
+			return printPromoteResults(dockerCli.Out(), results)
+		}
+		return writeNodeResults(dockerCli.Out(), options.format, "update", results)
+	}
+
+	// labeled is set if the node that is being updated is already a manager,
+	// and only its labels change. Nodes are updated one at a time, so it
+	// applies to the next call of success.
+	var labeled bool
+	promoteVerbose := func(node *swarm.Node) error {
+		err := promote(node)
+		labeled = err == errOnlyLabelsChanged
+		switch err {
+		case errNoRoleChange:
+			_, _ = fmt.Fprintf(dockerCli.Out(), "Node %s is already a manager.\n", node.ID)
+		case errOnlyLabelsChanged:
+			return nil
+		}
+		return err
+	}
+	var promoted []string
 	success := func(nodeID string) {
+		if labeled {
+			_, _ = fmt.Fprintf(dockerCli.Out(), "Node %s is already a manager; its labels were updated.\n", nodeID)
+			return
+		}
 		_, _ = fmt.Fprintf(dockerCli.Out(), "Node %s promoted to a manager in the swarm.\n", nodeID)
+		promoted = append(promoted, nodeID)
+	}
+	if err := updateNodes(ctx, dockerCli, nodes, promoteVerbose, success); err != nil {
+		return err
+	}
+	if options.wait {
+		for _, nodeID := range promoted {
+			if err := waitForManager(ctx, dockerCli.Client(), nodeID, options.waitTimeout); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dryRunPromote inspects the given nodes, and prints whether each node would
+// be promoted, without updating the nodes. Errors are collected, and returned
+// after all nodes have been inspected.
+func dryRunPromote(ctx context.Context, dockerCli command.Cli, nodes []string, promote func(node *swarm.Node) error) error {
+	var errs []string
+	for _, nodeID := range nodes {
+		node, _, err := dockerCli.Client().NodeInspectWithRaw(ctx, nodeID)
+		if err == nil {
+			err = promote(&node)
+		}
+		switch {
+		case err == errNoRoleChange:
+			_, _ = fmt.Fprintf(dockerCli.Out(), "Node %s is already a manager.\n", nodeID)
+		case err == errOnlyLabelsChanged:
+			_, _ = fmt.Fprintf(dockerCli.Out(), "Node %s is already a manager; its labels would be updated.\n", nodeID)
+		case err != nil:
+			errs = append(errs, fmt.Sprintf("failed to promote node %s: %s", nodeID, err))
+		default:
+			_, _ = fmt.Fprintf(dockerCli.Out(), "Node %s would be promoted to a manager in the swarm.\n", nodeID)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// printPromoteResults prints the result of promoting each node in the same
+// format as promoting nodes one at a time. Errors are collected, and returned
+// after printing all results.
+func printPromoteResults(out io.Writer, results []nodeResult) error {
+	var errs []string
+	for _, result := range results {
+		switch result.Action {
+		case actionSkipped:
+			_, _ = fmt.Fprintf(out, "Node %s is already a manager.\n", result.Node)
+		case actionLabeled:
+			_, _ = fmt.Fprintf(out, "Node %s is already a manager; its labels were updated.\n", result.Node)
+		case actionFailed:
+			errs = append(errs, fmt.Sprintf("failed to promote node %s: %s", result.Node, result.Error))
+		default:
+			_, _ = fmt.Fprintf(out, "Node %s promoted to a manager in the swarm.\n", result.Node)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// labelsEqual returns whether a and b contain the same labels.
+func labelsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// waitForManager polls the node until its role is manager and it has a
+// manager status, or until the timeout expires.
+func waitForManager(ctx context.Context, apiClient client.NodeAPIClient, nodeID string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		node, _, err := apiClient.NodeInspectWithRaw(ctx, nodeID)
+		if err == nil && node.Spec.Role == swarm.NodeRoleManager && node.ManagerStatus != nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return errors.Errorf("timed out after %s waiting for node %s to become a manager", timeout, nodeID)
+			}
+			return ctx.Err()
+		case <-time.After(waitPollInterval):
+		}
 	}
-	return updateNodes(ctx, dockerCli, nodes, promote, success)
 }
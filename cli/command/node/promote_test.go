@@ -3,12 +3,17 @@
 import (
 	"errors"
 	"io"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/docker/cli/cli/streams"
 	"github.com/docker/cli/internal/test"
 	"github.com/docker/cli/internal/test/builders"
 	"github.com/docker/docker/api/types/swarm"
 	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
 )
 
 func TestNodePromoteErrors(t *testing.T) {
@@ -82,3 +87,290 @@ func TestNodePromoteMultipleNode(t *testing.T) {
 	cmd.SetArgs([]string{"nodeID1", "nodeID2"})
 	assert.NilError(t, cmd.Execute())
 }
+
+func TestNodePromoteFromStdin(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			return *builders.Node(), []byte{}, nil
+		},
+	})
+	cli.SetIn(streams.NewIn(io.NopCloser(strings.NewReader("nodeID2\n\n  nodeID3  \n"))))
+	cmd := newPromoteCommand(cli)
+	cmd.SetArgs([]string{"nodeID1", "-"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), `Node nodeID1 promoted to a manager in the swarm.
+Node nodeID2 promoted to a manager in the swarm.
+Node nodeID3 promoted to a manager in the swarm.
+`))
+}
+
+func TestNodePromoteFormatJSON(t *testing.T) {
+	var calls int
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			calls++
+			switch calls {
+			case 1:
+				return *builders.Node(), []byte{}, nil
+			case 2:
+				return *builders.Node(builders.Manager()), []byte{}, nil
+			default:
+				return swarm.Node{}, []byte{}, errors.New("error inspecting the node")
+			}
+		},
+	})
+	cmd := newPromoteCommand(cli)
+	cmd.SetArgs([]string{"--format", "json", "nodeID1", "nodeID2", "nodeID3"})
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "failed to update 1 of 3 node(s)")
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), `[{"node":"nodeID1","action":"promoted"},{"node":"nodeID2","action":"skipped"},{"node":"nodeID3","action":"failed","error":"error inspecting the node"}]
+`))
+}
+
+func TestNodePromoteLabels(t *testing.T) {
+	var spec swarm.NodeSpec
+	cmd := newPromoteCommand(
+		test.NewFakeCli(&fakeClient{
+			nodeInspectFunc: func() (swarm.Node, []byte, error) {
+				return *builders.Node(builders.NodeLabels(map[string]string{"keep": "yes", "remove": "yes", "update": "old"})), []byte{}, nil
+			},
+			nodeUpdateFunc: func(nodeID string, version swarm.Version, node swarm.NodeSpec) error {
+				spec = node
+				return nil
+			},
+		}))
+	cmd.SetArgs([]string{"nodeID"})
+	assert.NilError(t, cmd.Flags().Set(flagLabelAdd, "update=new"))
+	assert.NilError(t, cmd.Flags().Set(flagLabelAdd, "add=yes"))
+	assert.NilError(t, cmd.Flags().Set(flagLabelRemove, "remove"))
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(spec.Role, swarm.NodeRoleManager))
+	assert.Check(t, is.DeepEqual(spec.Annotations.Labels, map[string]string{"keep": "yes", "update": "new", "add": "yes"}))
+}
+
+func TestNodePromoteLabelsAlreadyManager(t *testing.T) {
+	var updated int
+	var spec swarm.NodeSpec
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			return *builders.Node(builders.Manager(), builders.NodeLabels(map[string]string{"keep": "yes"})), []byte{}, nil
+		},
+		nodeUpdateFunc: func(nodeID string, version swarm.Version, node swarm.NodeSpec) error {
+			updated++
+			spec = node
+			return nil
+		},
+	})
+
+	// Labels are applied to nodes that are already a manager.
+	cmd := newPromoteCommand(cli)
+	cmd.SetArgs([]string{"nodeID"})
+	assert.NilError(t, cmd.Flags().Set(flagLabelAdd, "add=yes"))
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(updated, 1))
+	assert.Check(t, is.DeepEqual(spec.Annotations.Labels, map[string]string{"keep": "yes", "add": "yes"}))
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), "is already a manager; its labels were updated"))
+
+	// Nodes are not updated if neither their role nor their labels change.
+	cli.OutBuffer().Reset()
+	cmd = newPromoteCommand(cli)
+	cmd.SetArgs([]string{"nodeID"})
+	assert.NilError(t, cmd.Flags().Set(flagLabelAdd, "keep=yes"))
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(updated, 1))
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), "is already a manager.\n"))
+
+	// The results of nodes of which only the labels changed are reported as such.
+	cmd = newPromoteCommand(cli)
+	cmd.SetArgs([]string{"--format", "{{.Action}}", "nodeID"})
+	assert.NilError(t, cmd.Flags().Set(flagLabelAdd, "add=other"))
+	cli.OutBuffer().Reset()
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(updated, 2))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "labeled\n"))
+}
+
+func TestNodePromoteWait(t *testing.T) {
+	defer func(orig time.Duration) { waitPollInterval = orig }(waitPollInterval)
+	waitPollInterval = time.Millisecond
+
+	var updated bool
+	var inspectCalls int
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			inspectCalls++
+			if !updated || inspectCalls < 4 {
+				return *builders.Node(), []byte{}, nil
+			}
+			return *builders.Node(builders.Manager()), []byte{}, nil
+		},
+		nodeUpdateFunc: func(nodeID string, version swarm.Version, node swarm.NodeSpec) error {
+			updated = true
+			return nil
+		},
+	})
+	cmd := newPromoteCommand(cli)
+	cmd.SetArgs([]string{"--wait", "nodeID"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(inspectCalls, 4))
+}
+
+func TestNodePromoteWaitTimeout(t *testing.T) {
+	defer func(orig time.Duration) { waitPollInterval = orig }(waitPollInterval)
+	waitPollInterval = time.Millisecond
+
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			return *builders.Node(), []byte{}, nil
+		},
+	})
+	cmd := newPromoteCommand(cli)
+	cmd.SetArgs([]string{"--wait", "--wait-timeout", "20ms", "nodeID"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "timed out after 20ms waiting for node nodeID to become a manager")
+}
+
+func TestNodePromoteConfirm(t *testing.T) {
+	testCases := []struct {
+		doc            string
+		args           []string
+		input          string
+		expectedUpdate bool
+		expectedOutput string
+		expectedError  string
+	}{
+		{
+			doc:            "confirmed",
+			args:           []string{"nodeID"},
+			input:          "y\n",
+			expectedUpdate: true,
+			expectedOutput: "Promote node nodeID to manager? [y/N] Node nodeID promoted to a manager in the swarm.\n",
+		},
+		{
+			doc:            "declined",
+			args:           []string{"nodeID1", "nodeID2"},
+			input:          "n\n",
+			expectedOutput: "Promote nodes nodeID1, nodeID2 to manager? [y/N] ",
+			expectedError:  "node promote has been cancelled",
+		},
+		{
+			doc:            "yes",
+			args:           []string{"--yes", "nodeID"},
+			expectedUpdate: true,
+			expectedOutput: "Node nodeID promoted to a manager in the swarm.\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			var updated bool
+			cli := test.NewFakeCli(&fakeClient{
+				nodeInspectFunc: func() (swarm.Node, []byte, error) {
+					return *builders.Node(), []byte{}, nil
+				},
+				nodeUpdateFunc: func(string, swarm.Version, swarm.NodeSpec) error {
+					updated = true
+					return nil
+				},
+			})
+			cli.SetIn(streams.NewIn(io.NopCloser(strings.NewReader(tc.input))))
+			cli.In().SetIsTerminal(true)
+			cmd := newPromoteCommand(cli)
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			err := cmd.Execute()
+			if tc.expectedError != "" {
+				assert.Check(t, is.Error(err, tc.expectedError))
+			} else {
+				assert.Check(t, err)
+			}
+			assert.Check(t, is.Equal(updated, tc.expectedUpdate))
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), tc.expectedOutput))
+		})
+	}
+}
+
+func TestNodePromoteParallel(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectByID: func(nodeID string) (swarm.Node, []byte, error) {
+			return *builders.Node(builders.NodeID(nodeID)), []byte{}, nil
+		},
+		nodeUpdateFunc: func(nodeID string, _ swarm.Version, _ swarm.NodeSpec) error {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				m := maxInFlight.Load()
+				if n <= m || maxInFlight.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			if nodeID == "nodeID2" {
+				return errors.New("error updating the node")
+			}
+			return nil
+		},
+	})
+	cmd := newPromoteCommand(cli)
+	cmd.SetArgs([]string{"--parallel", "2", "nodeID1", "nodeID2", "nodeID3", "nodeID4", "nodeID5"})
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "failed to promote node nodeID2: error updating the node")
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), `Node nodeID1 promoted to a manager in the swarm.
+Node nodeID3 promoted to a manager in the swarm.
+Node nodeID4 promoted to a manager in the swarm.
+Node nodeID5 promoted to a manager in the swarm.
+`))
+	assert.Check(t, maxInFlight.Load() <= 2, "max in-flight updates: %d", maxInFlight.Load())
+}
+
+func TestNodePromoteParallelInvalid(t *testing.T) {
+	cmd := newPromoteCommand(test.NewFakeCli(&fakeClient{}))
+	cmd.SetArgs([]string{"--parallel", "0", "nodeID"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "invalid --parallel value 0: must be at least 1")
+}
+
+func TestNodePromoteDryRun(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectByID: func(nodeID string) (swarm.Node, []byte, error) {
+			switch nodeID {
+			case "manager1":
+				return *builders.Node(builders.NodeID(nodeID), builders.Manager()), []byte{}, nil
+			case "missing":
+				return swarm.Node{}, []byte{}, errors.New("no such node: missing")
+			default:
+				return *builders.Node(builders.NodeID(nodeID)), []byte{}, nil
+			}
+		},
+		nodeUpdateFunc: func(string, swarm.Version, swarm.NodeSpec) error {
+			return errors.New("should not be called")
+		},
+	})
+	cli.In().SetIsTerminal(true)
+	cmd := newPromoteCommand(cli)
+	cmd.SetArgs([]string{"--dry-run", "worker1", "manager1", "worker2"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), `Node worker1 would be promoted to a manager in the swarm.
+Node manager1 is already a manager.
+Node worker2 would be promoted to a manager in the swarm.
+`))
+
+	cli.ResetOutputBuffers()
+	cmd = newPromoteCommand(cli)
+	cmd.SetArgs([]string{"--dry-run", "worker1", "missing"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "failed to promote node missing: no such node: missing")
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "Node worker1 would be promoted to a manager in the swarm.\n"))
+
+	cmd = newPromoteCommand(cli)
+	cmd.SetArgs([]string{"--dry-run", "--wait", "worker1"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "--dry-run cannot be combined with --wait or --format")
+}
@@ -7,12 +7,14 @@
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
+	flagsHelper "github.com/docker/cli/cli/flags"
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/spf13/cobra"
 )
 
 type removeOptions struct {
-	force bool
+	force  bool
+	format string
 }
 
 func newRemoveCommand(dockerCli command.Cli) *cobra.Command {
@@ -30,19 +32,48 @@ func newRemoveCommand(dockerCli command.Cli) *cobra.Command {
 	}
 	flags := cmd.Flags()
 	flags.BoolVarP(&opts.force, "force", "f", false, "Force remove a node from the swarm")
+	flags.StringVar(&opts.format, "format", "", flagsHelper.InspectFormatHelp)
 	return cmd
 }
 
 func runRemove(ctx context.Context, dockerCLI command.Cli, nodeIDs []string, opts removeOptions) error {
 	apiClient := dockerCLI.Client()
 
-	var errs []error
+	results := make([]nodeResult, 0, len(nodeIDs))
+	removeErrs := make([]error, 0, len(nodeIDs))
 	for _, id := range nodeIDs {
-		if err := apiClient.NodeRemove(ctx, id, swarm.NodeRemoveOptions{Force: opts.force}); err != nil {
-			errs = append(errs, err)
+		result := nodeResult{Node: id, Action: "removed"}
+		err := apiClient.NodeRemove(ctx, id, swarm.NodeRemoveOptions{Force: opts.force})
+		if err != nil {
+			result.Action = actionFailed
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+		removeErrs = append(removeErrs, err)
+	}
+	if opts.format != "" {
+		return writeNodeResults(dockerCLI.Out(), opts.format, "remove", results)
+	}
+
+	if len(nodeIDs) == 1 {
+		if removeErrs[0] != nil {
+			return removeErrs[0]
+		}
+		_, _ = fmt.Fprintln(dockerCLI.Out(), nodeIDs[0])
+		return nil
+	}
+
+	var errs []error
+	for i, id := range nodeIDs {
+		if err := removeErrs[i]; err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove node %s: %w", id, err))
 			continue
 		}
 		_, _ = fmt.Fprintln(dockerCLI.Out(), id)
 	}
+	if len(errs) == 0 {
+		return nil
+	}
+	errs = append(errs, fmt.Errorf("failed to remove %d of %d node(s)", len(errs), len(nodeIDs)))
 	return errors.Join(errs...)
 }
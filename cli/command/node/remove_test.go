@@ -5,14 +5,17 @@
 	"io"
 	"testing"
 
+	cerrdefs "github.com/containerd/errdefs"
 	"github.com/docker/cli/internal/test"
+	"github.com/spf13/cobra"
 	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
 )
 
 func TestNodeRemoveErrors(t *testing.T) {
 	testCases := []struct {
 		args           []string
-		nodeRemoveFunc func() error
+		nodeRemoveFunc func(string) error
 		expectedError  string
 	}{
 		{
@@ -20,7 +23,7 @@ func TestNodeRemoveErrors(t *testing.T) {
 		},
 		{
 			args: []string{"nodeID"},
-			nodeRemoveFunc: func() error {
+			nodeRemoveFunc: func(string) error {
 				return errors.New("error removing the node")
 			},
 			expectedError: "error removing the node",
@@ -43,3 +46,60 @@ func TestNodeRemoveMultiple(t *testing.T) {
 	cmd.SetArgs([]string{"nodeID1", "nodeID2"})
 	assert.NilError(t, cmd.Execute())
 }
+
+func TestNodeRemoveBatch(t *testing.T) {
+	newClient := func() *fakeClient {
+		return &fakeClient{
+			nodeRemoveFunc: func(nodeID string) error {
+				if nodeID == "nodeID2" {
+					return errors.New("node nodeID2 is not down and can't be removed")
+				}
+				return nil
+			},
+		}
+	}
+
+	cli := test.NewFakeCli(newClient())
+	cmd := newRemoveCommand(cli)
+	cmd.SetArgs([]string{"nodeID1", "nodeID2", "nodeID3"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), `failed to remove node nodeID2: node nodeID2 is not down and can't be removed
+failed to remove 1 of 3 node(s)`)
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "nodeID1\nnodeID3\n"))
+
+	cli = test.NewFakeCli(newClient())
+	cmd = newRemoveCommand(cli)
+	cmd.SetArgs([]string{"--format", "json", "nodeID1", "nodeID2"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "failed to remove 1 of 2 node(s)")
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), `[{"node":"nodeID1","action":"removed"},{"node":"nodeID2","action":"failed","error":"node nodeID2 is not down and can't be removed"}]
+`))
+}
+
+func TestNodeRemoveNotFound(t *testing.T) {
+	notFound := cerrdefs.ErrNotFound.WithMessage("node nodeID2 not found")
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := newRemoveCommand(test.NewFakeCli(&fakeClient{
+			nodeRemoveFunc: func(nodeID string) error {
+				if nodeID == "nodeID2" {
+					return notFound
+				}
+				return nil
+			},
+		}))
+		cmd.SetArgs(args)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		return cmd
+	}
+
+	err := newCmd("nodeID2").Execute()
+	assert.Check(t, is.Error(err, "node nodeID2 not found"))
+	assert.Check(t, cerrdefs.IsNotFound(err))
+
+	err = newCmd("nodeID1", "nodeID2").Execute()
+	assert.Check(t, is.Error(err, "failed to remove node nodeID2: node nodeID2 not found\nfailed to remove 1 of 2 node(s)"))
+	assert.Check(t, cerrdefs.IsNotFound(err))
+}
@@ -1,30 +1,61 @@
+// FIXME(thaJeztah): remove once we are a module; the go:build directive prevents go from downgrading language version to go1.16:
+//go:build go1.23
+
 package node
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/cli/cli/command/inspect"
+	"github.com/docker/cli/internal/prompt"
 	"github.com/docker/cli/opts"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/fvbommel/sortorder"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"golang.org/x/sync/errgroup"
 )
 
 var errNoRoleChange = errors.New("role was already set to the requested value")
 
+// errOnlyLabelsChanged is returned by a mergeNode function if the role of
+// the node was already set to the requested value, but its labels changed.
+// Unlike errNoRoleChange, the node is still updated.
+var errOnlyLabelsChanged = errors.New("role was already set to the requested value, but labels changed")
+
+// drainPollInterval is the interval at which the tasks of a node are polled
+// when waiting for the node to be drained.
+var drainPollInterval = time.Second
+
 func newUpdateCommand(dockerCli command.Cli) *cobra.Command {
 	options := newNodeOptions()
 
 	cmd := &cobra.Command{
 		Use:   "update [OPTIONS] NODE",
 		Short: "Update a node",
-		Args:  cli.ExactArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed(flagSelector) {
+				return cli.NoArgs(cmd, args)
+			}
+			return cli.ExactArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runUpdate(cmd.Context(), dockerCli, cmd.Flags(), args[0])
+			if cmd.Flags().Changed(flagSelector) {
+				return runUpdateSelector(cmd.Context(), dockerCli, cmd.Flags(), options)
+			}
+			return runUpdate(cmd.Context(), dockerCli, cmd.Flags(), options, args[0])
 		},
 		ValidArgsFunction: completeNodeNames(dockerCli),
 	}
@@ -35,6 +66,9 @@ func newUpdateCommand(dockerCli command.Cli) *cobra.Command {
 	flags.Var(&options.annotations.labels, flagLabelAdd, `Add or update a node label ("key=value")`)
 	labelKeys := opts.NewListOpts(nil)
 	flags.Var(&labelKeys, flagLabelRemove, "Remove a node label if exists")
+	flags.BoolVar(&options.waitDrained, flagWaitDrained, false, `Wait for all tasks to be removed from the node (requires "--availability drain")`)
+	flags.DurationVar(&options.waitTimeout, flagWaitTimeout, 5*time.Minute, "Maximum time to wait for the node to be drained")
+	flags.Var(&options.selector, flagSelector, `Update all nodes with the given label ("key" or "key=value") instead of a single node`)
 
 	_ = cmd.RegisterFlagCompletionFunc(flagRole, completion.FromList("worker", "manager"))
 	_ = cmd.RegisterFlagCompletionFunc(flagAvailability, completion.FromList("active", "pause", "drain"))
@@ -47,38 +81,307 @@ func newUpdateCommand(dockerCli command.Cli) *cobra.Command {
 	return cmd
 }
 
-func runUpdate(ctx context.Context, dockerCli command.Cli, flags *pflag.FlagSet, nodeID string) error {
+// validateUpdateOptions validates the options that are common to updating a
+// single node, and updating the nodes matching a selector.
+func validateUpdateOptions(flags *pflag.FlagSet, options *nodeOptions) error {
+	if options.waitDrained && swarm.NodeAvailability(options.availability) != swarm.NodeAvailabilityDrain {
+		return errors.Errorf(`--%s can only be used with "--%s drain"`, flagWaitDrained, flagAvailability)
+	}
+	if flags.Changed(flagRole) {
+		switch swarm.NodeRole(options.role) {
+		case swarm.NodeRoleWorker, swarm.NodeRoleManager:
+		default:
+			return errors.Errorf(`invalid role %q, only "worker" and "manager" are supported`, options.role)
+		}
+	}
+	return nil
+}
+
+func runUpdate(ctx context.Context, dockerCli command.Cli, flags *pflag.FlagSet, options *nodeOptions, nodeID string) error {
+	if err := validateUpdateOptions(flags, options); err != nil {
+		return err
+	}
+
+	// Keep track of the role of the node before updating it, so that the
+	// outcome of changing the role can be reported.
+	var previousRole swarm.NodeRole
+	merge := mergeNodeUpdate(flags)
+	mergeNode := func(node *swarm.Node) error {
+		previousRole = node.Spec.Role
+		return merge(node)
+	}
 	success := func(_ string) {
-		fmt.Fprintln(dockerCli.Out(), nodeID)
+		switch {
+		case !flags.Changed(flagRole):
+			_, _ = fmt.Fprintln(dockerCli.Out(), nodeID)
+		case previousRole == swarm.NodeRole(options.role):
+			_, _ = fmt.Fprintf(dockerCli.Out(), "Node %s is already a %s.\n", nodeID, options.role)
+		default:
+			_, _ = fmt.Fprintf(dockerCli.Out(), "Node %s role changed to %s.\n", nodeID, options.role)
+		}
+	}
+	if err := updateNodes(ctx, dockerCli, []string{nodeID}, mergeNode, success); err != nil {
+		return err
+	}
+	if options.waitDrained {
+		return waitDrained(ctx, dockerCli, nodeID, options.waitTimeout)
 	}
-	return updateNodes(ctx, dockerCli, []string{nodeID}, mergeNodeUpdate(flags), success)
+	return nil
 }
 
-func updateNodes(ctx context.Context, dockerCli command.Cli, nodes []string, mergeNode func(node *swarm.Node) error, success func(nodeID string)) error {
-	client := dockerCli.Client()
+// runUpdateSelector updates all nodes that match the labels given through
+// --selector. All matching nodes are processed, even if updating one of them
+// fails, and a summary is printed after all nodes were updated.
+func runUpdateSelector(ctx context.Context, dockerCli command.Cli, flags *pflag.FlagSet, options *nodeOptions) error {
+	if err := validateUpdateOptions(flags, options); err != nil {
+		return err
+	}
+	selector := options.selector.GetSlice()
+	nodes, err := dockerCli.Client().NodeList(ctx, swarm.NodeListOptions{})
+	if err != nil {
+		return err
+	}
+	nodes = filterNodesByLabels(nodes, selector)
+	if len(nodes) == 0 {
+		return errors.Errorf("no nodes match --%s %s", flagSelector, strings.Join(selector, ","))
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return sortorder.NaturalLess(nodes[i].Description.Hostname, nodes[j].Description.Hostname)
+	})
+	nodeIDs := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		nodeIDs = append(nodeIDs, node.ID)
+	}
 
-	for _, nodeID := range nodes {
-		node, _, err := client.NodeInspectWithRaw(ctx, nodeID)
+	results := updateNodesResults(ctx, dockerCli.Client(), nodeIDs, mergeNodeUpdate(flags), "updated", 1)
+	var errs []string
+	for i, result := range results {
+		if result.Error == "" && options.waitDrained {
+			if err := waitDrained(ctx, dockerCli, result.Node, options.waitTimeout); err != nil {
+				results[i].Action = actionFailed
+				results[i].Error = err.Error()
+			}
+		}
+		if results[i].Error != "" {
+			errs = append(errs, fmt.Sprintf("failed to update node %s: %s", result.Node, results[i].Error))
+			continue
+		}
+		_, _ = fmt.Fprintln(dockerCli.Out(), result.Node)
+	}
+	if len(errs) > 0 {
+		errs = append(errs, fmt.Sprintf("failed to update %d of %d node(s)", len(errs), len(results)))
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	_, _ = fmt.Fprintf(dockerCli.Err(), "Updated %d node(s).\n", len(results))
+	return nil
+}
+
+// waitDrained polls the tasks of the given node until no tasks with a
+// desired state of "running" remain, or until the timeout expires. Progress
+// is reported on stderr.
+func waitDrained(ctx context.Context, dockerCli command.Cli, nodeID string, timeout time.Duration) error {
+	apiClient := dockerCli.Client()
+	node, _, err := apiClient.NodeInspectWithRaw(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	filter := filters.NewArgs(
+		filters.Arg("node", node.ID),
+		filters.Arg("desired-state", string(swarm.TaskStateRunning)),
+	)
+	remaining := -1
+	for {
+		tasks, err := apiClient.TaskList(ctx, swarm.TaskListOptions{Filters: filter})
 		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return errors.Errorf("timed out after %s waiting for node %s to be drained", timeout, nodeID)
+			}
 			return err
 		}
+		if len(tasks) == 0 {
+			_, _ = fmt.Fprintf(dockerCli.Err(), "Node %s is drained.\n", nodeID)
+			return nil
+		}
+		if len(tasks) != remaining {
+			remaining = len(tasks)
+			_, _ = fmt.Fprintf(dockerCli.Err(), "Waiting for %d task(s) to be removed from node %s...\n", remaining, nodeID)
+		}
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return errors.Errorf("timed out after %s waiting for node %s to be drained: %d task(s) remaining", timeout, nodeID, remaining)
+			}
+			return ctx.Err()
+		case <-time.After(drainPollInterval):
+		}
+	}
+}
 
-		err = mergeNode(&node)
+func updateNodes(ctx context.Context, dockerCli command.Cli, nodes []string, mergeNode func(node *swarm.Node) error, success func(nodeID string)) error {
+	apiClient := dockerCli.Client()
+
+	for _, nodeID := range nodes {
+		err := updateNode(ctx, apiClient, nodeID, mergeNode)
 		if err != nil {
 			if err == errNoRoleChange {
 				continue
 			}
 			return err
 		}
-		err = client.NodeUpdate(ctx, node.ID, node.Version, node.Spec)
-		if err != nil {
+		success(nodeID)
+	}
+	return nil
+}
+
+func updateNode(ctx context.Context, apiClient client.NodeAPIClient, nodeID string, mergeNode func(node *swarm.Node) error) error {
+	node, _, err := apiClient.NodeInspectWithRaw(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+	mergeErr := mergeNode(&node)
+	if mergeErr != nil && mergeErr != errOnlyLabelsChanged {
+		return mergeErr
+	}
+	if err := apiClient.NodeUpdate(ctx, node.ID, node.Version, node.Spec); err != nil {
+		return err
+	}
+	return mergeErr
+}
+
+// nodeResult is the outcome of an operation on a single node, as printed
+// by commands that support formatting their results.
+type nodeResult struct {
+	Node   string `json:"node"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+const (
+	actionSkipped = "skipped"
+	actionFailed  = "failed"
+	actionLabeled = "labeled"
+)
+
+// updateNodesResults is similar to updateNodes, but continues with the
+// remaining nodes if updating a node fails, and returns the result for
+// each node in the order given. Up to parallel nodes are updated at the
+// same time.
+func updateNodesResults(ctx context.Context, apiClient client.NodeAPIClient, nodes []string, mergeNode func(node *swarm.Node) error, action string, parallel int) []nodeResult {
+	results := make([]nodeResult, len(nodes))
+	var eg errgroup.Group
+	eg.SetLimit(max(parallel, 1))
+	for i, nodeID := range nodes {
+		eg.Go(func() error {
+			result := nodeResult{Node: nodeID, Action: action}
+			if err := updateNode(ctx, apiClient, nodeID, mergeNode); err != nil {
+				switch err {
+				case errNoRoleChange:
+					result.Action = actionSkipped
+				case errOnlyLabelsChanged:
+					result.Action = actionLabeled
+				default:
+					result.Action = actionFailed
+					result.Error = err.Error()
+				}
+			}
+			results[i] = result
+			return nil
+		})
+	}
+	_ = eg.Wait()
+	return results
+}
+
+// writeNodeResults writes the results using the given format, which is
+// either "json" or a Go template. It returns an error if any of the results
+// is a failure, so that the command exits with a non-zero status after
+// printing all results. The verb describes the operation in that error, for
+// example "update".
+func writeNodeResults(out io.Writer, format string, verb string, results []nodeResult) error {
+	nodeInspector, err := inspect.NewTemplateInspectorFromString(out, format)
+	if err != nil {
+		return cli.StatusError{StatusCode: 64, Status: err.Error()}
+	}
+	for _, result := range results {
+		if err := nodeInspector.Inspect(result, nil); err != nil {
 			return err
 		}
-		success(nodeID)
+	}
+	if err := nodeInspector.Flush(); err != nil {
+		return err
+	}
+	return nodeResultsError(verb, results)
+}
+
+// nodeResultsError returns an error if any of the results is a failure. The
+// verb describes the operation in that error, for example "update".
+func nodeResultsError(verb string, results []nodeResult) error {
+	var failed int
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return cli.StatusError{StatusCode: 1, Status: fmt.Sprintf("failed to %s %d of %d node(s)", verb, failed, len(results))}
+	}
+	return nil
+}
+
+// confirmRoleChange prompts the user to confirm changing the role of the
+// given nodes, unless confirmation is skipped, or stdin is not a terminal.
+// It returns an error if the user declined.
+func confirmRoleChange(ctx context.Context, dockerCli command.Cli, nodes []string, verb string, role swarm.NodeRole, skip bool) error {
+	if skip || !dockerCli.In().IsTerminal() {
+		return nil
+	}
+	subject := "node " + nodes[0]
+	if len(nodes) > 1 {
+		subject = "nodes " + strings.Join(nodes, ", ")
+	}
+	// e.g. "Promote node X to manager?"
+	message := fmt.Sprintf("%s %s to %s?", verb, subject, role)
+	r, err := prompt.Confirm(ctx, dockerCli.In(), dockerCli.Out(), message)
+	if err != nil {
+		return err
+	}
+	if !r {
+		return cancelledErr{errors.Errorf("node %s has been cancelled", strings.ToLower(verb))}
 	}
 	return nil
 }
 
+type cancelledErr struct{ error }
+
+func (cancelledErr) Cancelled() {}
+
+// readNodeIDs returns the given node IDs, replacing the special "-" argument
+// with the newline-separated list of node IDs read from in. Empty lines are
+// ignored.
+func readNodeIDs(in io.Reader, args []string) ([]string, error) {
+	nodes := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg != "-" {
+			nodes = append(nodes, arg)
+			continue
+		}
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			if nodeID := strings.TrimSpace(scanner.Text()); nodeID != "" {
+				nodes = append(nodes, nodeID)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, errors.Wrap(err, "failed to read node IDs from stdin")
+		}
+	}
+	return nodes, nil
+}
+
 func mergeNodeUpdate(flags *pflag.FlagSet) func(*swarm.Node) error {
 	return func(node *swarm.Node) error {
 		spec := &node.Spec
@@ -97,32 +400,42 @@ func mergeNodeUpdate(flags *pflag.FlagSet) func(*swarm.Node) error {
 			}
 			spec.Availability = swarm.NodeAvailability(str)
 		}
-		if spec.Annotations.Labels == nil {
-			spec.Annotations.Labels = make(map[string]string)
-		}
+		var labels, keys []string
 		if flags.Changed(flagLabelAdd) {
-			labels := flags.Lookup(flagLabelAdd).Value.(*opts.ListOpts).GetSlice()
-			for k, v := range opts.ConvertKVStringsToMap(labels) {
-				spec.Annotations.Labels[k] = v
-			}
+			labels = flags.Lookup(flagLabelAdd).Value.(*opts.ListOpts).GetSlice()
 		}
 		if flags.Changed(flagLabelRemove) {
-			keys := flags.Lookup(flagLabelRemove).Value.(*opts.ListOpts).GetSlice()
-			for _, k := range keys {
-				// if a key doesn't exist, fail the command explicitly
-				if _, exists := spec.Annotations.Labels[k]; !exists {
-					return errors.Errorf("key %s doesn't exist in node's labels", k)
-				}
-				delete(spec.Annotations.Labels, k)
-			}
+			keys = flags.Lookup(flagLabelRemove).Value.(*opts.ListOpts).GetSlice()
 		}
-		return nil
+		return mergeLabels(spec, labels, keys)
 	}
 }
 
+// mergeLabels adds the given "key=value" labels to the node's existing
+// labels, and removes the labels with the given keys.
+func mergeLabels(spec *swarm.NodeSpec, labels []string, keys []string) error {
+	if spec.Annotations.Labels == nil && len(labels) > 0 {
+		spec.Annotations.Labels = make(map[string]string)
+	}
+	for k, v := range opts.ConvertKVStringsToMap(labels) {
+		spec.Annotations.Labels[k] = v
+	}
+	for _, k := range keys {
+		// if a key doesn't exist, fail the command explicitly
+		if _, exists := spec.Annotations.Labels[k]; !exists {
+			return errors.Errorf("key %s doesn't exist in node's labels", k)
+		}
+		delete(spec.Annotations.Labels, k)
+	}
+	return nil
+}
+
 const (
 	flagRole         = "role"
 	flagAvailability = "availability"
 	flagLabelAdd     = "label-add"
 	flagLabelRemove  = "label-rm"
+	flagWaitDrained  = "wait-drained"
+	flagWaitTimeout  = "wait-timeout"
+	flagSelector     = "selector"
 )
@@ -5,11 +5,13 @@
 	"fmt"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/docker/cli/internal/test"
 	"github.com/docker/cli/internal/test/builders"
 	"github.com/docker/docker/api/types/swarm"
 	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
 )
 
 func TestNodeUpdateErrors(t *testing.T) {
@@ -53,6 +55,13 @@ func TestNodeUpdateErrors(t *testing.T) {
 			},
 			expectedError: "key notpresent doesn't exist in node's labels",
 		},
+		{
+			args: []string{"nodeID"},
+			flags: map[string]string{
+				"wait-drained": "true",
+			},
+			expectedError: `--wait-drained can only be used with "--availability drain"`,
+		},
 	}
 	for _, tc := range testCases {
 		cmd := newUpdateCommand(
@@ -168,3 +177,195 @@ func TestNodeUpdate(t *testing.T) {
 		assert.NilError(t, cmd.Execute())
 	}
 }
+
+func TestNodeUpdateRole(t *testing.T) {
+	testCases := []struct {
+		doc            string
+		node           *swarm.Node
+		role           string
+		expectedOutput string
+		expectedError  string
+	}{
+		{
+			doc:            "promote worker",
+			node:           builders.Node(),
+			role:           "manager",
+			expectedOutput: "Node nodeID role changed to manager.\n",
+		},
+		{
+			doc:            "already manager",
+			node:           builders.Node(builders.Manager()),
+			role:           "manager",
+			expectedOutput: "Node nodeID is already a manager.\n",
+		},
+		{
+			doc:            "demote manager",
+			node:           builders.Node(builders.Manager()),
+			role:           "worker",
+			expectedOutput: "Node nodeID role changed to worker.\n",
+		},
+		{
+			doc:            "already worker",
+			node:           builders.Node(),
+			role:           "worker",
+			expectedOutput: "Node nodeID is already a worker.\n",
+		},
+		{
+			doc:           "invalid role",
+			node:          builders.Node(),
+			role:          "leader",
+			expectedError: `invalid role "leader", only "worker" and "manager" are supported`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			var updated swarm.NodeSpec
+			cli := test.NewFakeCli(&fakeClient{
+				nodeInspectFunc: func() (swarm.Node, []byte, error) {
+					return *tc.node, []byte{}, nil
+				},
+				nodeUpdateFunc: func(_ string, _ swarm.Version, node swarm.NodeSpec) error {
+					updated = node
+					return nil
+				},
+			})
+			cmd := newUpdateCommand(cli)
+			cmd.SetArgs([]string{"--role", tc.role, "nodeID"})
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			err := cmd.Execute()
+			if tc.expectedError != "" {
+				assert.Check(t, is.Error(err, tc.expectedError))
+				return
+			}
+			assert.NilError(t, err)
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), tc.expectedOutput))
+			assert.Check(t, is.Equal(updated.Role, swarm.NodeRole(tc.role)))
+		})
+	}
+}
+
+func TestNodeUpdateWaitDrained(t *testing.T) {
+	defer func(orig time.Duration) { drainPollInterval = orig }(drainPollInterval)
+	drainPollInterval = time.Millisecond
+
+	var polls int
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			return *builders.Node(builders.NodeID("nodeID")), []byte{}, nil
+		},
+		taskListFunc: func(options swarm.TaskListOptions) ([]swarm.Task, error) {
+			assert.Check(t, is.DeepEqual(options.Filters.Get("node"), []string{"nodeID"}))
+			assert.Check(t, is.DeepEqual(options.Filters.Get("desired-state"), []string{"running"}))
+			polls++
+			// Two tasks remaining for the first two polls, then one, then none.
+			switch polls {
+			case 1, 2:
+				return []swarm.Task{*builders.Task(), *builders.Task()}, nil
+			case 3:
+				return []swarm.Task{*builders.Task()}, nil
+			}
+			return nil, nil
+		},
+	})
+	cmd := newUpdateCommand(cli)
+	cmd.SetArgs([]string{"nodeID"})
+	assert.Check(t, cmd.Flags().Set("availability", "drain"))
+	assert.Check(t, cmd.Flags().Set("wait-drained", "true"))
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(polls, 4))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "nodeID\n"))
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), `Waiting for 2 task(s) to be removed from node nodeID...
+Waiting for 1 task(s) to be removed from node nodeID...
+Node nodeID is drained.
+`))
+}
+
+func TestNodeUpdateWaitDrainedTimeout(t *testing.T) {
+	defer func(orig time.Duration) { drainPollInterval = orig }(drainPollInterval)
+	drainPollInterval = time.Millisecond
+
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			return *builders.Node(builders.NodeID("nodeID")), []byte{}, nil
+		},
+		taskListFunc: func(options swarm.TaskListOptions) ([]swarm.Task, error) {
+			return []swarm.Task{*builders.Task()}, nil
+		},
+	})
+	cmd := newUpdateCommand(cli)
+	cmd.SetArgs([]string{"nodeID"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, cmd.Flags().Set("availability", "drain"))
+	assert.Check(t, cmd.Flags().Set("wait-drained", "true"))
+	assert.Check(t, cmd.Flags().Set("wait-timeout", "20ms"))
+	assert.Error(t, cmd.Execute(), "timed out after 20ms waiting for node nodeID to be drained: 1 task(s) remaining")
+}
+
+func TestNodeUpdateSelector(t *testing.T) {
+	nodes := []swarm.Node{
+		*builders.Node(builders.NodeID("nodeID1"), builders.Hostname("node1"), builders.NodeLabels(map[string]string{"role": "db"})),
+		*builders.Node(builders.NodeID("nodeID2"), builders.Hostname("node2"), builders.NodeLabels(map[string]string{"role": "web"})),
+		*builders.Node(builders.NodeID("nodeID3"), builders.Hostname("node3"), builders.NodeLabels(map[string]string{"role": "db"})),
+	}
+	newClient := func(updated map[string]swarm.NodeAvailability) *fakeClient {
+		return &fakeClient{
+			nodeListFunc: func() ([]swarm.Node, error) {
+				return nodes, nil
+			},
+			nodeInspectByID: func(nodeID string) (swarm.Node, []byte, error) {
+				for _, n := range nodes {
+					if n.ID == nodeID {
+						return n, []byte{}, nil
+					}
+				}
+				return swarm.Node{}, []byte{}, errors.New("no such node: " + nodeID)
+			},
+			nodeUpdateFunc: func(nodeID string, _ swarm.Version, spec swarm.NodeSpec) error {
+				if nodeID == "nodeID3" && updated == nil {
+					return errors.New("error updating the node")
+				}
+				if updated != nil {
+					updated[nodeID] = spec.Availability
+				}
+				return nil
+			},
+		}
+	}
+
+	updated := map[string]swarm.NodeAvailability{}
+	cli := test.NewFakeCli(newClient(updated))
+	cmd := newUpdateCommand(cli)
+	cmd.SetArgs([]string{"--availability", "drain", "--selector", "role=db"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.DeepEqual(updated, map[string]swarm.NodeAvailability{
+		"nodeID1": swarm.NodeAvailabilityDrain,
+		"nodeID3": swarm.NodeAvailabilityDrain,
+	}))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "nodeID1\nnodeID3\n"))
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "Updated 2 node(s).\n"))
+
+	cli = test.NewFakeCli(newClient(nil))
+	cmd = newUpdateCommand(cli)
+	cmd.SetArgs([]string{"--availability", "drain", "--selector", "role=db"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), `failed to update node nodeID3: error updating the node
+failed to update 1 of 2 node(s)`)
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "nodeID1\n"))
+
+	cmd = newUpdateCommand(test.NewFakeCli(newClient(nil)))
+	cmd.SetArgs([]string{"--availability", "drain", "--selector", "role=cache"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "no nodes match --selector role=cache")
+
+	cmd = newUpdateCommand(test.NewFakeCli(newClient(nil)))
+	cmd.SetArgs([]string{"--availability", "drain", "--selector", "role=db", "nodeID1"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.ErrorContains(t, cmd.Execute(), "accepts no arguments")
+}
@@ -18,15 +18,20 @@ func NewPluginCommand(dockerCli command.Cli) *cobra.Command {
 
 	cmd.AddCommand(
 		newDisableCommand(dockerCli),
+		newDoctorCommand(dockerCli),
 		newEnableCommand(dockerCli),
+		newInitCLICommand(dockerCli),
 		newInspectCommand(dockerCli),
 		newInstallCommand(dockerCli),
+		newInstallCLICommand(dockerCli),
 		newListCommand(dockerCli),
 		newRemoveCommand(dockerCli),
 		newSetCommand(dockerCli),
+		newPruneCacheCommand(dockerCli),
 		newPushCommand(dockerCli),
 		newCreateCommand(dockerCli),
 		newUpgradeCommand(dockerCli),
+		newWhichCommand(dockerCli),
 	)
 	return cmd
 }
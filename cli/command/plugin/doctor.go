@@ -0,0 +1,81 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli-plugins/manager"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/cli/cli/command/formatter/tabwriter"
+	"github.com/fvbommel/sortorder"
+	"github.com/spf13/cobra"
+)
+
+func newDoctorCommand(dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose problems with CLI plugins",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctor(dockerCli, cmd.Root())
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+}
+
+// runDoctor runs all CLI plugin candidates, including shadowed candidates,
+// and prints a table with the status of each candidate, and why it is not a
+// valid plugin, followed by the plugin aliases that are not used. It returns
+// a [cli.StatusError] if problems were found, so that the command exits with
+// a non-zero status.
+func runDoctor(dockerCli command.Cli, rootCmd *cobra.Command) error {
+	candidates, err := manager.DiagnosePluginCandidates(dockerCli, rootCmd)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(candidates))
+	for name := range candidates {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return sortorder.NaturalLess(names[i], names[j])
+	})
+
+	var problems int
+	tw := tabwriter.NewWriter(dockerCli.Out(), 10, 1, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "NAME\tSTATUS\tPROBLEM\tPATH\tDETAILS")
+	for _, name := range names {
+		for _, c := range candidates[name] {
+			status, problem, details := "active", "-", ""
+			switch {
+			case c.Err != nil:
+				status, problem, details = "invalid", string(c.Problem), c.Err.Error()
+				problems++
+			case c.Shadowed:
+				status = "shadowed"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", name, status, problem, c.Path, details)
+		}
+	}
+	_ = tw.Flush()
+
+	aliasErrs := manager.CheckPluginAliases(dockerCli, rootCmd)
+	if len(aliasErrs) > 0 {
+		_, _ = fmt.Fprintln(dockerCli.Out(), "\nPlugin aliases:")
+		for _, err := range aliasErrs {
+			_, _ = fmt.Fprintln(dockerCli.Out(), "  "+err.Error())
+		}
+	}
+
+	switch {
+	case problems == 0 && len(aliasErrs) == 0:
+		_, _ = fmt.Fprintln(dockerCli.Out(), "\nNo problems found")
+		return nil
+	case len(aliasErrs) == 0:
+		return cli.StatusError{StatusCode: 1, Status: fmt.Sprintf("found %d invalid CLI plugin candidate(s)", problems)}
+	default:
+		return cli.StatusError{StatusCode: 1, Status: fmt.Sprintf("found %d invalid CLI plugin candidate(s) and %d plugin alias problem(s)", problems, len(aliasErrs))}
+	}
+}
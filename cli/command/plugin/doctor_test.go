@@ -0,0 +1,66 @@
+package plugin
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestDoctor(t *testing.T) {
+	const valid = `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("plugins1",
+			fs.WithFile("docker-aaa", valid, fs.WithMode(0o777)),
+			fs.WithFile("docker-bbb", "#!/bin/sh\necho 'not json'", fs.WithMode(0o777)),
+		),
+		fs.WithDir("plugins2",
+			fs.WithFile("docker-aaa", valid, fs.WithMode(0o777)),
+		),
+	)
+	defer dir.Remove()
+
+	dockerCLI := test.NewFakeCli(&fakeClient{})
+	dockerCLI.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs: []string{dir.Join("plugins1"), dir.Join("plugins2")},
+		CLIPluginsAliases:   map[string]string{"aaa": "bbb", "a": "aaa"},
+	})
+	cmd := newDoctorCommand(dockerCLI)
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	err := cmd.Execute()
+	assert.Check(t, is.Error(err, "found 1 invalid CLI plugin candidate(s) and 1 plugin alias problem(s)"))
+	var statusErr cli.StatusError
+	assert.Check(t, errors.As(err, &statusErr))
+	assert.Check(t, is.Equal(statusErr.StatusCode, 1))
+
+	out := dockerCLI.OutBuffer().String()
+	assert.Check(t, is.Contains(out, "NAME      STATUS     PROBLEM            PATH"))
+	assert.Check(t, is.Contains(out, "aaa       active     -                  "+dir.Join("plugins1", "docker-aaa")))
+	assert.Check(t, is.Contains(out, "aaa       shadowed   -                  "+dir.Join("plugins2", "docker-aaa")))
+	assert.Check(t, is.Contains(out, "bbb       invalid    invalid metadata   "+dir.Join("plugins1", "docker-bbb")+`   invalid metadata output: expected JSON, but the plugin printed "not json"`))
+	assert.Check(t, is.Contains(out, "\nPlugin aliases:\n"+`  plugin alias "aaa" is not used: a CLI plugin with the same name is installed`+"\n"))
+}
+
+func TestDoctorNoProblems(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	dockerCLI := test.NewFakeCli(&fakeClient{})
+	dockerCLI.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+	cmd := newDoctorCommand(dockerCLI)
+	cmd.SetArgs([]string{})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Contains(dockerCLI.OutBuffer().String(), "\nNo problems found\n"))
+}
@@ -0,0 +1,125 @@
+package plugin
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli-plugins/manager"
+	"github.com/docker/cli/cli-plugins/metadata"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// initCLIGoVersion is the minimum Go version of generated plugin projects.
+const initCLIGoVersion = "1.23"
+
+//go:embed templates/initcli/*.tmpl
+var initCLITemplates embed.FS
+
+type initCLIOptions struct {
+	name             string
+	dir              string
+	module           string
+	vendor           string
+	shortDescription string
+	force            bool
+}
+
+func newInitCLICommand(dockerCli command.Cli) *cobra.Command {
+	var opts initCLIOptions
+
+	cmd := &cobra.Command{
+		Use:   "init-cli [OPTIONS] NAME",
+		Short: "Generate a Go project for a new CLI plugin",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.name = args[0]
+			return runInitCLI(dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.dir, "output", "o", "", `Directory to generate the project in (default "./docker-NAME")`)
+	flags.StringVar(&opts.module, "module", "", `Go module path of the project (default "example.com/docker-NAME")`)
+	flags.StringVar(&opts.vendor, "vendor", "Example", "Vendor of the plugin, as shown in the plugin's metadata")
+	flags.StringVar(&opts.shortDescription, "description", "", "Short description of the plugin")
+	flags.BoolVarP(&opts.force, "force", "f", false, "Generate the project in a directory that is not empty")
+	return cmd
+}
+
+// initCLIData is the data that is passed to the templates of the project.
+type initCLIData struct {
+	Name             string
+	Module           string
+	Vendor           string
+	ShortDescription string
+	GoVersion        string
+}
+
+// runInitCLI generates a Go project for a CLI plugin with the given name,
+// which builds a plugin binary that prints a greeting, and a test for it.
+func runInitCLI(dockerCli command.Cli, opts initCLIOptions) error {
+	if !manager.IsValidPluginName(opts.name) {
+		return errors.Errorf("invalid plugin name %q: plugin names consist of lowercase letters and digits, and start with a letter", opts.name)
+	}
+	binary := metadata.NamePrefix + opts.name
+	dir := opts.dir
+	if dir == "" {
+		dir = binary
+	}
+	data := initCLIData{
+		Name:             opts.name,
+		Module:           opts.module,
+		Vendor:           opts.vendor,
+		ShortDescription: opts.shortDescription,
+		GoVersion:        initCLIGoVersion,
+	}
+	if data.Module == "" {
+		data.Module = "example.com/" + binary
+	}
+	if data.ShortDescription == "" {
+		data.ShortDescription = "The " + opts.name + " CLI plugin"
+	}
+
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 && !opts.force {
+		return errors.Errorf("directory %s is not empty: use --force to generate the project anyway", dir)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmpls, err := template.ParseFS(initCLITemplates, "templates/initcli/*.tmpl")
+	if err != nil {
+		return err
+	}
+	for _, tmpl := range tmpls.Templates() {
+		fileName := filepath.Join(dir, strings.TrimSuffix(tmpl.Name(), ".tmpl"))
+		if err := writeTemplate(fileName, tmpl, data); err != nil {
+			return errors.Wrapf(err, "failed to generate %s", fileName)
+		}
+	}
+
+	_, _ = fmt.Fprintf(dockerCli.Out(), "Generated CLI plugin %q in %s\n", opts.name, dir)
+	_, _ = fmt.Fprintf(dockerCli.Out(), "Run \"make install\" in this directory to build and install the plugin, and \"docker %s\" to run it.\n", opts.name)
+	return nil
+}
+
+func writeTemplate(fileName string, tmpl *template.Template, data initCLIData) error {
+	f, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(f, data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
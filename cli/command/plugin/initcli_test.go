@@ -0,0 +1,93 @@
+package plugin
+
+import (
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/cli/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestInitCLI(t *testing.T) {
+	dir := t.TempDir()
+	projectDir := filepath.Join(dir, "myplugin")
+
+	cli := test.NewFakeCli(nil)
+	cmd := newInitCLICommand(cli)
+	cmd.SetArgs([]string{"--output", projectDir, "--module", "example.org/acme/myplugin", "--vendor", "ACME", "myplugin"})
+	cmd.SetOut(io.Discard)
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), `Generated CLI plugin "myplugin" in `+projectDir))
+
+	entries, err := os.ReadDir(projectDir)
+	assert.NilError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Check(t, is.DeepEqual(names, []string{"Makefile", "go.mod", "main.go", "main_test.go"}))
+
+	goMod, err := os.ReadFile(filepath.Join(projectDir, "go.mod"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(goMod), "module example.org/acme/myplugin\n\ngo "+initCLIGoVersion+"\n"))
+
+	makefile, err := os.ReadFile(filepath.Join(projectDir, "Makefile"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Contains(string(makefile), "BINARY := docker-myplugin\n"))
+
+	// The generated Go files must be valid Go.
+	for _, name := range []string{"main.go", "main_test.go"} {
+		_, err := parser.ParseFile(token.NewFileSet(), filepath.Join(projectDir, name), nil, parser.AllErrors)
+		assert.Check(t, err, name)
+	}
+	mainGo, err := os.ReadFile(filepath.Join(projectDir, "main.go"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Contains(string(mainGo), `Vendor:           "ACME",`))
+	assert.Check(t, is.Contains(string(mainGo), `ShortDescription: "The myplugin CLI plugin",`))
+}
+
+func TestInitCLIErrors(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("existing", fs.WithFile("main.go", "package main\n")),
+	)
+	defer dir.Remove()
+
+	testCases := []struct {
+		doc           string
+		args          []string
+		expectedError string
+	}{
+		{
+			doc:           "invalid name",
+			args:          []string{"--output", dir.Join("invalid"), "My-Plugin"},
+			expectedError: `invalid plugin name "My-Plugin"`,
+		},
+		{
+			doc:           "directory not empty",
+			args:          []string{"--output", dir.Join("existing"), "myplugin"},
+			expectedError: "is not empty: use --force",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cmd := newInitCLICommand(test.NewFakeCli(nil))
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.ErrorContains(t, cmd.Execute(), tc.expectedError)
+		})
+	}
+
+	cmd := newInitCLICommand(test.NewFakeCli(nil))
+	cmd.SetArgs([]string{"--output", dir.Join("existing"), "--force", "myplugin"})
+	cmd.SetOut(io.Discard)
+	assert.NilError(t, cmd.Execute())
+	_, err := os.Stat(dir.Join("existing", "Makefile"))
+	assert.NilError(t, err)
+}
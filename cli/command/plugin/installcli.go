@@ -0,0 +1,323 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli-plugins/manager"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/cli/cli/config"
+	manifesttypes "github.com/docker/cli/cli/manifest/types"
+	registryclient "github.com/docker/cli/cli/registry/client"
+	"github.com/docker/distribution"
+	registrytypes "github.com/docker/docker/api/types/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// cliPluginLayerMediaType is the media type of the layer that contains the
+// binary of a CLI plugin distributed as an OCI artifact.
+const cliPluginLayerMediaType = "application/vnd.docker.cli-plugin.v1+binary"
+
+// cliPluginSignatureMediaType is the media type of the optional layer that
+// contains the minisign signature of the plugin binary, which is installed
+// next to the plugin so that it can be verified when the signature policy
+// is set.
+const cliPluginSignatureMediaType = "application/vnd.docker.cli-plugin.v1+minisig"
+
+// signatureFileSuffix is the suffix of the file that the signature of a
+// plugin is installed to, next to the plugin.
+const signatureFileSuffix = ".minisig"
+
+type installCLIOptions struct {
+	remote   string
+	name     string
+	platform string
+	insecure bool
+	force    bool
+}
+
+// registryClientProvider is used in tests to provide a fake registry client.
+type registryClientProvider interface {
+	RegistryClient(bool) registryclient.RegistryClient
+}
+
+func newInstallCLICommand(dockerCli command.Cli) *cobra.Command {
+	var opts installCLIOptions
+
+	cmd := &cobra.Command{
+		Use:   "install-cli [OPTIONS] REFERENCE",
+		Short: "Install a CLI plugin distributed as an OCI artifact",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.remote = args[0]
+			return runInstallCLI(cmd.Context(), dockerCli, cmd.Root(), opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.name, "name", "", "Name to install the plugin as (default: derived from the artifact)")
+	flags.StringVar(&opts.platform, "platform", "", "Install the plugin for the given platform (default: the platform of the CLI)")
+	flags.BoolVar(&opts.insecure, "insecure", false, "Allow communication with an insecure registry")
+	flags.BoolVarP(&opts.force, "force", "f", false, "Replace the plugin if it is already installed")
+	return cmd
+}
+
+// newCLIPluginRegistryClient returns a client for fetching CLI plugins from
+// a registry.
+func newCLIPluginRegistryClient(dockerCli command.Cli, allowInsecure bool) registryclient.RegistryClient {
+	if p, ok := dockerCli.(registryClientProvider); ok {
+		return p.RegistryClient(allowInsecure)
+	}
+	resolver := func(ctx context.Context, index *registrytypes.IndexInfo) registrytypes.AuthConfig {
+		return command.ResolveAuthConfig(dockerCli.ConfigFile(), index)
+	}
+	return registryclient.NewRegistryClient(resolver, command.UserAgent(), allowInsecure)
+}
+
+// runInstallCLI fetches the CLI plugin for the requested platform from the
+// registry, verifies its digest, and installs it into the "cli-plugins"
+// directory inside the CLI's config directory. The plugin is validated
+// before it replaces an existing plugin with the same name.
+func runInstallCLI(ctx context.Context, dockerCli command.Cli, rootCmd *cobra.Command, opts installCLIOptions) error {
+	namedRef, err := reference.ParseNormalizedNamed(opts.remote)
+	if err != nil {
+		return err
+	}
+	namedRef = reference.TagNameOnly(namedRef)
+
+	platform := platforms.DefaultSpec()
+	if opts.platform != "" {
+		platform, err = platforms.Parse(opts.platform)
+		if err != nil {
+			return errors.Wrap(err, "invalid --platform")
+		}
+	}
+
+	rclient := newCLIPluginRegistryClient(dockerCli, opts.insecure)
+	mfst, err := resolveCLIPluginManifest(ctx, rclient, namedRef, platform)
+	if err != nil {
+		return err
+	}
+	layer, err := cliPluginLayer(mfst)
+	if err != nil {
+		return err
+	}
+
+	name := opts.name
+	if name == "" {
+		name = cliPluginName(namedRef, layer)
+	}
+	if !manager.IsValidPluginName(name) {
+		return errors.Errorf("invalid plugin name %q: use --name to set a name that consists of lowercase letters and digits", name)
+	}
+
+	pluginDir := filepath.Join(config.Dir(), "cli-plugins")
+	fileName := "docker-" + name
+	if platform.OS == "windows" {
+		fileName += ".exe"
+	}
+	target := filepath.Join(pluginDir, fileName)
+	if _, err := os.Stat(target); err == nil && !opts.force {
+		return errors.Errorf("plugin %q is already installed at %s: use --force to replace it", name, target)
+	}
+
+	if err := os.MkdirAll(pluginDir, 0o755); err != nil {
+		return err
+	}
+	// The plugin is downloaded to a temporary directory in the plugin
+	// directory, so that it can be validated before it is moved in place.
+	stagingDir, err := os.MkdirTemp(pluginDir, ".install-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stagingDir)
+
+	blobRef, err := reference.WithDigest(reference.TrimNamed(namedRef), layer.Digest)
+	if err != nil {
+		return err
+	}
+	if err := fetchCLIPlugin(ctx, rclient, blobRef, layer, filepath.Join(stagingDir, fileName)); err != nil {
+		return err
+	}
+	sigLayer, signed := cliPluginSignatureLayer(mfst)
+	if signed {
+		sigRef, err := reference.WithDigest(reference.TrimNamed(namedRef), sigLayer.Digest)
+		if err != nil {
+			return err
+		}
+		if err := fetchBlob(ctx, rclient, sigRef, sigLayer, filepath.Join(stagingDir, fileName+signatureFileSuffix)); err != nil {
+			return err
+		}
+	}
+
+	// The plugin is validated by running it for its metadata, which is only
+	// possible if it is for the platform of the CLI.
+	if !platforms.Default().Match(platform) {
+		if err := installCLIPluginFiles(stagingDir, fileName, target, signed); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(dockerCli.Err(), "WARNING: plugin %s was not validated, because it is for platform %s\n", name, platforms.Format(platform))
+		_, _ = fmt.Fprintf(dockerCli.Out(), "Installed plugin %s (%s) to %s\n", name, layer.Digest, target)
+		return nil
+	}
+
+	p, err := manager.GetPluginFromDir(name, stagingDir, dockerCli, rootCmd)
+	if err != nil {
+		return err
+	}
+	if p.Err != nil {
+		return errors.Wrapf(p.Err, "%s does not contain a valid CLI plugin", opts.remote)
+	}
+	if err := installCLIPluginFiles(stagingDir, fileName, target, signed); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(dockerCli.Out(), "Installed plugin %s %s (%s) to %s\n", name, p.Version, layer.Digest, target)
+	return nil
+}
+
+// installCLIPluginFiles moves the plugin, and its signature if signed, from
+// the staging directory to target. The signature is moved first, so that the
+// plugin is never installed with the signature of the plugin it replaces. A
+// signature of a replaced plugin is removed if the new plugin is not signed.
+func installCLIPluginFiles(stagingDir, fileName, target string, signed bool) error {
+	if signed {
+		if err := os.Rename(filepath.Join(stagingDir, fileName+signatureFileSuffix), target+signatureFileSuffix); err != nil {
+			return err
+		}
+	} else if err := os.Remove(target + signatureFileSuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Rename(filepath.Join(stagingDir, fileName), target)
+}
+
+// resolveCLIPluginManifest returns the manifest for the given platform. If
+// ref refers to a single manifest, that manifest is returned, unless its
+// config specifies a different platform.
+func resolveCLIPluginManifest(ctx context.Context, rclient registryclient.RegistryClient, ref reference.Named, platform ocispec.Platform) (manifesttypes.ImageManifest, error) {
+	matcher := platforms.Only(platform)
+	mfst, err := rclient.GetManifest(ctx, ref)
+	if err == nil {
+		if p := mfst.Descriptor.Platform; p != nil && p.OS != "" && !matcher.Match(*p) {
+			return manifesttypes.ImageManifest{}, errors.Errorf("%s is for platform %s, not %s", ref, platforms.Format(*p), platforms.Format(platform))
+		}
+		return mfst, nil
+	}
+	// Only fall back to the manifest list if ref refers to one, so that
+	// other errors, such as authentication errors, are not hidden.
+	var listErr registryclient.ErrManifestList
+	if !errors.As(err, &listErr) {
+		return manifesttypes.ImageManifest{}, errors.Wrapf(err, "failed to fetch the manifest of %s", ref)
+	}
+
+	list, err := rclient.GetManifestList(ctx, ref)
+	if err != nil {
+		return manifesttypes.ImageManifest{}, err
+	}
+	for _, m := range list {
+		if m.Descriptor.Platform != nil && matcher.Match(*m.Descriptor.Platform) {
+			return m, nil
+		}
+	}
+	return manifesttypes.ImageManifest{}, errors.Errorf("%s has no plugin for platform %s", ref, platforms.Format(platform))
+}
+
+// cliPluginLayer returns the layer of the manifest that contains the plugin
+// binary. This is the layer with the CLI plugin media type, or the only layer
+// if the manifest has a single layer.
+func cliPluginLayer(mfst manifesttypes.ImageManifest) (distribution.Descriptor, error) {
+	if mfst.OCIManifest == nil {
+		return distribution.Descriptor{}, errors.Errorf("%s is not an OCI artifact", mfst.Ref)
+	}
+	layers := mfst.OCIManifest.Layers
+	for _, l := range layers {
+		if l.MediaType == cliPluginLayerMediaType {
+			return l, nil
+		}
+	}
+	if len(layers) == 1 {
+		return layers[0], nil
+	}
+	return distribution.Descriptor{}, errors.Errorf("%s does not contain a CLI plugin: expected a single layer or a layer with media type %s", mfst.Ref, cliPluginLayerMediaType)
+}
+
+// cliPluginSignatureLayer returns the layer of the manifest that contains
+// the signature of the plugin, if any.
+func cliPluginSignatureLayer(mfst manifesttypes.ImageManifest) (distribution.Descriptor, bool) {
+	if mfst.OCIManifest == nil {
+		return distribution.Descriptor{}, false
+	}
+	for _, l := range mfst.OCIManifest.Layers {
+		if l.MediaType == cliPluginSignatureMediaType {
+			return l, true
+		}
+	}
+	return distribution.Descriptor{}, false
+}
+
+// cliPluginName derives the name of the plugin from the title annotation of
+// the layer ("docker-<name>"), or from the last path component of the
+// repository if the layer has no title.
+func cliPluginName(ref reference.Named, layer distribution.Descriptor) string {
+	name := layer.Annotations[ocispec.AnnotationTitle]
+	if name == "" {
+		name = path.Base(reference.Path(ref))
+	}
+	name = strings.TrimSuffix(name, ".exe")
+	return strings.TrimPrefix(name, "docker-")
+}
+
+// fetchCLIPlugin downloads the layer to dest, and verifies its size and
+// digest. The file is made executable once it is verified.
+func fetchCLIPlugin(ctx context.Context, rclient registryclient.RegistryClient, ref reference.Canonical, layer distribution.Descriptor, dest string) error {
+	if err := fetchBlob(ctx, rclient, ref, layer, dest); err != nil {
+		return err
+	}
+	return os.Chmod(dest, 0o755)
+}
+
+// fetchBlob downloads the layer to dest, and verifies its size and digest.
+func fetchBlob(ctx context.Context, rclient registryclient.RegistryClient, ref reference.Canonical, layer distribution.Descriptor, dest string) error {
+	getter, ok := rclient.(registryclient.BlobGetter)
+	if !ok {
+		return errors.New("the registry client does not support fetching blobs")
+	}
+	rc, err := getter.GetBlob(ctx, ref)
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch %s", ref)
+	}
+	defer rc.Close()
+
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Read at most one byte more than expected, so that a registry that
+	// sends more data than expected is detected without writing all of it.
+	verifier := layer.Digest.Verifier()
+	n, err := io.Copy(io.MultiWriter(f, verifier), io.LimitReader(rc, layer.Size+1))
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch %s", ref)
+	}
+	if n != layer.Size {
+		return errors.Errorf("size mismatch for %s: expected %d bytes, got %d", layer.Digest, layer.Size, n)
+	}
+	if !verifier.Verified() {
+		return errors.Errorf("digest verification failed for %s", layer.Digest)
+	}
+	return f.Close()
+}
@@ -0,0 +1,373 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/distribution/reference"
+	"github.com/docker/cli/cli/config"
+	manifesttypes "github.com/docker/cli/cli/manifest/types"
+	registryclient "github.com/docker/cli/cli/registry/client"
+	"github.com/docker/cli/internal/test"
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/ocischema"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+type fakeRegistryClient struct {
+	registryclient.RegistryClient
+	getManifestFunc     func(ref reference.Named) (manifesttypes.ImageManifest, error)
+	getManifestListFunc func(ref reference.Named) ([]manifesttypes.ImageManifest, error)
+	getBlobFunc         func(ref reference.Canonical) (io.ReadCloser, error)
+}
+
+func (c *fakeRegistryClient) GetManifest(_ context.Context, ref reference.Named) (manifesttypes.ImageManifest, error) {
+	if c.getManifestFunc != nil {
+		return c.getManifestFunc(ref)
+	}
+	if c.getManifestListFunc != nil {
+		return manifesttypes.ImageManifest{}, registryclient.ErrManifestList{Ref: ref}
+	}
+	return manifesttypes.ImageManifest{}, errors.New("no such manifest")
+}
+
+func (c *fakeRegistryClient) GetManifestList(_ context.Context, ref reference.Named) ([]manifesttypes.ImageManifest, error) {
+	if c.getManifestListFunc != nil {
+		return c.getManifestListFunc(ref)
+	}
+	return nil, errors.New("no such manifest list")
+}
+
+func (c *fakeRegistryClient) GetBlob(_ context.Context, ref reference.Canonical) (io.ReadCloser, error) {
+	if c.getBlobFunc != nil {
+		return c.getBlobFunc(ref)
+	}
+	return nil, errors.New("no such blob")
+}
+
+const installCLIPlugin = `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing","Version":"v1.2.3"}'`
+
+// pluginArtifact returns a manifest with a single layer for the given
+// content, and a blob store that serves the layer.
+func pluginArtifact(content string, annotations map[string]string, p *ocispec.Platform) (manifesttypes.ImageManifest, map[digest.Digest]string) {
+	layer := distribution.Descriptor{
+		MediaType:   cliPluginLayerMediaType,
+		Digest:      digest.FromString(content),
+		Size:        int64(len(content)),
+		Annotations: annotations,
+	}
+	return manifesttypes.ImageManifest{
+		Descriptor: ocispec.Descriptor{Platform: p},
+		OCIManifest: &ocischema.DeserializedManifest{
+			Manifest: ocischema.Manifest{Layers: []distribution.Descriptor{layer}},
+		},
+	}, map[digest.Digest]string{layer.Digest: content}
+}
+
+func blobGetter(blobs map[digest.Digest]string) func(ref reference.Canonical) (io.ReadCloser, error) {
+	return func(ref reference.Canonical) (io.ReadCloser, error) {
+		content, ok := blobs[ref.Digest()]
+		if !ok {
+			return nil, errors.New("no such blob")
+		}
+		return io.NopCloser(strings.NewReader(content)), nil
+	}
+}
+
+func setConfigDir(t *testing.T) string {
+	t.Helper()
+	dir := fs.NewDir(t, t.Name())
+	oldDir := config.Dir()
+	config.SetDir(dir.Path())
+	t.Cleanup(func() {
+		config.SetDir(oldDir)
+		dir.Remove()
+	})
+	return dir.Path()
+}
+
+func TestInstallCLI(t *testing.T) {
+	configDir := setConfigDir(t)
+
+	mfst, blobs := pluginArtifact(installCLIPlugin, nil, nil)
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetRegistryClient(&fakeRegistryClient{
+		getManifestFunc: func(ref reference.Named) (manifesttypes.ImageManifest, error) {
+			assert.Check(t, is.Equal(ref.String(), "example.com/tools/docker-hello:latest"))
+			return mfst, nil
+		},
+		getBlobFunc: blobGetter(blobs),
+	})
+	cmd := newInstallCLICommand(cli)
+	cmd.SetArgs([]string{"example.com/tools/docker-hello"})
+	assert.NilError(t, cmd.Execute())
+
+	target := filepath.Join(configDir, "cli-plugins", "docker-hello")
+	assert.Check(t, is.Equal(cli.OutBuffer().String(),
+		"Installed plugin hello v1.2.3 ("+digest.FromString(installCLIPlugin).String()+") to "+target+"\n"))
+	content, err := os.ReadFile(target)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(content), installCLIPlugin))
+	st, err := os.Stat(target)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(st.Mode().Perm(), os.FileMode(0o755)))
+
+	// Only the plugin is left in the plugin directory.
+	entries, err := os.ReadDir(filepath.Join(configDir, "cli-plugins"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(entries, 1))
+
+	cmd = newInstallCLICommand(cli)
+	cmd.SetArgs([]string{"example.com/tools/docker-hello"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, is.ErrorContains(cmd.Execute(), `plugin "hello" is already installed`))
+
+	cmd = newInstallCLICommand(cli)
+	cmd.SetArgs([]string{"--force", "example.com/tools/docker-hello"})
+	assert.NilError(t, cmd.Execute())
+}
+
+func TestInstallCLIPlatform(t *testing.T) {
+	configDir := setConfigDir(t)
+
+	amd64, blobs := pluginArtifact(installCLIPlugin+"\n# amd64", map[string]string{ocispec.AnnotationTitle: "docker-hello"}, &ocispec.Platform{OS: "linux", Architecture: "amd64"})
+	arm64, arm64Blobs := pluginArtifact(installCLIPlugin+"\n# arm64", map[string]string{ocispec.AnnotationTitle: "docker-hello"}, &ocispec.Platform{OS: "linux", Architecture: "arm64"})
+	for dgst, content := range arm64Blobs {
+		blobs[dgst] = content
+	}
+
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetRegistryClient(&fakeRegistryClient{
+		getManifestListFunc: func(reference.Named) ([]manifesttypes.ImageManifest, error) {
+			return []manifesttypes.ImageManifest{amd64, arm64}, nil
+		},
+		getBlobFunc: blobGetter(blobs),
+	})
+	cmd := newInstallCLICommand(cli)
+	cmd.SetArgs([]string{"--platform", "linux/arm64", "example.com/hello-plugin:1.0"})
+	assert.NilError(t, cmd.Execute())
+
+	content, err := os.ReadFile(filepath.Join(configDir, "cli-plugins", "docker-hello"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(content), installCLIPlugin+"\n# arm64"))
+
+	cmd = newInstallCLICommand(cli)
+	cmd.SetArgs([]string{"--platform", "windows/amd64", "example.com/hello-plugin:1.0"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, is.Error(cmd.Execute(), "example.com/hello-plugin:1.0 has no plugin for platform windows/amd64"))
+}
+
+func TestInstallCLIOtherPlatform(t *testing.T) {
+	if runtime.GOARCH == "s390x" {
+		t.Skip("test requires a platform other than linux/s390x")
+	}
+	configDir := setConfigDir(t)
+
+	// Plugins for another platform cannot be run, so they are installed
+	// without validating their metadata.
+	const content = "#!/bin/sh\nexit 1"
+	mfst, blobs := pluginArtifact(content, nil, &ocispec.Platform{OS: "linux", Architecture: "s390x"})
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetRegistryClient(&fakeRegistryClient{
+		getManifestListFunc: func(reference.Named) ([]manifesttypes.ImageManifest, error) {
+			return []manifesttypes.ImageManifest{mfst}, nil
+		},
+		getBlobFunc: blobGetter(blobs),
+	})
+	cmd := newInstallCLICommand(cli)
+	cmd.SetArgs([]string{"--platform", "linux/s390x", "example.com/tools/docker-hello:1.0"})
+	assert.NilError(t, cmd.Execute())
+
+	target := filepath.Join(configDir, "cli-plugins", "docker-hello")
+	assert.Check(t, is.Equal(cli.OutBuffer().String(),
+		"Installed plugin hello ("+digest.FromString(content).String()+") to "+target+"\n"))
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "WARNING: plugin hello was not validated, because it is for platform linux/s390x\n"))
+	actual, err := os.ReadFile(target)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(actual), content))
+}
+
+func TestInstallCLISignature(t *testing.T) {
+	configDir := setConfigDir(t)
+
+	const signature = "untrusted comment: signature\nRWQ...\n"
+	mfst, blobs := pluginArtifact(installCLIPlugin, nil, nil)
+	sigLayer := distribution.Descriptor{
+		MediaType: cliPluginSignatureMediaType,
+		Digest:    digest.FromString(signature),
+		Size:      int64(len(signature)),
+	}
+	blobs[sigLayer.Digest] = signature
+	signed := mfst
+	signed.OCIManifest = &ocischema.DeserializedManifest{
+		Manifest: ocischema.Manifest{Layers: append([]distribution.Descriptor{sigLayer}, mfst.OCIManifest.Layers...)},
+	}
+
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetRegistryClient(&fakeRegistryClient{
+		getManifestFunc: func(ref reference.Named) (manifesttypes.ImageManifest, error) {
+			if ref.String() == "example.com/tools/docker-hello:signed" {
+				return signed, nil
+			}
+			return mfst, nil
+		},
+		getBlobFunc: blobGetter(blobs),
+	})
+
+	// The signature is installed next to the plugin.
+	cmd := newInstallCLICommand(cli)
+	cmd.SetArgs([]string{"example.com/tools/docker-hello:signed"})
+	assert.NilError(t, cmd.Execute())
+	target := filepath.Join(configDir, "cli-plugins", "docker-hello")
+	content, err := os.ReadFile(target + ".minisig")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(content), signature))
+
+	// The signature is removed if the plugin is replaced with an unsigned one.
+	cmd = newInstallCLICommand(cli)
+	cmd.SetArgs([]string{"--force", "example.com/tools/docker-hello:unsigned"})
+	assert.NilError(t, cmd.Execute())
+	_, err = os.Stat(target + ".minisig")
+	assert.Check(t, os.IsNotExist(err))
+}
+
+func TestInstallCLIErrors(t *testing.T) {
+	dgst := digest.FromString(installCLIPlugin)
+
+	testCases := []struct {
+		doc         string
+		args        []string
+		artifact    string
+		blob        string
+		expectedErr string
+	}{
+		{
+			doc:         "digest mismatch",
+			args:        []string{"example.com/docker-hello"},
+			blob:        installCLIPlugin + "\n# tampered",
+			expectedErr: "size mismatch for " + dgst.String(),
+		},
+		{
+			doc:         "digest mismatch with same size",
+			args:        []string{"example.com/docker-hello"},
+			blob:        strings.Replace(installCLIPlugin, "e2e", "xyz", 1),
+			expectedErr: "digest verification failed for " + dgst.String(),
+		},
+		{
+			doc:         "invalid name",
+			args:        []string{"--name", "Hello", "example.com/docker-hello"},
+			blob:        installCLIPlugin,
+			expectedErr: `invalid plugin name "Hello"`,
+		},
+		{
+			doc:         "not a plugin",
+			args:        []string{"example.com/docker-hello"},
+			artifact:    "#!/bin/sh\necho not a plugin",
+			blob:        "#!/bin/sh\necho not a plugin",
+			expectedErr: "example.com/docker-hello does not contain a valid CLI plugin",
+		},
+		{
+			doc:         "invalid platform",
+			args:        []string{"--platform", "linux/amd64/v3/x", "example.com/docker-hello"},
+			blob:        installCLIPlugin,
+			expectedErr: "invalid --platform",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			configDir := setConfigDir(t)
+			artifact := tc.artifact
+			if artifact == "" {
+				artifact = installCLIPlugin
+			}
+			mfst, _ := pluginArtifact(artifact, nil, nil)
+			cli := test.NewFakeCli(&fakeClient{})
+			cli.SetRegistryClient(&fakeRegistryClient{
+				getManifestFunc: func(reference.Named) (manifesttypes.ImageManifest, error) {
+					return mfst, nil
+				},
+				getBlobFunc: func(reference.Canonical) (io.ReadCloser, error) {
+					return io.NopCloser(strings.NewReader(tc.blob)), nil
+				},
+			})
+			cmd := newInstallCLICommand(cli)
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Check(t, is.ErrorContains(cmd.Execute(), tc.expectedErr))
+
+			_, err := os.Stat(filepath.Join(configDir, "cli-plugins", "docker-hello"))
+			assert.Check(t, os.IsNotExist(err))
+		})
+	}
+}
+
+func TestInstallCLIManifestError(t *testing.T) {
+	setConfigDir(t)
+
+	var listFetched bool
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetRegistryClient(&fakeRegistryClient{
+		getManifestFunc: func(reference.Named) (manifesttypes.ImageManifest, error) {
+			return manifesttypes.ImageManifest{}, errors.New("unauthorized: authentication required")
+		},
+		getManifestListFunc: func(reference.Named) ([]manifesttypes.ImageManifest, error) {
+			listFetched = true
+			return nil, errors.New("no such manifest list")
+		},
+	})
+	cmd := newInstallCLICommand(cli)
+	cmd.SetArgs([]string{"example.com/docker-hello"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, is.Error(cmd.Execute(), "failed to fetch the manifest of example.com/docker-hello:latest: unauthorized: authentication required"))
+	assert.Check(t, !listFetched, "manifest list should only be fetched if the reference refers to one")
+}
+
+// endlessReader returns an endless stream of data.
+type endlessReader struct{}
+
+func (endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'x'
+	}
+	return len(p), nil
+}
+
+func TestInstallCLIBlobTooLarge(t *testing.T) {
+	configDir := setConfigDir(t)
+
+	mfst, _ := pluginArtifact(installCLIPlugin, nil, nil)
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetRegistryClient(&fakeRegistryClient{
+		getManifestFunc: func(reference.Named) (manifesttypes.ImageManifest, error) {
+			return mfst, nil
+		},
+		getBlobFunc: func(reference.Canonical) (io.ReadCloser, error) {
+			return io.NopCloser(endlessReader{}), nil
+		},
+	})
+	cmd := newInstallCLICommand(cli)
+	cmd.SetArgs([]string{"example.com/docker-hello"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	size := len(installCLIPlugin)
+	assert.Check(t, is.ErrorContains(cmd.Execute(), fmt.Sprintf("expected %d bytes, got %d", size, size+1)))
+
+	_, err := os.Stat(filepath.Join(configDir, "cli-plugins", "docker-hello"))
+	assert.Check(t, os.IsNotExist(err))
+}
@@ -2,23 +2,33 @@
 
 import (
 	"context"
+	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli-plugins/manager"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/command/completion"
 	"github.com/docker/cli/cli/command/formatter"
 	flagsHelper "github.com/docker/cli/cli/flags"
 	"github.com/docker/cli/opts"
 	"github.com/fvbommel/sortorder"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
 type listOptions struct {
-	quiet   bool
-	noTrunc bool
-	format  string
-	filter  opts.FilterOpt
+	quiet      bool
+	noTrunc    bool
+	cliPlugins bool
+	allDirs    bool
+	verbose    bool
+	probe      bool
+	upgrades   bool
+	vendor     string
+	format     string
+	filter     opts.FilterOpt
 }
 
 func newListCommand(dockerCli command.Cli) *cobra.Command {
@@ -30,6 +40,15 @@ func newListCommand(dockerCli command.Cli) *cobra.Command {
 		Aliases: []string{"list"},
 		Args:    cli.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if options.allDirs {
+				return runListCLIPluginCandidates(dockerCli, cmd.Root(), options)
+			}
+			if options.probe {
+				return runProbeCLIPlugins(dockerCli, cmd.Root(), options)
+			}
+			if options.cliPlugins || options.verbose || options.upgrades || options.vendor != "" {
+				return runListCLIPlugins(cmd.Context(), dockerCli, cmd.Root(), options)
+			}
 			return runList(cmd.Context(), dockerCli, options)
 		},
 		ValidArgsFunction: completion.NoComplete,
@@ -41,6 +60,12 @@ func newListCommand(dockerCli command.Cli) *cobra.Command {
 	flags.BoolVar(&options.noTrunc, "no-trunc", false, "Don't truncate output")
 	flags.StringVar(&options.format, "format", "", flagsHelper.FormatHelp)
 	flags.VarP(&options.filter, "filter", "f", `Provide filter values (e.g. "enabled=true")`)
+	flags.BoolVar(&options.cliPlugins, "cli", false, "List CLI plugins instead of Engine plugins")
+	flags.BoolVarP(&options.verbose, "verbose", "v", false, "Print warnings for CLI plugin directories that could not be read, or the status of each candidate with --show-all-dirs (implies --cli)")
+	flags.BoolVar(&options.allDirs, "show-all-dirs", false, "List all CLI plugin candidates, including shadowed ones (implies --cli)")
+	flags.BoolVar(&options.probe, "probe", false, "Run each CLI plugin to check that it is working (implies --cli)")
+	flags.BoolVar(&options.upgrades, "check-upgrades", false, "Check the CLI plugin index for newer versions of CLI plugins (implies --cli)")
+	flags.StringVar(&options.vendor, "vendor", "", "Only list CLI plugins with a vendor that contains the given text (implies --cli)")
 
 	return cmd
 }
@@ -71,3 +96,155 @@ func runList(ctx context.Context, dockerCli command.Cli, options listOptions) er
 	}
 	return FormatWrite(pluginsCtx, plugins)
 }
+
+// runListCLIPlugins lists the CLI plugins that are installed on the client.
+// Invalid plugins are omitted, and only the plugin that takes precedence
+// is listed if multiple candidates with the same name exist. If a vendor is
+// set, only plugins with a vendor that contains it (ignoring case) are listed.
+func runListCLIPlugins(ctx context.Context, dockerCli command.Cli, rootCmd *cobra.Command, options listOptions) error {
+	if options.filter.Value().Len() > 0 {
+		return errors.New("the --filter option is not supported for CLI plugins")
+	}
+	all, warnings, err := manager.ListPluginsWithWarnings(dockerCli, rootCmd)
+	if err != nil {
+		return err
+	}
+	if options.verbose {
+		for _, w := range warnings {
+			_, _ = fmt.Fprintf(dockerCli.Err(), "WARNING: failed to read plugin directory: %v\n", w)
+		}
+	}
+	vendor := strings.ToLower(options.vendor)
+	plugins := make([]manager.Plugin, 0, len(all))
+	for _, p := range all {
+		if p.Err == nil && strings.Contains(strings.ToLower(p.Vendor), vendor) {
+			plugins = append(plugins, p)
+		}
+	}
+
+	format := options.format
+	if len(format) == 0 {
+		format = formatter.TableFormatKey
+	}
+
+	var usage map[string]manager.PluginUsageStats
+	if cfg := dockerCli.ConfigFile(); cfg != nil && cfg.CLIPluginsRecordUsage {
+		usage, err = manager.PluginUsage(dockerCli)
+		if err != nil {
+			return err
+		}
+	}
+
+	var upgrades map[string]string
+	if options.upgrades {
+		upgrades, err = manager.CheckUpgrades(ctx, dockerCli, plugins)
+		if err != nil {
+			return err
+		}
+	}
+
+	formatOpts := formatter.PluginFormatOptions{Quiet: options.quiet, Usage: usage, Upgrades: upgrades}
+	pluginsCtx := formatter.Context{
+		Output: dockerCli.Out(),
+		Format: formatter.NewPluginFormatWithOptions(format, formatOpts),
+		Trunc:  !options.noTrunc,
+	}
+	return formatter.PluginWriteWithOptions(pluginsCtx, plugins, formatOpts)
+}
+
+// runProbeCLIPlugins runs the metadata command of each CLI plugin, and prints
+// whether it succeeded, and how long it took. Unlike runListCLIPlugins,
+// invalid plugins are included.
+func runProbeCLIPlugins(dockerCli command.Cli, rootCmd *cobra.Command, options listOptions) error {
+	if options.filter.Value().Len() > 0 {
+		return errors.New("the --filter option is not supported for CLI plugins")
+	}
+	results, err := manager.ProbePlugins(dockerCli, rootCmd)
+	if err != nil {
+		return err
+	}
+
+	format := options.format
+	if len(format) == 0 {
+		format = formatter.TableFormatKey
+	}
+
+	probeCtx := formatter.Context{
+		Output: dockerCli.Out(),
+		Format: formatter.NewPluginProbeFormat(format, options.quiet),
+		Trunc:  !options.noTrunc,
+	}
+	return formatter.PluginProbeWrite(probeCtx, results)
+}
+
+// runListCLIPluginCandidates prints all CLI plugin candidates, grouped by
+// plugin name. The paths for each plugin are printed in order of precedence,
+// and the path that is used when running the plugin is marked with "*".
+// With --verbose, each candidate is run to validate it, and its status is
+// printed after the path.
+func runListCLIPluginCandidates(dockerCli command.Cli, rootCmd *cobra.Command, options listOptions) error {
+	if options.filter.Value().Len() > 0 || options.format != "" || options.quiet {
+		return errors.New("the --filter, --format, and --quiet options cannot be combined with --show-all-dirs")
+	}
+	if options.verbose {
+		return runDiagnoseCLIPluginCandidates(dockerCli, rootCmd)
+	}
+	candidates := manager.ListPluginCandidates(dockerCli)
+	names := make([]string, 0, len(candidates))
+	for name := range candidates {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return sortorder.NaturalLess(names[i], names[j])
+	})
+
+	out := dockerCli.Out()
+	for _, name := range names {
+		_, _ = fmt.Fprintln(out, name)
+		for i, path := range candidates[name] {
+			marker := " "
+			if i == 0 {
+				marker = "*"
+			}
+			_, _ = fmt.Fprintf(out, "  %s %s\n", marker, path)
+		}
+	}
+	return nil
+}
+
+// runDiagnoseCLIPluginCandidates prints all CLI plugin candidates like
+// runListCLIPluginCandidates, followed by the status of each candidate:
+// whether it is active or shadowed, and why it is not a valid plugin.
+func runDiagnoseCLIPluginCandidates(dockerCli command.Cli, rootCmd *cobra.Command) error {
+	candidates, err := manager.DiagnosePluginCandidates(dockerCli, rootCmd)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(candidates))
+	for name := range candidates {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return sortorder.NaturalLess(names[i], names[j])
+	})
+
+	out := dockerCli.Out()
+	for _, name := range names {
+		_, _ = fmt.Fprintln(out, name)
+		for _, c := range candidates[name] {
+			marker, status := " ", ""
+			switch {
+			case c.Active:
+				marker, status = "*", "active"
+			case c.Shadowed && c.Err != nil:
+				status = "shadowed, invalid: " + c.Err.Error()
+			case c.Shadowed:
+				status = "shadowed"
+			default:
+				status = "invalid: " + c.Err.Error()
+			}
+			_, _ = fmt.Fprintf(out, "  %s %s (%s)\n", marker, c.Path, status)
+		}
+	}
+	return nil
+}
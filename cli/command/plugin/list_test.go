@@ -3,14 +3,18 @@
 import (
 	"errors"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/docker/cli/cli/config/configfile"
 	"github.com/docker/cli/internal/test"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
 
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
 	"gotest.tools/v3/golden"
 )
 
@@ -177,3 +181,210 @@ func TestList(t *testing.T) {
 		})
 	}
 }
+
+func TestListCLIPluginsQuiet(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-bbb", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-broken", `#!/bin/sh
+echo 'not json'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	testCases := []struct {
+		description string
+		flags       map[string]string
+		expected    string
+	}{
+		{
+			description: "quiet",
+			flags:       map[string]string{"cli": "true", "quiet": "true"},
+			expected:    "aaa\nbbb\n",
+		},
+		{
+			description: "format takes precedence over quiet",
+			flags:       map[string]string{"cli": "true", "quiet": "true", "format": "{{.Name}}: {{.Vendor}}"},
+			expected:    "aaa: e2e-testing\nbbb: e2e-testing\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{})
+			cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+			cmd := newListCommand(cli)
+			cmd.SetArgs([]string{})
+			for key, value := range tc.flags {
+				assert.NilError(t, cmd.Flags().Set(key, value))
+			}
+			assert.NilError(t, cmd.Execute())
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), tc.expected))
+		})
+	}
+}
+
+func TestListCLIPluginsVendor(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"Docker Inc."}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-bbb", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-ccc", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"docker community"}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	testCases := []struct {
+		vendor   string
+		expected string
+	}{
+		{vendor: "Docker Inc.", expected: "aaa\n"},
+		{vendor: "docker", expected: "aaa\nccc\n"},
+		{vendor: "TESTING", expected: "bbb\n"},
+		{vendor: "no-such-vendor", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.vendor, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{})
+			cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+			cmd := newListCommand(cli)
+			cmd.SetArgs([]string{"--vendor", tc.vendor, "--format", "{{.Name}}"})
+			assert.NilError(t, cmd.Execute())
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), tc.expected))
+		})
+	}
+}
+
+func TestListCLIPluginCandidates(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("plugins1",
+			fs.WithFile("docker-plugin1", ""),
+			fs.WithFile("docker-plugin10", ""),
+		),
+		fs.WithDir("plugins2",
+			fs.WithFile("docker-plugin1", ""),
+			fs.WithFile("docker-plugin2", ""),
+		),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Join("plugins1"), dir.Join("plugins2")}})
+	cmd := newListCommand(cli)
+	cmd.SetArgs([]string{"--show-all-dirs"})
+	assert.NilError(t, cmd.Execute())
+
+	expected := "plugin1\n" +
+		"  * " + dir.Join("plugins1", "docker-plugin1") + "\n" +
+		"    " + dir.Join("plugins2", "docker-plugin1") + "\n" +
+		"plugin2\n" +
+		"  * " + dir.Join("plugins2", "docker-plugin2") + "\n" +
+		"plugin10\n" +
+		"  * " + dir.Join("plugins1", "docker-plugin10") + "\n"
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), expected))
+}
+
+func TestListCLIPluginCandidatesVerbose(t *testing.T) {
+	const valid = `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`
+	const invalid = `#!/bin/sh
+echo 'not json'`
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("plugins1",
+			fs.WithFile("docker-aaa", valid, fs.WithMode(0o777)),
+			fs.WithFile("docker-bbb", invalid, fs.WithMode(0o777)),
+			fs.WithFile("docker-ccc", valid, fs.WithMode(0o644)),
+		),
+		fs.WithDir("plugins2",
+			fs.WithFile("docker-aaa", invalid, fs.WithMode(0o777)),
+			fs.WithFile("docker-bbb", valid, fs.WithMode(0o777)),
+		),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Join("plugins1"), dir.Join("plugins2")}})
+	cmd := newListCommand(cli)
+	cmd.SetArgs([]string{"--show-all-dirs", "--verbose"})
+	assert.NilError(t, cmd.Execute())
+
+	const invalidErr = `invalid metadata output: expected JSON, but the plugin printed "not json"`
+	expected := "aaa\n" +
+		"  * " + dir.Join("plugins1", "docker-aaa") + " (active)\n" +
+		"    " + dir.Join("plugins2", "docker-aaa") + " (shadowed, invalid: " + invalidErr + ")\n" +
+		"bbb\n" +
+		"    " + dir.Join("plugins1", "docker-bbb") + " (invalid: " + invalidErr + ")\n" +
+		"    " + dir.Join("plugins2", "docker-bbb") + " (shadowed)\n" +
+		"ccc\n" +
+		"    " + dir.Join("plugins1", "docker-ccc") + " (invalid: failed to fetch metadata: "
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), expected))
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), "permission denied)\n"))
+}
+
+func TestListCLIPluginsVerbose(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("not-a-dir", ""),
+		fs.WithDir("plugins",
+			fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+		),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Join("not-a-dir"), dir.Join("plugins")}})
+	cmd := newListCommand(cli)
+	cmd.SetArgs([]string{"-v", "-q"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "aaa\n"))
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "WARNING: failed to read plugin directory: "+dir.Join("not-a-dir")+" is a file, not a directory\n"))
+}
+
+func TestListCLIPluginsProbe(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-broken", `#!/bin/sh
+echo 'not json'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+	cmd := newListCommand(cli)
+	cmd.SetArgs([]string{"--probe", "--format", "{{.Name}}: {{.Status}} {{.Error}}"})
+	assert.NilError(t, cmd.Execute())
+	out := cli.OutBuffer().String()
+	assert.Check(t, is.Contains(out, "aaa: pass \n"))
+	assert.Check(t, is.Contains(out, `broken: fail invalid metadata output: expected JSON, but the plugin printed "not json"`))
+}
+
+func TestListCLIPluginsCheckUpgrades(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing","Version":"1.0.0"}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-bbb", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing","Version":"2.0.0"}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"name":"aaa","version":"1.1.0"},{"name":"bbb","version":"2.0.0"}]`))
+	}))
+	defer srv.Close()
+
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs: []string{dir.Path()},
+		CLIPluginsIndexURL:  srv.URL,
+	})
+	cmd := newListCommand(cli)
+	cmd.SetArgs([]string{"--check-upgrades", "--format", "{{.Name}}: {{.Upgrade}}"})
+	assert.NilError(t, cmd.Execute())
+	out := cli.OutBuffer().String()
+	assert.Check(t, is.Contains(out, "aaa: 1.1.0\n"))
+	assert.Check(t, is.Contains(out, "bbb: \n"))
+}
@@ -0,0 +1,32 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli-plugins/manager"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/spf13/cobra"
+)
+
+func newPruneCacheCommand(dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "prune-cache",
+		Short: "Remove the cached metadata of CLI plugins",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPruneCache(dockerCli)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+}
+
+func runPruneCache(dockerCli command.Cli) error {
+	n, err := manager.PruneMetadataCache(dockerCli)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(dockerCli.Out(), "Removed cached metadata of %d CLI plugin(s)\n", n)
+	return nil
+}
@@ -0,0 +1,29 @@
+package plugin
+
+import (
+	"os"
+	"testing"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestPruneCache(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(), fs.WithFile("cli-plugins-metadata-cache.json", `{
+  "/usr/libexec/docker/cli-plugins/docker-aaa": {"modTime":"2024-01-01T00:00:00Z","size":10,"metadata":{}}
+}`))
+	defer dir.Remove()
+
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetConfigFile(&configfile.ConfigFile{Filename: dir.Join("config.json")})
+	cmd := newPruneCacheCommand(cli)
+	cmd.SetArgs([]string{})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "Removed cached metadata of 1 CLI plugin(s)\n"))
+
+	_, err := os.Stat(dir.Join("cli-plugins-metadata-cache.json"))
+	assert.Check(t, os.IsNotExist(err))
+}
@@ -0,0 +1,63 @@
+package plugin
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli-plugins/manager"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/spf13/cobra"
+)
+
+type whichOptions struct {
+	name string
+	all  bool
+}
+
+func newWhichCommand(dockerCli command.Cli) *cobra.Command {
+	var opts whichOptions
+
+	cmd := &cobra.Command{
+		Use:   "which [OPTIONS] PLUGIN",
+		Short: "Print the path of a CLI plugin",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.name = args[0]
+			return runWhich(dockerCli, cmd.Root(), opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVarP(&opts.all, "all", "a", false, "Print all candidate paths, including shadowed ones")
+	return cmd
+}
+
+// runWhich prints the path of the CLI plugin that is run for the given name.
+// With --all, the paths of all candidates with that name are printed in order
+// of precedence, starting with the path that is run.
+func runWhich(dockerCli command.Cli, rootCmd *cobra.Command, opts whichOptions) error {
+	p, err := manager.GetPlugin(opts.name, dockerCli, rootCmd)
+	if err != nil {
+		return err
+	}
+	if p.Err != nil {
+		_, _ = fmt.Fprintf(dockerCli.Err(), "WARNING: plugin %q is not valid: %v\n", p.Name, p.Err)
+	}
+
+	paths := []string{p.Path}
+	if opts.all {
+		if candidates := manager.ListPluginCandidates(dockerCli)[opts.name]; len(candidates) > 0 {
+			paths = candidates
+		}
+	}
+	for _, path := range paths {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		_, _ = fmt.Fprintln(dockerCli.Out(), path)
+	}
+	return nil
+}
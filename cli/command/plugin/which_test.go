@@ -0,0 +1,73 @@
+package plugin
+
+import (
+	"io"
+	"testing"
+
+	"github.com/docker/cli/cli-plugins/manager"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestWhich(t *testing.T) {
+	const meta = `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("plugins1",
+			fs.WithFile("docker-whichtest", meta, fs.WithMode(0o777)),
+		),
+		fs.WithDir("plugins2",
+			fs.WithFile("docker-whichtest", meta, fs.WithMode(0o777)),
+		),
+	)
+	defer dir.Remove()
+
+	testCases := []struct {
+		doc      string
+		args     []string
+		expected string
+	}{
+		{
+			doc:      "active plugin",
+			args:     []string{"whichtest"},
+			expected: dir.Join("plugins1", "docker-whichtest") + "\n",
+		},
+		{
+			doc:  "all candidates",
+			args: []string{"--all", "whichtest"},
+			expected: dir.Join("plugins1", "docker-whichtest") + "\n" +
+				dir.Join("plugins2", "docker-whichtest") + "\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{})
+			cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Join("plugins1"), dir.Join("plugins2")}})
+			cmd := newWhichCommand(cli)
+			cmd.SetArgs(tc.args)
+			assert.NilError(t, cmd.Execute())
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), tc.expected))
+			assert.Check(t, is.Equal(cli.ErrBuffer().String(), ""))
+		})
+	}
+}
+
+func TestWhichNotFound(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+
+	for _, args := range [][]string{{"whichnotfound"}, {"--all", "whichnotfound"}} {
+		cli := test.NewFakeCli(&fakeClient{})
+		cli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+		cmd := newWhichCommand(cli)
+		cmd.SetArgs(args)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		err := cmd.Execute()
+		assert.Check(t, manager.IsNotFound(err), "expected a not-found error, got %v", err)
+		assert.Check(t, is.Equal(cli.OutBuffer().String(), ""))
+	}
+}
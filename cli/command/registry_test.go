@@ -3,6 +3,7 @@
 import (
 	"bytes"
 	"path"
+	"path/filepath"
 	"testing"
 
 	"github.com/docker/cli/cli/command"
@@ -57,7 +58,7 @@ func TestGetDefaultAuthConfig(t *testing.T) {
 			expectedAuthConfig: testAuthConfigs[1],
 		},
 	}
-	cfg := configfile.New("filename")
+	cfg := configfile.New(filepath.Join(t.TempDir(), "config.json"))
 	for _, authconfig := range testAuthConfigs {
 		assert.Check(t, cfg.GetCredentialsStore(authconfig.ServerAddress).Store(configtypes.AuthConfig(authconfig)))
 	}
@@ -70,7 +71,7 @@ func TestGetDefaultAuthConfig(t *testing.T) {
 }
 
 func TestGetDefaultAuthConfig_HelperError(t *testing.T) {
-	cfg := configfile.New("filename")
+	cfg := configfile.New(filepath.Join(t.TempDir(), "config.json"))
 	cfg.CredentialsStore = "fake-does-not-exist"
 
 	const serverAddress = "test-server-address"
@@ -5,11 +5,14 @@
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/command/completion"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/moby/sys/atomicwriter"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -26,6 +29,48 @@ type initOptions struct {
 	availability              string
 	defaultAddrPools          []net.IPNet
 	DefaultAddrPoolMaskLength uint32
+	workerTokenFile           string
+	managerTokenFile          string
+	inspectRetries            int
+	ifNotInitialized          bool
+	quiet                     int
+}
+
+// inspectBackoff is the delay before the first retry of a request that is
+// made after the swarm was initialized. The delay doubles for each retry.
+var inspectBackoff = 100 * time.Millisecond
+
+// postInitExitCode is the exit status of "docker swarm init" if the swarm
+// was initialized, but a step after initializing the swarm failed.
+const postInitExitCode = 3
+
+// PostInitError is returned by "docker swarm init" if the swarm was
+// initialized, but a step after initializing the swarm failed, for example
+// fetching the unlock key. The swarm exists, and the failed step can be
+// retried with other commands, such as "docker swarm unlock-key".
+type PostInitError struct {
+	NodeID string
+	Err    error
+}
+
+func (e *PostInitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PostInitError) Unwrap() error {
+	return e.Err
+}
+
+// postInitError wraps err in a [PostInitError], so that the command exits
+// with [postInitExitCode]. It returns nil if err is nil.
+func postInitError(nodeID string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return cli.StatusError{
+		Cause:      &PostInitError{NodeID: nodeID, Err: err},
+		StatusCode: postInitExitCode,
+	}
 }
 
 func newInitCommand(dockerCli command.Cli) *cobra.Command {
@@ -49,18 +94,24 @@ func newInitCommand(dockerCli command.Cli) *cobra.Command {
 
 	flags := cmd.Flags()
 	flags.Var(&opts.listenAddr, flagListenAddr, `Listen address (format: "<ip|interface>[:port]")`)
-	flags.StringVar(&opts.advertiseAddr, flagAdvertiseAddr, "", `Advertised address (format: "<ip|interface>[:port]")`)
+	flags.StringVar(&opts.advertiseAddr, flagAdvertiseAddr, "", `Advertised address (format: "<ip|interface>[:port]", or "auto:<interface>")`)
 	flags.StringVar(&opts.dataPathAddr, flagDataPathAddr, "", `Address or interface to use for data path traffic (format: "<ip|interface>")`)
 	flags.SetAnnotation(flagDataPathAddr, "version", []string{"1.31"})
 	flags.Uint32Var(&opts.dataPathPort, flagDataPathPort, 0, "Port number to use for data path traffic (1024 - 49151). If no value is set or is set to 0, the default port (4789) is used.")
 	flags.SetAnnotation(flagDataPathPort, "version", []string{"1.40"})
-	flags.BoolVar(&opts.forceNewCluster, "force-new-cluster", false, "Force create a new cluster from current state")
+	flags.BoolVar(&opts.forceNewCluster, flagForceNewCluster, false, "Force create a new cluster from current state")
 	flags.BoolVar(&opts.autolock, flagAutolock, false, "Enable manager autolocking (requiring an unlock key to start a stopped manager)")
 	flags.StringVar(&opts.availability, flagAvailability, "active", `Availability of the node ("active", "pause", "drain")`)
 	flags.IPNetSliceVar(&opts.defaultAddrPools, flagDefaultAddrPool, []net.IPNet{}, "default address pool in CIDR format")
 	flags.SetAnnotation(flagDefaultAddrPool, "version", []string{"1.39"})
 	flags.Uint32Var(&opts.DefaultAddrPoolMaskLength, flagDefaultAddrPoolMaskLength, 24, "default address pool subnet mask length")
 	flags.SetAnnotation(flagDefaultAddrPoolMaskLength, "version", []string{"1.39"})
+	flags.StringVar(&opts.workerTokenFile, flagWorkerTokenFile, "", "Write the worker join token to a file")
+	flags.StringVar(&opts.managerTokenFile, flagManagerTokenFile, "", "Write the manager join token to a file")
+	flags.IntVar(&opts.inspectRetries, flagInspectRetries, 3, "Number of times to retry inspecting the swarm after it was initialized")
+	_ = flags.MarkHidden(flagInspectRetries)
+	flags.BoolVar(&opts.ifNotInitialized, flagIfNotInitialized, false, "Do not fail if the node is already part of a swarm")
+	flags.CountVarP(&opts.quiet, flagQuiet, "q", "Only display the node ID, or nothing if repeated")
 	addSwarmFlags(flags, &opts.swarmOptions)
 	return cmd
 }
@@ -68,14 +119,42 @@ func newInitCommand(dockerCli command.Cli) *cobra.Command {
 func runInit(ctx context.Context, dockerCLI command.Cli, flags *pflag.FlagSet, opts initOptions) error {
 	apiClient := dockerCLI.Client()
 
+	if opts.DefaultAddrPoolMaskLength == 0 {
+		return errors.Errorf("invalid --%s %d: must be a positive number", flagDefaultAddrPoolMaskLength, opts.DefaultAddrPoolMaskLength)
+	}
 	defaultAddrPool := make([]string, 0, len(opts.defaultAddrPools))
 	for _, p := range opts.defaultAddrPools {
+		ones, bits := p.Mask.Size()
+		if opts.DefaultAddrPoolMaskLength > uint32(bits) {
+			return errors.Errorf("invalid --%s %d: must be at most %d for address pool %s", flagDefaultAddrPoolMaskLength, opts.DefaultAddrPoolMaskLength, bits, p.String())
+		}
+		if opts.DefaultAddrPoolMaskLength < uint32(ones) {
+			return errors.Errorf("invalid --%s %d: must be at least the prefix length of address pool %s", flagDefaultAddrPoolMaskLength, opts.DefaultAddrPoolMaskLength, p.String())
+		}
 		defaultAddrPool = append(defaultAddrPool, p.String())
 	}
+	if flags.Changed(flagCertExpiry) && opts.nodeCertExpiry <= 0 {
+		return errors.Errorf("invalid --%s %s: must be a positive duration", flagCertExpiry, opts.nodeCertExpiry)
+	}
+	if flags.Changed(flagSnapshotInterval) && opts.snapshotInterval == 0 {
+		return errors.Errorf("invalid --%s %d: must be a positive number", flagSnapshotInterval, opts.snapshotInterval)
+	}
+	advertiseAddr, err := resolveAutoAddr(opts.advertiseAddr)
+	if err != nil {
+		return err
+	}
+	advertiseAddr, err = normalizeIPv6Addr(advertiseAddr)
+	if err != nil {
+		return err
+	}
+	dataPathAddr, err := normalizeDataPathAddr(opts.dataPathAddr)
+	if err != nil {
+		return err
+	}
 	req := swarm.InitRequest{
 		ListenAddr:       opts.listenAddr.String(),
-		AdvertiseAddr:    opts.advertiseAddr,
-		DataPathAddr:     opts.dataPathAddr,
+		AdvertiseAddr:    advertiseAddr,
+		DataPathAddr:     dataPathAddr,
 		DataPathPort:     opts.dataPathPort,
 		DefaultAddrPool:  defaultAddrPool,
 		ForceNewCluster:  opts.forceNewCluster,
@@ -93,7 +172,38 @@ func runInit(ctx context.Context, dockerCLI command.Cli, flags *pflag.FlagSet, o
 		}
 	}
 
+	if opts.ifNotInitialized {
+		info, err := apiClient.Info(ctx)
+		if err != nil {
+			return err
+		}
+		switch info.Swarm.LocalNodeState {
+		case swarm.LocalNodeStateActive, swarm.LocalNodeStatePending, swarm.LocalNodeStateLocked:
+			switch opts.quiet {
+			case 0:
+				_, _ = fmt.Fprintf(dockerCLI.Out(), "Node %s is already part of a swarm.\n", info.Swarm.NodeID)
+			case 1:
+				_, _ = fmt.Fprintln(dockerCLI.Out(), info.Swarm.NodeID)
+			}
+			return nil
+		}
+	}
+
+	if opts.forceNewCluster {
+		_, _ = fmt.Fprintln(dockerCLI.Err(), "WARNING: --force-new-cluster creates a new single-manager cluster from the current state of this node. All other managers are removed from the swarm, and must join again.")
+	}
+
+	// Generating the CA can take a while on constrained hardware, so let
+	// the user know that the command is not stuck. The message is printed
+	// to stderr so that it doesn't end up in the output of the command.
+	showProgress := opts.quiet == 0 && dockerCLI.Err().IsTerminal()
+	if showProgress {
+		_, _ = fmt.Fprint(dockerCLI.Err(), "Initializing swarm...")
+	}
 	nodeID, err := apiClient.SwarmInit(ctx, req)
+	if showProgress {
+		_, _ = fmt.Fprint(dockerCLI.Err(), "\r\033[K")
+	}
 	if err != nil {
 		if strings.Contains(err.Error(), "could not choose an IP address to advertise") || strings.Contains(err.Error(), "could not find the system's IP address") {
 			return errors.New(err.Error() + " - specify one with --advertise-addr")
@@ -101,21 +211,111 @@ func runInit(ctx context.Context, dockerCLI command.Cli, flags *pflag.FlagSet, o
 		return err
 	}
 
+	if opts.quiet > 0 {
+		// The join command can be obtained later using "docker swarm
+		// join-token". The unlock key is always printed, as a bare line,
+		// because the manager can't be restarted without it.
+		if opts.quiet == 1 {
+			_, _ = fmt.Fprintln(dockerCLI.Out(), nodeID)
+		}
+		if req.AutoLockManagers {
+			unlockKey, err := getUnlockKey(ctx, apiClient, opts)
+			if err != nil {
+				return postInitError(nodeID, err)
+			}
+			_, _ = fmt.Fprintln(dockerCLI.Out(), unlockKey)
+		}
+		return postInitError(nodeID, writeJoinTokens(ctx, apiClient, opts))
+	}
+
 	_, _ = fmt.Fprintf(dockerCLI.Out(), "Swarm initialized: current node (%s) is now a manager.\n\n", nodeID)
 
-	if err := printJoinCommand(ctx, dockerCLI, nodeID, true, false); err != nil {
-		return err
+	// The swarm was initialized at this point, so retry any failing requests
+	// below to account for transient errors, instead of failing the command.
+	err = retryInspect(ctx, opts.inspectRetries, func() error {
+		return printJoinCommand(ctx, dockerCLI, nodeID, true, false)
+	})
+	if err != nil {
+		return postInitError(nodeID, err)
 	}
 
 	_, _ = fmt.Fprintln(dockerCLI.Out(), "To add a manager to this swarm, run 'docker swarm join-token manager' and follow the instructions.")
 
 	if req.AutoLockManagers {
-		unlockKeyResp, err := apiClient.SwarmGetUnlockKey(ctx)
+		unlockKey, err := getUnlockKey(ctx, apiClient, opts)
 		if err != nil {
-			return errors.Wrap(err, "could not fetch unlock key")
+			return postInitError(nodeID, err)
 		}
-		printUnlockCommand(dockerCLI.Out(), unlockKeyResp.UnlockKey)
+		printUnlockCommand(dockerCLI.Out(), unlockKey)
 	}
 
+	// Write the join tokens last, so that failing to write them does not
+	// prevent the unlock key from being printed; it cannot be recovered if
+	// the swarm is locked.
+	if err := writeJoinTokens(ctx, apiClient, opts); err != nil {
+		return postInitError(nodeID, err)
+	}
 	return nil
 }
+
+// getUnlockKey returns the key to unlock the managers of the swarm that was
+// just initialized, retrying to account for transient errors.
+func getUnlockKey(ctx context.Context, apiClient client.SwarmAPIClient, opts initOptions) (string, error) {
+	var unlockKeyResp swarm.UnlockKeyResponse
+	err := retryInspect(ctx, opts.inspectRetries, func() error {
+		var err error
+		unlockKeyResp, err = apiClient.SwarmGetUnlockKey(ctx)
+		return err
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "could not fetch unlock key")
+	}
+	return unlockKeyResp.UnlockKey, nil
+}
+
+// writeJoinTokens writes the swarm's join tokens to the files specified
+// through the --worker-token-file and --manager-token-file options.
+func writeJoinTokens(ctx context.Context, apiClient client.SwarmAPIClient, opts initOptions) error {
+	if opts.workerTokenFile == "" && opts.managerTokenFile == "" {
+		return nil
+	}
+	var sw swarm.Swarm
+	err := retryInspect(ctx, opts.inspectRetries, func() error {
+		var err error
+		sw, err = apiClient.SwarmInspect(ctx)
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not fetch join tokens")
+	}
+	if opts.workerTokenFile != "" {
+		if err := atomicwriter.WriteFile(opts.workerTokenFile, []byte(sw.JoinTokens.Worker+"\n"), 0o600); err != nil {
+			return errors.Wrap(err, "could not write worker join token")
+		}
+	}
+	if opts.managerTokenFile != "" {
+		if err := atomicwriter.WriteFile(opts.managerTokenFile, []byte(sw.JoinTokens.Manager+"\n"), 0o600); err != nil {
+			return errors.Wrap(err, "could not write manager join token")
+		}
+	}
+	return nil
+}
+
+// retryInspect calls fn until it succeeds, or until it failed after the given
+// number of retries, in which case the last error is returned. It waits for
+// inspectBackoff before the first retry, and doubles the delay for each retry.
+func retryInspect(ctx context.Context, retries int, fn func() error) error {
+	delay := inspectBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
@@ -4,18 +4,28 @@
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/docker/cli/cli"
 	"github.com/docker/cli/internal/test"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/api/types/system"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 	"gotest.tools/v3/golden"
 )
 
 func TestSwarmInitErrorOnAPIFailure(t *testing.T) {
+	defer func(orig time.Duration) { inspectBackoff = orig }(inspectBackoff)
+	inspectBackoff = time.Millisecond
+
 	testCases := []struct {
 		name                  string
 		flags                 map[string]string
@@ -63,6 +73,68 @@ func TestSwarmInitErrorOnAPIFailure(t *testing.T) {
 			},
 			expectedError: "could not fetch unlock key: error getting swarm unlock key",
 		},
+		{
+			name: "negative-cert-expiry",
+			flags: map[string]string{
+				flagCertExpiry: "-1h",
+			},
+			swarmInitFunc: func(swarm.InitRequest) (string, error) {
+				return "", errors.New("should not be called")
+			},
+			expectedError: "invalid --cert-expiry -1h0m0s: must be a positive duration",
+		},
+		{
+			name: "zero-cert-expiry",
+			flags: map[string]string{
+				flagCertExpiry: "0s",
+			},
+			swarmInitFunc: func(swarm.InitRequest) (string, error) {
+				return "", errors.New("should not be called")
+			},
+			expectedError: "invalid --cert-expiry 0s: must be a positive duration",
+		},
+		{
+			name: "zero-snapshot-interval",
+			flags: map[string]string{
+				flagSnapshotInterval: "0",
+			},
+			swarmInitFunc: func(swarm.InitRequest) (string, error) {
+				return "", errors.New("should not be called")
+			},
+			expectedError: "invalid --snapshot-interval 0: must be a positive number",
+		},
+		{
+			name: "zero-default-addr-pool-mask-length",
+			flags: map[string]string{
+				flagDefaultAddrPoolMaskLength: "0",
+			},
+			swarmInitFunc: func(swarm.InitRequest) (string, error) {
+				return "", errors.New("should not be called")
+			},
+			expectedError: "invalid --default-addr-pool-mask-length 0: must be a positive number",
+		},
+		{
+			name: "default-addr-pool-mask-length-too-long",
+			flags: map[string]string{
+				flagDefaultAddrPool:           "10.20.0.0/16",
+				flagDefaultAddrPoolMaskLength: "33",
+			},
+			swarmInitFunc: func(swarm.InitRequest) (string, error) {
+				return "", errors.New("should not be called")
+			},
+			expectedError: "invalid --default-addr-pool-mask-length 33: must be at most 32 for address pool 10.20.0.0/16",
+		},
+		{
+			name: "default-addr-pool-mask-length-too-short",
+			flags: map[string]string{
+				flagDefaultAddrPool:           "10.20.0.0/16",
+				flagDefaultAddrPoolMaskLength: "8",
+			},
+			swarmInitFunc: func(swarm.InitRequest) (string, error) {
+				return "", errors.New("should not be called")
+			},
+			expectedError: "invalid --default-addr-pool-mask-length 8: must be at least the prefix length of address pool 10.20.0.0/16",
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -113,6 +185,45 @@ func TestSwarmInit(t *testing.T) {
 				}, nil
 			},
 		},
+		{
+			name: "init-quiet",
+			flags: map[string]string{
+				flagQuiet:    "1",
+				flagAutolock: "true",
+			},
+			swarmInitFunc: func(swarm.InitRequest) (string, error) {
+				return "nodeID", nil
+			},
+			swarmGetUnlockKeyFunc: func() (swarm.UnlockKeyResponse, error) {
+				return swarm.UnlockKeyResponse{
+					UnlockKey: "unlock-key",
+				}, nil
+			},
+		},
+		{
+			name: "init-quiet-quiet",
+			flags: map[string]string{
+				flagQuiet: "2",
+			},
+			swarmInitFunc: func(swarm.InitRequest) (string, error) {
+				return "nodeID", nil
+			},
+		},
+		{
+			name: "init-quiet-quiet-autolock",
+			flags: map[string]string{
+				flagQuiet:    "2",
+				flagAutolock: "true",
+			},
+			swarmInitFunc: func(swarm.InitRequest) (string, error) {
+				return "nodeID", nil
+			},
+			swarmGetUnlockKeyFunc: func() (swarm.UnlockKeyResponse, error) {
+				return swarm.UnlockKeyResponse{
+					UnlockKey: "unlock-key",
+				}, nil
+			},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -159,3 +270,498 @@ func TestSwarmInitWithExternalCA(t *testing.T) {
 	assert.NilError(t, cmd.Flags().Set(flagExternalCA, "protocol=cfssl,url=https://example.com,cacert="+certFile))
 	assert.NilError(t, cmd.Execute())
 }
+
+func TestSwarmInitWriteJoinTokens(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		swarmInitFunc: func(swarm.InitRequest) (string, error) {
+			return "nodeID", nil
+		},
+		swarmInspectFunc: func() (swarm.Swarm, error) {
+			return swarm.Swarm{
+				JoinTokens: swarm.JoinTokens{
+					Worker:  "worker-join-token",
+					Manager: "manager-join-token",
+				},
+			}, nil
+		},
+	})
+
+	tempDir := t.TempDir()
+	workerFile := filepath.Join(tempDir, "worker-token")
+	managerFile := filepath.Join(tempDir, "manager-token")
+
+	cmd := newInitCommand(cli)
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.NilError(t, cmd.Flags().Set(flagWorkerTokenFile, workerFile))
+	assert.NilError(t, cmd.Flags().Set(flagManagerTokenFile, managerFile))
+	assert.NilError(t, cmd.Execute())
+
+	for file, expected := range map[string]string{
+		workerFile:  "worker-join-token\n",
+		managerFile: "manager-join-token\n",
+	} {
+		content, err := os.ReadFile(file)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(string(content), expected))
+		if runtime.GOOS != "windows" {
+			fi, err := os.Stat(file)
+			assert.NilError(t, err)
+			assert.Check(t, is.Equal(fi.Mode().Perm(), os.FileMode(0o600)))
+		}
+	}
+}
+
+func TestSwarmInitWriteJoinTokensError(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		swarmInitFunc: func(swarm.InitRequest) (string, error) {
+			return "nodeID", nil
+		},
+		swarmGetUnlockKeyFunc: func() (swarm.UnlockKeyResponse, error) {
+			return swarm.UnlockKeyResponse{UnlockKey: "unlock-key"}, nil
+		},
+	})
+
+	cmd := newInitCommand(cli)
+	cmd.SetArgs([]string{"--" + flagAutolock})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.NilError(t, cmd.Flags().Set(flagWorkerTokenFile, filepath.Join(t.TempDir(), "no-such-dir", "worker-token")))
+	assert.ErrorContains(t, cmd.Execute(), "could not write worker join token")
+	// The unlock key is printed, even though writing the join tokens failed.
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), "unlock-key"))
+}
+
+func TestSwarmInitPostInitError(t *testing.T) {
+	defer func(orig time.Duration) { inspectBackoff = orig }(inspectBackoff)
+	inspectBackoff = time.Millisecond
+
+	fakeCli := test.NewFakeCli(&fakeClient{
+		swarmInitFunc: func(swarm.InitRequest) (string, error) {
+			return "nodeID", nil
+		},
+		swarmGetUnlockKeyFunc: func() (swarm.UnlockKeyResponse, error) {
+			return swarm.UnlockKeyResponse{}, errors.New("error getting swarm unlock key")
+		},
+	})
+	cmd := newInitCommand(fakeCli)
+	cmd.SetArgs([]string{"--" + flagAutolock})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	err := cmd.Execute()
+	assert.Error(t, err, "could not fetch unlock key: error getting swarm unlock key")
+
+	var statusErr cli.StatusError
+	assert.Assert(t, errors.As(err, &statusErr))
+	assert.Check(t, is.Equal(statusErr.StatusCode, postInitExitCode))
+	var postInitErr *PostInitError
+	assert.Assert(t, errors.As(err, &postInitErr))
+	assert.Check(t, is.Equal(postInitErr.NodeID, "nodeID"))
+
+	// Failing to initialize the swarm is not a post-init error.
+	fakeCli = test.NewFakeCli(&fakeClient{
+		swarmInitFunc: func(swarm.InitRequest) (string, error) {
+			return "", errors.New("error initializing the swarm")
+		},
+	})
+	cmd = newInitCommand(fakeCli)
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	err = cmd.Execute()
+	assert.Error(t, err, "error initializing the swarm")
+	assert.Check(t, !errors.As(err, &postInitErr))
+}
+
+func TestSwarmInitWithMultipleExternalCAs(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		swarmInitFunc: func(req swarm.InitRequest) (string, error) {
+			if assert.Check(t, is.Len(req.Spec.CAConfig.ExternalCAs, 2)) {
+				assert.Check(t, is.Equal(req.Spec.CAConfig.ExternalCAs[0].URL, "https://ca1.example.com"))
+				assert.Check(t, is.Equal(req.Spec.CAConfig.ExternalCAs[1].URL, "https://ca2.example.com"))
+			}
+			return "nodeID", nil
+		},
+	})
+
+	cmd := newInitCommand(cli)
+	cmd.SetArgs([]string{
+		"--external-ca", "protocol=cfssl,url=https://ca1.example.com",
+		"--external-ca", "protocol=cfssl,url=https://ca2.example.com",
+	})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.NilError(t, cmd.Execute())
+}
+
+func TestSwarmInitWithInvalidExternalCA(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		swarmInitFunc: func(req swarm.InitRequest) (string, error) {
+			return "", errors.New("swarm init should not be called")
+		},
+	})
+
+	cmd := newInitCommand(cli)
+	cmd.SetArgs([]string{
+		"--external-ca", "protocol=cfssl,url=https://ca1.example.com",
+		"--external-ca", "protocol=cfssl",
+	})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.ErrorContains(t, cmd.Execute(), "the external-ca option needs a url= parameter")
+}
+
+func TestSwarmInitIPv6AdvertiseAddr(t *testing.T) {
+	var req swarm.InitRequest
+	cli := test.NewFakeCli(&fakeClient{
+		swarmInitFunc: func(r swarm.InitRequest) (string, error) {
+			req = r
+			return "nodeID", nil
+		},
+	})
+	cmd := newInitCommand(cli)
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, cmd.Flags().Set(flagListenAddr, "[::1]"))
+	assert.Check(t, cmd.Flags().Set(flagAdvertiseAddr, "[::1]"))
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(req.ListenAddr, "[::1]:2377"))
+	assert.Check(t, is.Equal(req.AdvertiseAddr, "::1"))
+}
+
+func TestSwarmInitAutoAdvertiseAddr(t *testing.T) {
+	defer func(orig func(string) ([]net.Addr, error)) { interfaceAddrs = orig }(interfaceAddrs)
+	interfaceAddrs = func(string) ([]net.Addr, error) {
+		return []net.Addr{&net.IPNet{IP: net.ParseIP("192.168.1.10"), Mask: net.CIDRMask(24, 32)}}, nil
+	}
+
+	var req swarm.InitRequest
+	cli := test.NewFakeCli(&fakeClient{
+		swarmInitFunc: func(r swarm.InitRequest) (string, error) {
+			req = r
+			return "nodeID", nil
+		},
+	})
+	cmd := newInitCommand(cli)
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, cmd.Flags().Set(flagAdvertiseAddr, "auto:eth0"))
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(req.AdvertiseAddr, "192.168.1.10"))
+}
+
+func TestSwarmInitInvalidIPv6AdvertiseAddr(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		swarmInitFunc: func(swarm.InitRequest) (string, error) {
+			return "", errors.New("should not be called")
+		},
+	})
+	cmd := newInitCommand(cli)
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, cmd.Flags().Set(flagAdvertiseAddr, "[eth0]:2377"))
+	assert.Error(t, cmd.Execute(), `invalid address "[eth0]:2377": "eth0" is not a valid IPv6 address`)
+}
+
+func TestSwarmInitDataPathAddr(t *testing.T) {
+	var req swarm.InitRequest
+	cli := test.NewFakeCli(&fakeClient{
+		swarmInitFunc: func(r swarm.InitRequest) (string, error) {
+			req = r
+			return "nodeID", nil
+		},
+	})
+	cmd := newInitCommand(cli)
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, cmd.Flags().Set(flagDataPathAddr, "[fd00::1]"))
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(req.DataPathAddr, "fd00::1"))
+
+	cmd = newInitCommand(cli)
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, cmd.Flags().Set(flagDataPathAddr, "10.0.0.1:4789"))
+	assert.Error(t, cmd.Execute(), `invalid address "10.0.0.1:4789": a port cannot be specified for the data path address`)
+}
+
+func TestSwarmInitDefaultAddrPool(t *testing.T) {
+	var req swarm.InitRequest
+	cli := test.NewFakeCli(&fakeClient{
+		swarmInitFunc: func(r swarm.InitRequest) (string, error) {
+			req = r
+			return "nodeID", nil
+		},
+	})
+	cmd := newInitCommand(cli)
+	cmd.SetArgs([]string{
+		"--default-addr-pool", "10.20.0.0/16",
+		"--default-addr-pool", "10.30.0.0/16",
+		"--default-addr-pool-mask-length", "26",
+	})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.DeepEqual(req.DefaultAddrPool, []string{"10.20.0.0/16", "10.30.0.0/16"}))
+	assert.Check(t, is.Equal(req.SubnetSize, uint32(26)))
+
+	cmd = newInitCommand(cli)
+	cmd.SetArgs([]string{"--default-addr-pool", "10.20.0.0"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, is.ErrorContains(cmd.Execute(), "invalid string being converted to CIDR: 10.20.0.0"))
+}
+
+func TestSwarmInitForceNewCluster(t *testing.T) {
+	var req swarm.InitRequest
+	cli := test.NewFakeCli(&fakeClient{
+		swarmInitFunc: func(r swarm.InitRequest) (string, error) {
+			req = r
+			return "nodeID", nil
+		},
+	})
+	cmd := newInitCommand(cli)
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, cmd.Flags().Set(flagForceNewCluster, "true"))
+	assert.Check(t, cmd.Flags().Set(flagAutolock, "true"))
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, req.ForceNewCluster)
+	assert.Check(t, req.AutoLockManagers)
+	assert.Check(t, is.Contains(cli.ErrBuffer().String(), "WARNING: --force-new-cluster"))
+}
+
+func TestSwarmInitProgress(t *testing.T) {
+	for _, isTerminal := range []bool{false, true} {
+		t.Run(fmt.Sprintf("terminal=%t", isTerminal), func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{
+				swarmInitFunc: func(swarm.InitRequest) (string, error) {
+					return "nodeID", nil
+				},
+			})
+			cli.Err().SetIsTerminal(isTerminal)
+			cmd := newInitCommand(cli)
+			cmd.SetArgs([]string{})
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.NilError(t, cmd.Execute())
+			golden.Assert(t, cli.OutBuffer().String(), "init-init.golden")
+			if isTerminal {
+				assert.Check(t, is.Equal(cli.ErrBuffer().String(), "Initializing swarm...\r\033[K"))
+			} else {
+				assert.Check(t, is.Equal(cli.ErrBuffer().String(), ""))
+			}
+		})
+	}
+}
+
+func TestSwarmInitIfNotInitialized(t *testing.T) {
+	testCases := []struct {
+		doc            string
+		state          swarm.LocalNodeState
+		quiet          string
+		expectedInit   bool
+		expectedOutput string
+	}{
+		{
+			doc:            "active",
+			state:          swarm.LocalNodeStateActive,
+			expectedOutput: "Node nodeID is already part of a swarm.\n",
+		},
+		{
+			doc:            "locked",
+			state:          swarm.LocalNodeStateLocked,
+			expectedOutput: "Node nodeID is already part of a swarm.\n",
+		},
+		{
+			doc:            "inactive",
+			state:          swarm.LocalNodeStateInactive,
+			expectedInit:   true,
+			expectedOutput: "Swarm initialized: current node (newNodeID) is now a manager.\n",
+		},
+		{
+			doc:            "active quiet",
+			state:          swarm.LocalNodeStateActive,
+			quiet:          "-q",
+			expectedOutput: "nodeID\n",
+		},
+		{
+			doc:            "inactive quiet",
+			state:          swarm.LocalNodeStateInactive,
+			quiet:          "-q",
+			expectedInit:   true,
+			expectedOutput: "newNodeID\n",
+		},
+		{
+			doc:   "active quiet quiet",
+			state: swarm.LocalNodeStateActive,
+			quiet: "-qq",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			var initCalled bool
+			cli := test.NewFakeCli(&fakeClient{
+				infoFunc: func() (system.Info, error) {
+					return system.Info{Swarm: swarm.Info{NodeID: "nodeID", LocalNodeState: tc.state}}, nil
+				},
+				swarmInitFunc: func(swarm.InitRequest) (string, error) {
+					initCalled = true
+					return "newNodeID", nil
+				},
+			})
+			cmd := newInitCommand(cli)
+			args := []string{"--" + flagIfNotInitialized}
+			if tc.quiet != "" {
+				args = append(args, tc.quiet)
+			}
+			cmd.SetArgs(args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.NilError(t, cmd.Execute())
+			assert.Check(t, is.Equal(initCalled, tc.expectedInit))
+			if tc.quiet != "" {
+				assert.Check(t, is.Equal(cli.OutBuffer().String(), tc.expectedOutput))
+			} else {
+				assert.Check(t, strings.HasPrefix(cli.OutBuffer().String(), tc.expectedOutput))
+			}
+		})
+	}
+}
+
+func TestSwarmInitRetryInspect(t *testing.T) {
+	defer func(orig time.Duration) { inspectBackoff = orig }(inspectBackoff)
+	inspectBackoff = time.Millisecond
+
+	var initCalls, inspectCalls, unlockKeyCalls int
+	cli := test.NewFakeCli(&fakeClient{
+		swarmInitFunc: func(swarm.InitRequest) (string, error) {
+			initCalls++
+			return "nodeID", nil
+		},
+		swarmInspectFunc: func() (swarm.Swarm, error) {
+			inspectCalls++
+			if inspectCalls < 3 {
+				return swarm.Swarm{}, errors.New("transient error")
+			}
+			return swarm.Swarm{}, nil
+		},
+		swarmGetUnlockKeyFunc: func() (swarm.UnlockKeyResponse, error) {
+			unlockKeyCalls++
+			if unlockKeyCalls < 2 {
+				return swarm.UnlockKeyResponse{}, errors.New("transient error")
+			}
+			return swarm.UnlockKeyResponse{UnlockKey: "unlock-key"}, nil
+		},
+	})
+	cmd := newInitCommand(cli)
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, cmd.Flags().Set(flagAutolock, "true"))
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(initCalls, 1))
+	assert.Check(t, is.Equal(inspectCalls, 3))
+	assert.Check(t, is.Equal(unlockKeyCalls, 2))
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), "unlock-key"))
+}
+
+func TestSwarmInitRetryInspectExhausted(t *testing.T) {
+	defer func(orig time.Duration) { inspectBackoff = orig }(inspectBackoff)
+	inspectBackoff = time.Millisecond
+
+	var inspectCalls int
+	cli := test.NewFakeCli(&fakeClient{
+		swarmInspectFunc: func() (swarm.Swarm, error) {
+			inspectCalls++
+			return swarm.Swarm{}, errors.New("error inspecting the swarm")
+		},
+	})
+	cmd := newInitCommand(cli)
+	cmd.SetArgs([]string{"--inspect-retries", "1"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "error inspecting the swarm")
+	assert.Check(t, is.Equal(inspectCalls, 2))
+}
+
+func TestSwarmInitCertExpiry(t *testing.T) {
+	var req swarm.InitRequest
+	cli := test.NewFakeCli(&fakeClient{
+		swarmInitFunc: func(r swarm.InitRequest) (string, error) {
+			req = r
+			return "nodeID", nil
+		},
+	})
+	cmd := newInitCommand(cli)
+	cmd.SetArgs([]string{"--cert-expiry", "24h"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(req.Spec.CAConfig.NodeCertExpiry, 24*time.Hour))
+}
+
+func TestSwarmInitRaftSnapshots(t *testing.T) {
+	var req swarm.InitRequest
+	cli := test.NewFakeCli(&fakeClient{
+		swarmInitFunc: func(r swarm.InitRequest) (string, error) {
+			req = r
+			return "nodeID", nil
+		},
+	})
+	cmd := newInitCommand(cli)
+	cmd.SetArgs([]string{"--max-snapshots", "5", "--snapshot-interval", "5000"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.NilError(t, cmd.Execute())
+	assert.Assert(t, req.Spec.Raft.KeepOldSnapshots != nil)
+	assert.Check(t, is.Equal(*req.Spec.Raft.KeepOldSnapshots, uint64(5)))
+	assert.Check(t, is.Equal(req.Spec.Raft.SnapshotInterval, uint64(5000)))
+
+	cmd = newInitCommand(cli)
+	cmd.SetArgs([]string{"--max-snapshots", "-1"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.ErrorContains(t, cmd.Execute(), `invalid argument "-1" for "--max-snapshots" flag`)
+}
+
+func TestSwarmInitTaskHistoryLimit(t *testing.T) {
+	for _, limit := range []int64{0, 10, -1} {
+		t.Run(strconv.FormatInt(limit, 10), func(t *testing.T) {
+			var req swarm.InitRequest
+			cli := test.NewFakeCli(&fakeClient{
+				swarmInitFunc: func(r swarm.InitRequest) (string, error) {
+					req = r
+					return "nodeID", nil
+				},
+			})
+			cmd := newInitCommand(cli)
+			cmd.SetArgs([]string{"--task-history-limit", strconv.FormatInt(limit, 10)})
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.NilError(t, cmd.Execute())
+			assert.Assert(t, req.Spec.Orchestration.TaskHistoryRetentionLimit != nil)
+			assert.Check(t, is.Equal(*req.Spec.Orchestration.TaskHistoryRetentionLimit, limit))
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		cli := test.NewFakeCli(&fakeClient{
+			swarmInitFunc: func(swarm.InitRequest) (string, error) {
+				return "", errors.New("should not be called")
+			},
+		})
+		cmd := newInitCommand(cli)
+		cmd.SetArgs([]string{"--task-history-limit", "five"})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		assert.ErrorContains(t, cmd.Execute(), `invalid argument "five" for "--task-history-limit" flag`)
+	})
+}
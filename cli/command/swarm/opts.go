@@ -4,6 +4,7 @@
 	"encoding/csv"
 	"encoding/pem"
 	"fmt"
+	"net"
 	"os"
 	"strings"
 	"time"
@@ -36,6 +37,11 @@
 	flagAvailability              = "availability"
 	flagCACert                    = "ca-cert"
 	flagCAKey                     = "ca-key"
+	flagWorkerTokenFile           = "worker-token-file"
+	flagManagerTokenFile          = "manager-token-file"
+	flagInspectRetries            = "inspect-retries"
+	flagIfNotInitialized          = "if-not-initialized"
+	flagForceNewCluster           = "force-new-cluster"
 )
 
 type swarmOptions struct {
@@ -59,6 +65,10 @@ func (a *NodeAddrOption) String() string {
 
 // Set the value for this flag
 func (a *NodeAddrOption) Set(value string) error {
+	value, err := normalizeIPv6Addr(value)
+	if err != nil {
+		return err
+	}
 	addr, err := opts.ParseTCPAddr(value, a.addr)
 	if err != nil {
 		return err
@@ -87,6 +97,86 @@ func NewListenAddrOption() NodeAddrOption {
 	return NewNodeAddrOption(defaultListenAddr)
 }
 
+// normalizeIPv6Addr validates addresses that use the bracketed IPv6 notation
+// ("[ip]" or "[ip]:port"). The brackets are removed if no port is specified,
+// so that the default port is applied in the same way as for a bare IPv6
+// address. Other addresses are returned as-is.
+func normalizeIPv6Addr(value string) (string, error) {
+	if !strings.HasPrefix(value, "[") {
+		return value, nil
+	}
+	end := strings.Index(value, "]")
+	if end < 0 {
+		return "", errors.Errorf("invalid address %q: missing ']' in IPv6 address", value)
+	}
+	host, rest := value[1:end], value[end+1:]
+	if ip := net.ParseIP(host); ip == nil || ip.To4() != nil {
+		return "", errors.Errorf("invalid address %q: %q is not a valid IPv6 address", value, host)
+	}
+	switch {
+	case rest == "" || rest == ":":
+		return host, nil
+	case !strings.HasPrefix(rest, ":"):
+		return "", errors.Errorf("invalid address %q: expected a port after the IPv6 address", value)
+	}
+	return value, nil
+}
+
+// autoAddrPrefix is the prefix for addresses that are resolved from the
+// name of a network interface ("auto:<interface>").
+const autoAddrPrefix = "auto:"
+
+// interfaceAddrs returns the addresses of the named network interface. It is
+// a variable so that it can be replaced in tests.
+var interfaceAddrs = func(name string) ([]net.Addr, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return iface.Addrs()
+}
+
+// resolveAutoAddr resolves addresses in the "auto:<interface>" form to the
+// first non-loopback IPv4 address of the interface. Other addresses are
+// returned as-is.
+func resolveAutoAddr(value string) (string, error) {
+	name, ok := strings.CutPrefix(value, autoAddrPrefix)
+	if !ok {
+		return value, nil
+	}
+	if name == "" {
+		return "", errors.Errorf("invalid address %q: missing interface name", value)
+	}
+	addrs, err := interfaceAddrs(name)
+	if err != nil {
+		return "", errors.Errorf("invalid address %q: %v", value, err)
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() {
+			continue
+		}
+		if ip4 := ipNet.IP.To4(); ip4 != nil {
+			return ip4.String(), nil
+		}
+	}
+	return "", errors.Errorf("invalid address %q: interface %q has no non-loopback IPv4 address", value, name)
+}
+
+// normalizeDataPathAddr validates an address for data path traffic, which
+// must be an IP address or interface name without a port. Bracketed IPv6
+// addresses are accepted, and returned without brackets.
+func normalizeDataPathAddr(value string) (string, error) {
+	addr, err := normalizeIPv6Addr(value)
+	if err != nil {
+		return "", err
+	}
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return "", errors.Errorf("invalid address %q: a port cannot be specified for the data path address", value)
+	}
+	return addr, nil
+}
+
 // ExternalCAOption is a Value type for parsing external CA specifications.
 type ExternalCAOption struct {
 	values []*swarm.ExternalCA
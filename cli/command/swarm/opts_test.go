@@ -1,6 +1,8 @@
 package swarm
 
 import (
+	"errors"
+	"net"
 	"testing"
 
 	"gotest.tools/v3/assert"
@@ -26,6 +28,43 @@ func TestNodeAddrOptionSetHostOnlyIPv6(t *testing.T) {
 	assert.Check(t, is.Equal("[::1]:2377", opt.Value()))
 }
 
+func TestNodeAddrOptionSetBracketedIPv6(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected string
+	}{
+		{value: "[::1]", expected: "[::1]:2377"},
+		{value: "[::1]:", expected: "[::1]:2377"},
+		{value: "[::1]:4567", expected: "[::1]:4567"},
+		{value: "[fe80::1]:2377", expected: "[fe80::1]:2377"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			opt := NewListenAddrOption()
+			assert.NilError(t, opt.Set(tc.value))
+			assert.Check(t, is.Equal(tc.expected, opt.Value()))
+		})
+	}
+}
+
+func TestNodeAddrOptionSetInvalidIPv6(t *testing.T) {
+	testCases := []struct {
+		value         string
+		expectedError string
+	}{
+		{value: "[::1", expectedError: `invalid address "[::1": missing ']' in IPv6 address`},
+		{value: "[eth0]:2377", expectedError: `invalid address "[eth0]:2377": "eth0" is not a valid IPv6 address`},
+		{value: "[127.0.0.1]:2377", expectedError: `invalid address "[127.0.0.1]:2377": "127.0.0.1" is not a valid IPv6 address`},
+		{value: "[::1]2377", expectedError: `invalid address "[::1]2377": expected a port after the IPv6 address`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			opt := NewListenAddrOption()
+			assert.Error(t, opt.Set(tc.value), tc.expectedError)
+		})
+	}
+}
+
 func TestNodeAddrOptionSetPortOnly(t *testing.T) {
 	opt := NewListenAddrOption()
 	assert.NilError(t, opt.Set(":4545"))
@@ -37,6 +76,67 @@ func TestNodeAddrOptionSetInvalidFormat(t *testing.T) {
 	assert.Error(t, opt.Set("http://localhost:4545"), "invalid proto, expected tcp: http://localhost:4545")
 }
 
+func TestNormalizeDataPathAddr(t *testing.T) {
+	for _, tc := range []struct{ value, expected string }{
+		{value: "", expected: ""},
+		{value: "eth0", expected: "eth0"},
+		{value: "10.0.0.1", expected: "10.0.0.1"},
+		{value: "::1", expected: "::1"},
+		{value: "[::1]", expected: "::1"},
+	} {
+		addr, err := normalizeDataPathAddr(tc.value)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(addr, tc.expected))
+	}
+
+	for _, tc := range []struct{ value, expectedErr string }{
+		{value: "10.0.0.1:4789", expectedErr: `invalid address "10.0.0.1:4789": a port cannot be specified for the data path address`},
+		{value: "[::1]:4789", expectedErr: `invalid address "[::1]:4789": a port cannot be specified for the data path address`},
+		{value: "[eth0]", expectedErr: `invalid address "[eth0]": "eth0" is not a valid IPv6 address`},
+	} {
+		_, err := normalizeDataPathAddr(tc.value)
+		assert.Check(t, is.Error(err, tc.expectedErr))
+	}
+}
+
+func TestResolveAutoAddr(t *testing.T) {
+	defer func(orig func(string) ([]net.Addr, error)) { interfaceAddrs = orig }(interfaceAddrs)
+	interfaceAddrs = func(name string) ([]net.Addr, error) {
+		switch name {
+		case "eth0":
+			return []net.Addr{
+				&net.IPNet{IP: net.ParseIP("127.0.0.1"), Mask: net.CIDRMask(8, 32)},
+				&net.IPNet{IP: net.ParseIP("fd00::1"), Mask: net.CIDRMask(64, 128)},
+				&net.IPNet{IP: net.ParseIP("192.168.1.10"), Mask: net.CIDRMask(24, 32)},
+			}, nil
+		case "eth1":
+			return []net.Addr{&net.IPNet{IP: net.ParseIP("fd00::2"), Mask: net.CIDRMask(64, 128)}}, nil
+		default:
+			return nil, errors.New("no such network interface")
+		}
+	}
+
+	for _, tc := range []struct{ value, expected string }{
+		{value: "", expected: ""},
+		{value: "eth0", expected: "eth0"},
+		{value: "10.0.0.1:2377", expected: "10.0.0.1:2377"},
+		{value: "auto:eth0", expected: "192.168.1.10"},
+	} {
+		addr, err := resolveAutoAddr(tc.value)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(addr, tc.expected))
+	}
+
+	for _, tc := range []struct{ value, expectedErr string }{
+		{value: "auto:", expectedErr: `invalid address "auto:": missing interface name`},
+		{value: "auto:eth1", expectedErr: `invalid address "auto:eth1": interface "eth1" has no non-loopback IPv4 address`},
+		{value: "auto:eth9", expectedErr: `invalid address "auto:eth9": no such network interface`},
+	} {
+		_, err := resolveAutoAddr(tc.value)
+		assert.Check(t, is.Error(err, tc.expectedErr))
+	}
+}
+
 func TestExternalCAOptionErrors(t *testing.T) {
 	testCases := []struct {
 		externalCA    string
@@ -18,31 +18,49 @@
 
 // ConfigFile ~/.docker/config.json file info
 type ConfigFile struct {
-	AuthConfigs          map[string]types.AuthConfig  `json:"auths"`
-	HTTPHeaders          map[string]string            `json:"HttpHeaders,omitempty"`
-	PsFormat             string                       `json:"psFormat,omitempty"`
-	ImagesFormat         string                       `json:"imagesFormat,omitempty"`
-	NetworksFormat       string                       `json:"networksFormat,omitempty"`
-	PluginsFormat        string                       `json:"pluginsFormat,omitempty"`
-	VolumesFormat        string                       `json:"volumesFormat,omitempty"`
-	StatsFormat          string                       `json:"statsFormat,omitempty"`
-	DetachKeys           string                       `json:"detachKeys,omitempty"`
-	CredentialsStore     string                       `json:"credsStore,omitempty"`
-	CredentialHelpers    map[string]string            `json:"credHelpers,omitempty"`
-	Filename             string                       `json:"-"` // Note: for internal use only
-	ServiceInspectFormat string                       `json:"serviceInspectFormat,omitempty"`
-	ServicesFormat       string                       `json:"servicesFormat,omitempty"`
-	TasksFormat          string                       `json:"tasksFormat,omitempty"`
-	SecretFormat         string                       `json:"secretFormat,omitempty"`
-	ConfigFormat         string                       `json:"configFormat,omitempty"`
-	NodesFormat          string                       `json:"nodesFormat,omitempty"`
-	PruneFilters         []string                     `json:"pruneFilters,omitempty"`
-	Proxies              map[string]ProxyConfig       `json:"proxies,omitempty"`
-	CurrentContext       string                       `json:"currentContext,omitempty"`
-	CLIPluginsExtraDirs  []string                     `json:"cliPluginsExtraDirs,omitempty"`
-	Plugins              map[string]map[string]string `json:"plugins,omitempty"`
-	Aliases              map[string]string            `json:"aliases,omitempty"`
-	Features             map[string]string            `json:"features,omitempty"`
+	AuthConfigs               map[string]types.AuthConfig  `json:"auths"`
+	HTTPHeaders               map[string]string            `json:"HttpHeaders,omitempty"`
+	PsFormat                  string                       `json:"psFormat,omitempty"`
+	ImagesFormat              string                       `json:"imagesFormat,omitempty"`
+	NetworksFormat            string                       `json:"networksFormat,omitempty"`
+	PluginsFormat             string                       `json:"pluginsFormat,omitempty"`
+	VolumesFormat             string                       `json:"volumesFormat,omitempty"`
+	StatsFormat               string                       `json:"statsFormat,omitempty"`
+	DetachKeys                string                       `json:"detachKeys,omitempty"`
+	CredentialsStore          string                       `json:"credsStore,omitempty"`
+	CredentialHelpers         map[string]string            `json:"credHelpers,omitempty"`
+	Filename                  string                       `json:"-"` // Note: for internal use only
+	ServiceInspectFormat      string                       `json:"serviceInspectFormat,omitempty"`
+	ServicesFormat            string                       `json:"servicesFormat,omitempty"`
+	TasksFormat               string                       `json:"tasksFormat,omitempty"`
+	SecretFormat              string                       `json:"secretFormat,omitempty"`
+	ConfigFormat              string                       `json:"configFormat,omitempty"`
+	NodesFormat               string                       `json:"nodesFormat,omitempty"`
+	PruneFilters              []string                     `json:"pruneFilters,omitempty"`
+	Proxies                   map[string]ProxyConfig       `json:"proxies,omitempty"`
+	CurrentContext            string                       `json:"currentContext,omitempty"`
+	CLIPluginsExtraDirs       []string                     `json:"cliPluginsExtraDirs,omitempty"`
+	CLIPluginsMetadataTimeout string                       `json:"cliPluginsMetadataTimeout,omitempty"`
+	CLIPluginsMaxRuntime      string                       `json:"cliPluginsMaxRuntime,omitempty"`
+	CLIPluginsAliases         map[string]string            `json:"cliPluginsAliases,omitempty"`
+	CLIPluginsManifestURL     string                       `json:"cliPluginsManifestURL,omitempty"`
+	CLIPluginsIndexURL        string                       `json:"cliPluginsIndexURL,omitempty"`
+	CLIPluginsPrefixStderr    bool                         `json:"cliPluginsPrefixStderr,omitempty"`
+	CLIPluginsChecksums       map[string]string            `json:"cliPluginsChecksums,omitempty"`
+	CLIPluginsExecWrapper     []string                     `json:"cliPluginsExecWrapper,omitempty"`
+	CLIPluginsRecordUsage     bool                         `json:"cliPluginsRecordUsage,omitempty"`
+	CLIPluginsStrictShadowing bool                         `json:"cliPluginsStrictShadowing,omitempty"`
+	CLIPluginsEnvAllowlist    []string                     `json:"cliPluginsEnvAllowlist,omitempty"`
+	CLIPluginsMetadataCache   bool                         `json:"cliPluginsMetadataCache,omitempty"`
+	CLIPluginsSignaturePolicy string                       `json:"cliPluginsSignaturePolicy,omitempty"`
+	CLIPluginsTrustedKeys     []string                     `json:"cliPluginsTrustedKeys,omitempty"`
+	CLIPluginsUpdateCheck     bool                         `json:"cliPluginsUpdateCheck,omitempty"`
+	CLIPluginsPrefixes        []string                     `json:"cliPluginsPrefixes,omitempty"`
+	CLIPluginsPathAllowlist   []string                     `json:"cliPluginsPathAllowlist,omitempty"`
+	CLIPluginsContainerSocket string                       `json:"cliPluginsContainerSocket,omitempty"`
+	Plugins                   map[string]map[string]string `json:"plugins,omitempty"`
+	Aliases                   map[string]string            `json:"aliases,omitempty"`
+	Features                  map[string]string            `json:"features,omitempty"`
 
 	// Deprecated: experimental CLI features are always enabled and this field is no longer used. Use [Features] instead for optional features. This field will be removed in a future release.
 	Experimental string `json:"experimental,omitempty"`
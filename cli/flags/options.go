@@ -64,6 +64,9 @@ type ClientOptions struct {
 	TLSOptions *tlsconfig.Options
 	Context    string
 	ConfigDir  string
+
+	// DisablePlugins disables discovering and running CLI plugins.
+	DisablePlugins bool
 }
 
 // NewClientOptions returns a new ClientOptions.
@@ -80,6 +83,7 @@ func (o *ClientOptions) InstallFlags(flags *pflag.FlagSet) {
 
 	flags.StringVar(&o.ConfigDir, "config", configDir, "Location of client config files")
 	flags.BoolVarP(&o.Debug, "debug", "D", false, "Enable debug mode")
+	flags.BoolVar(&o.DisablePlugins, "disable-plugins", false, "Disable CLI plugins")
 	flags.StringVarP(&o.LogLevel, "log-level", "l", "info", `Set the logging level ("debug", "info", "warn", "error", "fatal")`)
 	flags.BoolVar(&o.TLS, "tls", dockerTLS, "Use TLS; implied by --tlsverify")
 	flags.BoolVar(&o.TLSVerify, FlagTLSVerify, dockerTLSVerify, "Use TLS and verify the remote")
@@ -3,6 +3,7 @@
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
@@ -25,6 +26,13 @@ type RegistryClient interface {
 	PutManifest(ctx context.Context, ref reference.Named, manifest distribution.Manifest) (digest.Digest, error)
 }
 
+// BlobGetter is implemented by RegistryClients that can fetch the content of
+// a blob. It is a separate interface so that existing implementations of
+// RegistryClient do not have to implement it.
+type BlobGetter interface {
+	GetBlob(ctx context.Context, ref reference.Canonical) (io.ReadCloser, error)
+}
+
 // NewRegistryClient returns a new RegistryClient with a resolver
 func NewRegistryClient(resolver AuthConfigResolver, userAgent string, insecure bool) RegistryClient {
 	return &client{
@@ -54,6 +62,16 @@ func (err ErrBlobCreated) Error() string {
 		err.From, err.Target)
 }
 
+// ErrManifestList is returned by GetManifest if the reference refers to a
+// manifest list, which must be fetched with GetManifestList instead.
+type ErrManifestList struct {
+	Ref reference.Named
+}
+
+func (err ErrManifestList) Error() string {
+	return fmt.Sprintf("%s is a manifest list", err.Ref)
+}
+
 // httpProtoError returned if attempting to use TLS with a non-TLS registry
 type httpProtoError struct {
 	cause error
@@ -185,6 +203,22 @@ func (c *client) GetManifestList(ctx context.Context, ref reference.Named) ([]ma
 	return result, err
 }
 
+// GetBlob returns a reader for the content of the blob referenced by ref. The
+// content is not verified against the digest; it is up to the caller to do so.
+func (c *client) GetBlob(ctx context.Context, ref reference.Canonical) (io.ReadCloser, error) {
+	var result io.ReadCloser
+	fetch := func(ctx context.Context, repo distribution.Repository, _ reference.Named) (bool, error) {
+		var err error
+		result, err = repo.Blobs(ctx).Open(ctx, ref.Digest())
+		return result != nil, err
+	}
+
+	if err := c.iterateEndpoints(ctx, ref, fetch); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func getManifestOptionsFromReference(ref reference.Named) (digest.Digest, []distribution.ManifestServiceOption, error) {
 	if tagged, isTagged := ref.(reference.NamedTagged); isTagged {
 		tag := tagged.Tag()
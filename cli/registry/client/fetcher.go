@@ -35,7 +35,7 @@ func fetchManifest(ctx context.Context, repo distribution.Repository, ref refere
 	case *ocischema.DeserializedManifest:
 		return pullManifestOCISchema(ctx, ref, repo, *v)
 	case *manifestlist.DeserializedManifestList:
-		return types.ImageManifest{}, errors.Errorf("%s is a manifest list", ref)
+		return types.ImageManifest{}, ErrManifestList{Ref: ref}
 	}
 	return types.ImageManifest{}, errors.Errorf("%s is not a manifest", ref)
 }
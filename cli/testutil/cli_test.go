@@ -0,0 +1,44 @@
+package testutil
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func newHelloCommand(dockerCLI command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use: "hello",
+		RunE: func(*cobra.Command, []string) error {
+			who, _ := dockerCLI.ConfigFile().PluginConfig("hello", "who")
+			if who == "" {
+				who = "World"
+			}
+			_, _ = fmt.Fprintln(dockerCLI.Err(), "WARNING: greeting the world")
+			_, _ = fmt.Fprintf(dockerCLI.Out(), "Hello %s!\n", who)
+			return nil
+		},
+	}
+}
+
+func TestFakeCli(t *testing.T) {
+	cli := NewFakeCli(nil)
+	assert.Check(t, is.Equal(cli.CurrentContext(), command.DefaultContextName))
+
+	cmd := newHelloCommand(cli)
+	cmd.SetArgs([]string{})
+	assert.NilError(t, cmd.Execute())
+	AssertGoldenOutput(t, cli, "hello-stdout.golden", "hello-stderr.golden")
+
+	cli.ResetOutputBuffers()
+	cli.SetConfigFile(&configfile.ConfigFile{
+		Plugins: map[string]map[string]string{"hello": {"who": "Moby"}},
+	})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "Hello Moby!\n"))
+}
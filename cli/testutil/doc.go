@@ -0,0 +1,12 @@
+// Package testutil provides helpers to write unit tests for commands that
+// are written against the [command.Cli] interface, such as the commands of
+// CLI plugins. It provides a fake CLI that captures the output of commands,
+// and uses the API client that is passed to it, which is usually a fake
+// client that embeds [client.Client] and overrides the methods that the
+// command under test uses. The [builders] package helps creating the API
+// objects that are returned by such fake clients.
+//
+// [command.Cli]: https://pkg.go.dev/github.com/docker/cli/cli/command#Cli
+// [client.Client]: https://pkg.go.dev/github.com/docker/docker/client#Client
+// [builders]: https://pkg.go.dev/github.com/docker/cli/cli/testutil/builders
+package testutil
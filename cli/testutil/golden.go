@@ -0,0 +1,22 @@
+package testutil
+
+import (
+	"testing"
+
+	"gotest.tools/v3/golden"
+)
+
+// AssertGoldenOutput asserts that the output that was written to the stdout
+// and stderr of cli matches the given golden files, relative to the "testdata"
+// directory of the package under test. An empty file name skips comparing
+// that stream. Run the tests with the "-update" flag to update the golden
+// files with the actual output.
+func AssertGoldenOutput(t *testing.T, cli *FakeCli, stdoutFile, stderrFile string) {
+	t.Helper()
+	if stdoutFile != "" {
+		golden.Assert(t, cli.OutBuffer().String(), stdoutFile)
+	}
+	if stderrFile != "" {
+		golden.Assert(t, cli.ErrBuffer().String(), stderrFile)
+	}
+}
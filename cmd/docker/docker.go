@@ -197,8 +197,9 @@ func setupHelpCommand(dockerCli command.Cli, rootCmd, helpCmd *cobra.Command) {
 	helpCmd.Run = nil
 	helpCmd.RunE = func(c *cobra.Command, args []string) error {
 		if len(args) > 0 {
-			helpcmd, err := pluginmanager.PluginRunCommand(dockerCli, args[0], rootCmd)
+			helpcmd, warnings, err := pluginmanager.PluginRunCommandWithWarnings(dockerCli, args[0], rootCmd)
 			if err == nil {
+				printPluginWarnings(dockerCli, warnings)
 				return helpcmd.Run()
 			}
 			if !pluginmanager.IsNotFound(err) {
@@ -220,10 +221,11 @@ func tryRunPluginHelp(dockerCli command.Cli, ccmd *cobra.Command, cargs []string
 	if err != nil {
 		return err
 	}
-	helpcmd, err := pluginmanager.PluginRunCommand(dockerCli, cmd.Name(), root)
+	helpcmd, warnings, err := pluginmanager.PluginRunCommandWithWarnings(dockerCli, cmd.Name(), root)
 	if err != nil {
 		return err
 	}
+	printPluginWarnings(dockerCli, warnings)
 	return helpcmd.Run()
 }
 
@@ -291,21 +293,32 @@ func setValidateArgs(dockerCli command.Cli, cmd *cobra.Command) {
 	})
 }
 
+// printPluginWarnings prints the warnings about a plugin that is run.
+func printPluginWarnings(dockerCli command.Cli, warnings []error) {
+	for _, w := range warnings {
+		_, _ = fmt.Fprintf(dockerCli.Err(), "WARNING: %v\n", w)
+	}
+}
+
 func tryPluginRun(ctx context.Context, dockerCli command.Cli, cmd *cobra.Command, subcommand string, envs []string) error {
-	plugincmd, err := pluginmanager.PluginRunCommand(dockerCli, subcommand, cmd)
+	plugincmd, plugin, warnings, err := pluginmanager.ResolvePluginCommand(dockerCli, subcommand, cmd)
 	if err != nil {
 		return err
 	}
+	printPluginWarnings(dockerCli, warnings)
 
 	// Establish the plugin socket, adding it to the environment under a
-	// well-known key if successful.
-	srv, err := socket.NewPluginServer(nil)
+	// well-known key if successful. Plugins use the socket to report the
+	// progress of long-running operations, which is rendered on stderr.
+	progress := pluginmanager.NewProgressRenderer(dockerCli.Err())
+	srv, err := socket.NewPluginServer(socket.HandleProgress(progress.Render))
 	if err == nil {
 		plugincmd.Env = append(plugincmd.Env, socket.EnvKey+"="+srv.Addr().String())
 		defer func() {
 			// Close the server when plugin execution is over, so that in case
 			// it's still open, any sockets on the filesystem are cleaned up.
 			_ = srv.Close()
+			progress.Finish()
 		}()
 	}
 
@@ -372,19 +385,40 @@ func tryPluginRun(ctx context.Context, dockerCli command.Cli, cmd *cobra.Command
 		}
 	}()
 
-	if err := plugincmd.Run(); err != nil {
+	// Check for a newer version of the plugin while the plugin runs. The
+	// check is cancelled if it is still running shortly after the plugin
+	// exited.
+	waitUpdateCheck := pluginmanager.StartPluginUpdateCheck(ctx, dockerCli, *plugin)
+	defer waitUpdateCheck()
+
+	pluginmanager.NotifyPluginObserver(pluginmanager.PluginEvent{Name: plugin.Name, Path: plugin.Path, Phase: pluginmanager.PluginPhaseExec})
+	err = pluginmanager.RunPluginCommand(dockerCli, subcommand, plugincmd)
+	if plugincmd.Process != nil {
+		// Only count runs in which the plugin was started.
+		pluginmanager.RecordPluginUsage(dockerCli, plugin.Name)
+	}
+	if err != nil {
 		statusCode := 1
 		exitErr, ok := err.(*exec.ExitError)
 		if !ok {
+			pluginmanager.NotifyPluginObserver(pluginmanager.PluginEvent{Name: plugin.Name, Path: plugin.Path, Phase: pluginmanager.PluginPhaseExit, ExitCode: -1, Err: err})
 			return err
 		}
 		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok {
 			statusCode = ws.ExitStatus()
 		}
+		pluginmanager.NotifyPluginObserver(pluginmanager.PluginEvent{Name: plugin.Name, Path: plugin.Path, Phase: pluginmanager.PluginPhaseExit, ExitCode: statusCode, Err: err})
 		return cli.StatusError{
 			StatusCode: statusCode,
 		}
 	}
+	pluginmanager.NotifyPluginObserver(pluginmanager.PluginEvent{Name: plugin.Name, Path: plugin.Path, Phase: pluginmanager.PluginPhaseExit})
+	waitUpdateCheck()
+	if dockerCli.Err().IsTerminal() {
+		if hint := pluginmanager.PluginUpdateHint(dockerCli, *plugin); hint != "" {
+			_, _ = fmt.Fprintln(dockerCli.Err(), "\n"+hint)
+		}
+	}
 	return nil
 }
 
@@ -427,6 +461,9 @@ func runDocker(ctx context.Context, dockerCli *command.DockerCli) error {
 	if err != nil {
 		return err
 	}
+	if disablePlugins, _ := cmd.Flags().GetBool("disable-plugins"); disablePlugins {
+		pluginmanager.SetPluginsDisabled(true)
+	}
 
 	if err := tcmd.Initialize(command.WithEnableGlobalMeterProvider(), command.WithEnableGlobalTracerProvider()); err != nil {
 		return err
@@ -467,13 +504,16 @@ func runDocker(ctx context.Context, dockerCli *command.DockerCli) error {
 		subCommand = ccmd
 		if err != nil || pluginmanager.IsPluginCommand(ccmd) {
 			err := tryPluginRun(ctx, dockerCli, cmd, args[0], envs)
+			if !pluginmanager.IsNotFound(err) && dockerCli.HooksEnabled() {
+				pluginmanager.RunPluginEventHooks(ctx, dockerCli, cmd, args, getExitCode(err), err)
+			}
 			if err == nil {
 				if ccmd != nil && dockerCli.Out().IsTerminal() && dockerCli.HooksEnabled() {
 					pluginmanager.RunPluginHooks(ctx, dockerCli, cmd, ccmd, args)
 				}
 				return nil
 			}
-			if !pluginmanager.IsNotFound(err) {
+			if !pluginmanager.IsNotFound(err) || pluginmanager.IsPluginsDisabled(err) {
 				// For plugin not found we fall through to
 				// cmd.Execute() which deals with reporting
 				// "command not found" in a consistent way.
@@ -489,16 +529,27 @@ func runDocker(ctx context.Context, dockerCli *command.DockerCli) error {
 	// We've parsed global args already, so reset args to those
 	// which remain.
 	cmd.SetArgs(args)
+	prevContext := dockerCli.ConfigFile().CurrentContext
 	err = cmd.ExecuteContext(ctx)
 
-	// If the command is being executed in an interactive terminal
-	// and hook are enabled, run the plugin hooks.
-	if subCommand != nil && dockerCli.Out().IsTerminal() && dockerCli.HooksEnabled() {
-		var errMessage string
-		if err != nil {
-			errMessage = err.Error()
+	if subCommand != nil && dockerCli.HooksEnabled() {
+		pluginmanager.RunCLICommandEventHooks(ctx, dockerCli, cmd, subCommand, getExitCode(err), err)
+		if currentContext := dockerCli.ConfigFile().CurrentContext; currentContext != prevContext {
+			if currentContext == "" {
+				currentContext = command.DefaultContextName
+			}
+			pluginmanager.RunContextSwitchHooks(ctx, dockerCli, cmd, currentContext)
+		}
+
+		// If the command is being executed in an interactive terminal,
+		// run the plugin hooks to print next steps.
+		if dockerCli.Out().IsTerminal() {
+			var errMessage string
+			if err != nil {
+				errMessage = err.Error()
+			}
+			pluginmanager.RunCLICommandHooks(ctx, dockerCli, cmd, subCommand, errMessage)
 		}
-		pluginmanager.RunCLICommandHooks(ctx, dockerCli, cmd, subCommand, errMessage)
 	}
 
 	return err
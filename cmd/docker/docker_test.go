@@ -10,12 +10,18 @@
 	"testing"
 	"time"
 
+	"github.com/docker/cli/cli"
+	pluginmanager "github.com/docker/cli/cli-plugins/manager"
 	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/config/configfile"
 	"github.com/docker/cli/cli/debug"
 	platformsignals "github.com/docker/cli/cmd/docker/internal/signals"
+	"github.com/docker/cli/internal/test"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
 )
 
 func TestClientDebugEnabled(t *testing.T) {
@@ -108,3 +114,105 @@ func TestUserTerminatedError(t *testing.T) {
 
 	assert.Equal(t, getExitCode(context.Cause(notifyCtx)), 143)
 }
+
+func TestTryPluginRunObserver(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+exit 3`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	var events []pluginmanager.PluginEvent
+	pluginmanager.SetPluginObserver(func(event pluginmanager.PluginEvent) {
+		events = append(events, event)
+	})
+	defer pluginmanager.SetPluginObserver(nil)
+
+	fakeCli := test.NewFakeCli(nil)
+	fakeCli.SetConfigFile(&configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}})
+
+	err := tryPluginRun(context.TODO(), fakeCli, &cobra.Command{}, "aaa", nil)
+	assert.Check(t, is.DeepEqual(err, cli.StatusError{StatusCode: 3}))
+
+	pluginPath := dir.Join("docker-aaa")
+	expected := []pluginmanager.PluginEvent{
+		{Name: "aaa", Path: pluginPath, Phase: pluginmanager.PluginPhaseMetadata},
+		{Name: "aaa", Path: pluginPath, Phase: pluginmanager.PluginPhaseExec},
+		{Name: "aaa", Path: pluginPath, Phase: pluginmanager.PluginPhaseExit, ExitCode: 3},
+	}
+	assert.Assert(t, is.Len(events, len(expected)))
+	for i, event := range events {
+		assert.Check(t, is.Equal(event.Name, expected[i].Name))
+		assert.Check(t, is.Equal(event.Path, expected[i].Path))
+		assert.Check(t, is.Equal(event.Phase, expected[i].Phase))
+		assert.Check(t, is.Equal(event.ExitCode, expected[i].ExitCode))
+	}
+}
+
+func TestTryPluginRunObserverExecWrapper(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-aaa", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'`, fs.WithMode(0o777)),
+		fs.WithFile("wrapper", `#!/bin/sh
+exec "$@"`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	var paths []string
+	pluginmanager.SetPluginObserver(func(event pluginmanager.PluginEvent) {
+		paths = append(paths, event.Path)
+	})
+	defer pluginmanager.SetPluginObserver(nil)
+
+	fakeCli := test.NewFakeCli(nil)
+	fakeCli.SetConfigFile(&configfile.ConfigFile{
+		CLIPluginsExtraDirs:   []string{dir.Path()},
+		CLIPluginsExecWrapper: []string{dir.Join("wrapper")},
+	})
+
+	err := tryPluginRun(context.TODO(), fakeCli, &cobra.Command{}, "aaa", nil)
+	assert.NilError(t, err)
+
+	// Events report the path of the plugin, not that of the wrapper.
+	pluginPath := dir.Join("docker-aaa")
+	assert.Check(t, is.DeepEqual(paths, []string{pluginPath, pluginPath, pluginPath}))
+}
+
+func TestTryPluginRunRecordsUsage(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("plugins",
+			fs.WithFile("docker-aaa", `#!/bin/sh
+if [ "$1" = "docker-cli-plugin-metadata" ]; then
+	echo '{"SchemaVersion":"0.1.0","Vendor":"e2e-testing"}'
+	exit 0
+fi
+exit 3`, fs.WithMode(0o777)),
+		),
+	)
+	defer dir.Remove()
+
+	fakeCli := test.NewFakeCli(nil)
+	fakeCli.SetConfigFile(&configfile.ConfigFile{
+		Filename:              dir.Join("config.json"),
+		CLIPluginsExtraDirs:   []string{dir.Join("plugins")},
+		CLIPluginsRecordUsage: true,
+	})
+
+	// Runs are counted once the plugin started, whether or not it succeeded.
+	err := tryPluginRun(context.TODO(), fakeCli, &cobra.Command{}, "aaa", nil)
+	assert.Check(t, is.DeepEqual(err, cli.StatusError{StatusCode: 3}))
+	usage, err := pluginmanager.PluginUsage(fakeCli)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(usage["aaa"].Invocations, 1))
+
+	// Plugins that can't be found are not counted.
+	err = tryPluginRun(context.TODO(), fakeCli, &cobra.Command{}, "bbb", nil)
+	assert.Check(t, pluginmanager.IsNotFound(err))
+	usage, err = pluginmanager.PluginUsage(fakeCli)
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(usage, 1))
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/docker/cli/cli-plugins/metadata"
+	"github.com/docker/cli/cli-plugins/plugin"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	var dockerCli command.Cli
+	plugin.Run(func(c command.Cli) *cobra.Command {
+		dockerCli = c
+		return RootCmd(c)
+	},
+		metadata.Metadata{
+			SchemaVersion:        "0.1.0",
+			Vendor:               "Docker Inc.",
+			Version:              "testing",
+			SkipPersistentPreRun: true,
+		},
+		plugin.WithShutdownHook(func() {
+			_, _ = fmt.Fprintln(dockerCli.Out(), "shutdown hook called")
+		}),
+	)
+}
+
+func RootCmd(dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "shutdown",
+		Short: "testing plugin that cleans up after receiving a signal",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			select {
+			case <-cmd.Context().Done():
+				_, _ = fmt.Fprintln(dockerCli.Out(), "context cancelled")
+			case <-time.After(3 * time.Second):
+				_, _ = fmt.Fprintln(dockerCli.Err(), "exit after 3 seconds")
+			}
+			return nil
+		},
+	}
+}
@@ -244,3 +244,32 @@ func TestPluginSocketCommunication(t *testing.T) {
 		})
 	})
 }
+
+// TestPluginShutdownHook executes a plugin that cancels the context of its
+// command when it receives a signal, and runs a shutdown hook before exiting.
+func TestPluginShutdownHook(t *testing.T) {
+	run, _, cleanup := prepare(t)
+	defer cleanup()
+
+	cmd := run("shutdown")
+	command := exec.Command(cmd.Command[0], cmd.Command[1:]...)
+
+	ptmx, err := pty.Start(command)
+	assert.NilError(t, err, "failed to launch command with fake TTY")
+
+	// send a SIGINT to the process group after 1 second, simulating a
+	// CTRL-C from a TTY, so that the plugin receives the signal directly
+	go func() {
+		<-time.After(time.Second)
+		err := syscall.Kill(-command.Process.Pid, syscall.SIGINT)
+		assert.NilError(t, err, "failed to signal process group")
+	}()
+	out, err := io.ReadAll(ptmx)
+	if err != nil && !strings.Contains(err.Error(), "input/output error") {
+		t.Fatal("failed to get command output")
+	}
+
+	// the plugin's context is cancelled instead of the plugin being
+	// terminated, and the shutdown hook runs after the command returned
+	assert.Equal(t, string(out), "context cancelled\r\nshutdown hook called\r\n")
+}
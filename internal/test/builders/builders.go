@@ -0,0 +1,89 @@
+// Package builders forwards to the builders in [builders], which are used by
+// the tests in this module.
+//
+// [builders]: https://pkg.go.dev/github.com/docker/cli/cli/testutil/builders
+package builders
+
+import "github.com/docker/cli/cli/testutil/builders"
+
+// The builders are provided by the public testutil package, so that they can
+// be used by CLI plugins; these are kept for the tests in this module.
+var (
+	Config          = builders.Config
+	ConfigLabels    = builders.ConfigLabels
+	ConfigName      = builders.ConfigName
+	ConfigID        = builders.ConfigID
+	ConfigVersion   = builders.ConfigVersion
+	ConfigCreatedAt = builders.ConfigCreatedAt
+	ConfigUpdatedAt = builders.ConfigUpdatedAt
+	ConfigData      = builders.ConfigData
+
+	Container = builders.Container
+	WithLabel = builders.WithLabel
+	WithName  = builders.WithName
+	WithPort  = builders.WithPort
+	WithSize  = builders.WithSize
+	IP        = builders.IP
+	TCP       = builders.TCP
+	UDP       = builders.UDP
+
+	NetworkResource       = builders.NetworkResource
+	NetworkResourceName   = builders.NetworkResourceName
+	NetworkResourceID     = builders.NetworkResourceID
+	NetworkResourceDriver = builders.NetworkResourceDriver
+	NetworkResourceScope  = builders.NetworkResourceScope
+
+	Node          = builders.Node
+	NodeID        = builders.NodeID
+	NodeName      = builders.NodeName
+	NodeLabels    = builders.NodeLabels
+	Hostname      = builders.Hostname
+	Leader        = builders.Leader
+	Manager       = builders.Manager
+	ManagerStatus = builders.ManagerStatus
+	EngineVersion = builders.EngineVersion
+
+	Secret          = builders.Secret
+	SecretLabels    = builders.SecretLabels
+	SecretName      = builders.SecretName
+	SecretDriver    = builders.SecretDriver
+	SecretID        = builders.SecretID
+	SecretVersion   = builders.SecretVersion
+	SecretCreatedAt = builders.SecretCreatedAt
+	SecretUpdatedAt = builders.SecretUpdatedAt
+
+	Service           = builders.Service
+	ServiceID         = builders.ServiceID
+	ServiceName       = builders.ServiceName
+	ServiceLabels     = builders.ServiceLabels
+	GlobalService     = builders.GlobalService
+	ReplicatedService = builders.ReplicatedService
+	ServiceStatus     = builders.ServiceStatus
+	ServiceImage      = builders.ServiceImage
+	ServicePort       = builders.ServicePort
+
+	Swarm    = builders.Swarm
+	Autolock = builders.Autolock
+
+	Task             = builders.Task
+	TaskID           = builders.TaskID
+	TaskName         = builders.TaskName
+	TaskServiceID    = builders.TaskServiceID
+	TaskNodeID       = builders.TaskNodeID
+	TaskDesiredState = builders.TaskDesiredState
+	TaskSlot         = builders.TaskSlot
+	WithStatus       = builders.WithStatus
+	TaskStatus       = builders.TaskStatus
+	Timestamp        = builders.Timestamp
+	StatusErr        = builders.StatusErr
+	TaskState        = builders.TaskState
+	PortStatus       = builders.PortStatus
+	WithTaskSpec     = builders.WithTaskSpec
+	TaskSpec         = builders.TaskSpec
+	TaskImage        = builders.TaskImage
+
+	Volume       = builders.Volume
+	VolumeLabels = builders.VolumeLabels
+	VolumeName   = builders.VolumeName
+	VolumeDriver = builders.VolumeDriver
+)
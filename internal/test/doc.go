@@ -1,4 +1,6 @@
 // Package test is a test-only package that can be used by other cli package to write unit test.
 //
 // It as an internal package and cannot be used outside of github.com/docker/cli package.
+// Use [github.com/docker/cli/cli/testutil] to write unit tests for commands
+// outside of this module, such as the commands of CLI plugins.
 package test